@@ -12,6 +12,7 @@ import (
 	"os/signal"
 	"path/filepath"
 	"time"
+	_ "time/tzdata" // user time zones must not depend on the host zoneinfo
 
 	"github.com/Shopify/sarama"
 	"github.com/go-redis/redis/v8"
@@ -23,6 +24,8 @@ import (
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/common/database/postgres"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/common/utils"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/config"
+	debtRepository "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/debt/repository/postgres"
+	debtUseCase "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/debt/usecase"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense"
 	expCache "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense/cache"
 	expenseRepository "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense/repository/postgres"
@@ -30,8 +33,15 @@ import (
 	exchangeRatesRepo "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/exrate/repository/postgres"
 	exrateUseCase "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/exrate/usecase"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/grpc/reports"
+	incomeRepository "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/income/repository/postgres"
+	incomeUseCase "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/income/usecase"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/kafka"
+	ledgerRepository "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/ledger/repository/postgres"
+	ledgerUseCase "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/ledger/usecase"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/providers"
+	recurringRepository "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/recurring/repository/postgres"
+	recurringUseCase "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/recurring/usecase"
 	userRepository "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/user/repository/postgres"
 	userUseCase "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/user/usecase"
 	"go.uber.org/zap"
@@ -53,6 +63,14 @@ func readConfig(path string) (*config.Service, error) {
 	return config.NewFromReader(bytes.NewReader(rawYAML))
 }
 
+func readCategoryRules(path string) ([]models.CategoryRule, error) {
+	rawYAML, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading category rules file")
+	}
+	return config.NewCategoryRulesFromReader(bytes.NewReader(rawYAML))
+}
+
 func main() {
 	flag.Parse()
 
@@ -173,6 +191,15 @@ func main() {
 	if err != nil {
 		zapLogger.Fatal("Failed to create expenses usecase", zap.Error(err))
 	}
+	if path := cfg.Values().CategoryRulesPath; path != "" {
+		rules, err := readCategoryRules(path)
+		if err != nil {
+			zapLogger.Fatal("Failed to read default category rules", zap.Error(err))
+		}
+		if err := regularExpUC.SetDefaultCategoryRules(rules); err != nil {
+			zapLogger.Fatal("Failed to set default category rules", zap.Error(err))
+		}
+	}
 	if kafkaCfg := cfg.Values().KafkaConfig; kafkaCfg != nil {
 		kafkaAsyncProducer, err := kafka.NewConfig().WithMetrics(
 			prometheus.DefaultRegisterer, "kafka-producer", "", 1*time.Second,
@@ -214,17 +241,57 @@ func main() {
 		expUC = regularExpUC
 	}
 
+	recRepo, err := recurringRepository.New(dbDoer)
+	if err != nil {
+		zapLogger.Fatal("Failed to create recurring expenses repository", zap.Error(err))
+	}
+	recUC, err := recurringUseCase.New(recRepo, expUC, userRepo)
+	if err != nil {
+		zapLogger.Fatal("Failed to create recurring expenses usecase", zap.Error(err))
+	}
+
+	ledgerRepo, err := ledgerRepository.New(dbDoer)
+	if err != nil {
+		zapLogger.Fatal("Failed to create ledgers repository", zap.Error(err))
+	}
+	ledgerUC, err := ledgerUseCase.New(cfg.Values().BaseCurrency, ledgerRepo, userRepo)
+	if err != nil {
+		zapLogger.Fatal("Failed to create ledgers usecase", zap.Error(err))
+	}
+
+	debtRepo, err := debtRepository.New(dbDoer)
+	if err != nil {
+		zapLogger.Fatal("Failed to create debts repository", zap.Error(err))
+	}
+	debtUC, err := debtUseCase.New(cfg.Values().BaseCurrency, debtRepo, expUC, userRepo, exrateRepo)
+	if err != nil {
+		zapLogger.Fatal("Failed to create debts usecase", zap.Error(err))
+	}
+
+	incomeRepo, err := incomeRepository.New(dbDoer)
+	if err != nil {
+		zapLogger.Fatal("Failed to create incomes repository", zap.Error(err))
+	}
+	incomeUC, err := incomeUseCase.New(cfg.Values().BaseCurrency, incomeRepo, expUC, userRepo, exrateRepo)
+	if err != nil {
+		zapLogger.Fatal("Failed to create incomes usecase", zap.Error(err))
+	}
+
 	opts := tg.Options{
-		Logger:     zapLogger,
-		LogUpdates: cfg.Values().LogUpdates,
-		WhiteList:  cfg.Values().WhiteList,
-		BlackList:  cfg.Values().BlackList,
-		Debug:      cfg.Values().Debug,
+		Logger:         zapLogger,
+		LogUpdates:     cfg.Values().LogUpdates,
+		WhiteList:      cfg.Values().WhiteList,
+		BlackList:      cfg.Values().BlackList,
+		Debug:          cfg.Values().Debug,
+		UndoTimeWindow: cfg.Values().UndoTimeWindow,
 	}
-	cl, err := tg.NewWithOptions(cfg.Token(), cfg.Values().BaseCurrency, cfg.Values().SupportedCurrencies, expUC, userUC, opts)
+	cl, err := tg.NewWithOptions(cfg.Token(), cfg.Values().BaseCurrency, cfg.Values().SupportedCurrencies, expUC, userUC, recUC, ledgerUC, debtUC, incomeUC, opts)
 	if err != nil {
 		zapLogger.Fatal("Failed to init telegram bot", zap.Error(err))
 	}
+	if err := regularExpUC.EnableLimitNotifications(cl, cfg.Values().LimitThresholds); err != nil {
+		zapLogger.Fatal("Failed to enable limit notifications", zap.Error(err))
+	}
 	if interval := cfg.Values().ExchangeRatesUpdateInterval; interval != 0 {
 		providerDone, err := exrateUC.RunAutoUpdater(ctx, zapLogger, interval)
 		if err != nil {
@@ -234,6 +301,15 @@ func main() {
 			<-providerDone
 		}()
 	}
+	if interval := cfg.Values().RecurringExpensesInterval; interval != 0 {
+		schedulerDone, err := recUC.RunScheduler(ctx, zapLogger, interval, cl)
+		if err != nil {
+			zapLogger.Fatal("Failed to run recurring expenses scheduler", zap.Error(err))
+		}
+		defer func() {
+			<-schedulerDone
+		}()
+	}
 	if grpcEndpoint := cfg.Values().GRPCEndpoint; grpcEndpoint != "" {
 		reportsService, err := reports.NewService(cl, zapLogger)
 		if err != nil {
@@ -91,7 +91,7 @@ func (c *ReportsConsumer) handleMessage(ctx context.Context, message *sarama.Con
 	span.SetTag(sinceUnixMillisSpanTagKey, event.Since.UnixMilli())
 	span.SetTag(tillUnixMillisSpanTagKey, event.Till.UnixMilli())
 
-	report, err := c.expenseUC.GetExpensesSummaryByCategorySince(ctx, event.UserID, event.Since, event.Till)
+	report, err := c.expenseUC.GetExpensesSummaryByCategorySince(ctx, event.UserID, event.Since, event.Till, event.Currency, event.Tag)
 	if err != nil {
 		return errors.Wrapf(err, "failed to get expenses report by categories by event=%+v", event)
 	}
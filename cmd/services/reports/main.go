@@ -11,6 +11,7 @@ import (
 	"os/signal"
 	"path/filepath"
 	"time"
+	_ "time/tzdata" // user time zones must not depend on the host zoneinfo
 
 	"github.com/go-redis/redis/v8"
 	_ "github.com/jackc/pgx/v5/stdlib"
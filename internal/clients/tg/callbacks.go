@@ -0,0 +1,432 @@
+package tg
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+	"gopkg.in/telebot.v3"
+)
+
+const (
+	expenseActionsUnique = "expense_actions"
+	undoExpenseUnique    = "undo_expense"
+	changeCategoryUnique = "change_category"
+	setCategoryUnique    = "set_category"
+	changeDateUnique     = "change_date"
+	setDateUnique        = "set_date"
+	pickCategoryUnique   = "pick_category"
+)
+
+const (
+	undoTimeWindowExpiredMsg = "Undo is not available anymore, please use /delete command."
+	noRecentCategoriesMsg    = "No recent categories found, please use /edit command."
+	pickCategoryMsg          = "Please, pick the category of the expense:"
+	pickedExpenseNotFoundMsg = "Can't find the expense to pick the category of, please use /expense command."
+	categoryAlreadyPickedMsg = "The category of the expense is already picked."
+	onlyAuthorCanPickMsg     = "Only the author of the expense can pick its category."
+	onlyAuthorCanChangeMsg   = "Only the author of the expense can change it."
+)
+
+const (
+	maxCallbackDataLen       = 64 // limited by telegram API
+	maxButtonsInRow          = 3
+	maxCategoriesInKeyboard  = 6
+	maxDaysAgoInKeyboard     = 6
+	recentCategoriesDaysSpan = 90
+)
+
+func callbackEndpoint(unique string) string {
+	return "\f" + unique
+}
+
+func makeCallbackData(unique string, id models.ExpenseID, value string) (string, bool) {
+	data := strconv.FormatInt(int64(id), 10)
+	if value != "" {
+		data += "|" + value
+	}
+	fits := len(callbackEndpoint(unique))+len("|")+len(data) <= maxCallbackDataLen
+	return data, fits
+}
+
+func parseCallbackData(data string) (models.ExpenseID, string, error) {
+	idStr, value, _ := strings.Cut(data, "|")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return 0, "", errors.Wrapf(err, "failed to parse expense ID from callback data %q", data)
+	}
+	return models.ExpenseID(id), value, nil
+}
+
+func makeExpenseActionsMarkup(id models.ExpenseID) *telebot.ReplyMarkup {
+	markup := &telebot.ReplyMarkup{}
+	data, _ := makeCallbackData(undoExpenseUnique, id, "")
+	markup.Inline(markup.Row(
+		markup.Data("Undo", undoExpenseUnique, data),
+		markup.Data("Change category", changeCategoryUnique, data),
+		markup.Data("Change date", changeDateUnique, data),
+	))
+	return markup
+}
+
+func respondCallback(teleCtx telebotReducedContext, text string) error {
+	if text == "" {
+		return teleCtx.Respond()
+	}
+	return teleCtx.Respond(&telebot.CallbackResponse{Text: text})
+}
+
+func (c *Client) handleExpenseActionsCallback(_ context.Context, teleCtx telebotReducedContext) error {
+	cb := teleCtx.Callback()
+	id, _, err := parseCallbackData(cb.Data)
+	if err != nil {
+		return err
+	}
+	if err := respondCallback(teleCtx, ""); err != nil {
+		return errors.Wrap(err, "failed to respond to callback")
+	}
+	return teleCtx.Edit(cb.Message.Text, makeExpenseActionsMarkup(id))
+}
+
+func (c *Client) handleUndoExpenseCallback(ctx context.Context, teleCtx telebotReducedContext) error {
+	cb := teleCtx.Callback()
+	id, _, err := parseCallbackData(cb.Data)
+	if err != nil {
+		return err
+	}
+	// the message with buttons is sent right after the expense creation
+	if time.Since(cb.Message.Time()) > c.undoTimeWindow {
+		return respondCallback(teleCtx, undoTimeWindowExpiredMsg)
+	}
+	userID := accountID(ctx, teleCtx.Sender())
+	exp, err := c.expUC.GetExpenseByID(ctx, userID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, expense.ErrDoesNotExist):
+			return respondCallback(teleCtx, expenseNotFoundMsg)
+		default:
+			return errors.Wrapf(err, "failed to get expenseID=%d for userID=%d", id, userID)
+		}
+	}
+	// members of the shared ledger see buttons of each other in the group chat
+	if !isExpenseAuthor(&exp, teleCtx.Sender()) {
+		return respondCallback(teleCtx, onlyAuthorCanChangeMsg)
+	}
+	if err := c.expUC.DeleteExpense(ctx, userID, id); err != nil {
+		switch {
+		case errors.Is(err, expense.ErrDoesNotExist):
+			return respondCallback(teleCtx, expenseNotFoundMsg)
+		default:
+			return errors.Wrapf(err, "failed to undo expenseID=%d for userID=%d", id, userID)
+		}
+	}
+	if err := respondCallback(teleCtx, ""); err != nil {
+		return errors.Wrap(err, "failed to respond to callback")
+	}
+	return teleCtx.Edit(fmt.Sprintf("Expense #%d successfully undone", id))
+}
+
+func (c *Client) handleChangeCategoryCallback(ctx context.Context, teleCtx telebotReducedContext) error {
+	cb := teleCtx.Callback()
+	id, _, err := parseCallbackData(cb.Data)
+	if err != nil {
+		return err
+	}
+	userID := accountID(ctx, teleCtx.Sender())
+	categories, err := c.getRecentCategories(ctx, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get recent categories for userID=%d", userID)
+	}
+	markup := &telebot.ReplyMarkup{}
+	btns := make([]telebot.Btn, 0, len(categories))
+	for _, category := range categories {
+		data, fits := makeCallbackData(setCategoryUnique, id, string(category))
+		if !fits {
+			continue
+		}
+		btns = append(btns, markup.Data(string(category), setCategoryUnique, data))
+	}
+	if len(btns) == 0 {
+		return respondCallback(teleCtx, noRecentCategoriesMsg)
+	}
+	if err := respondCallback(teleCtx, ""); err != nil {
+		return errors.Wrap(err, "failed to respond to callback")
+	}
+	return teleCtx.Edit(cb.Message.Text, makeChoiceMarkup(markup, id, btns))
+}
+
+func (c *Client) handleSetCategoryCallback(ctx context.Context, teleCtx telebotReducedContext) error {
+	id, category, err := parseCallbackData(teleCtx.Callback().Data)
+	if err != nil {
+		return err
+	}
+	return c.updateExpenseByCallback(ctx, teleCtx, id, func(exp *models.Expense) {
+		exp.Category = models.ExpenseCategory(category)
+	})
+}
+
+// sendCategorySuggestions offers categories learned from the user history for the expense without category.
+func (c *Client) sendCategorySuggestions(
+	ctx context.Context,
+	teleCtx telebotReducedContext,
+	teleMsg *telebot.Message,
+	exp models.Expense,
+) error {
+	categories, err := c.suggestCategories(ctx, teleMsg, exp)
+	if err != nil {
+		return err
+	}
+	if len(categories) == 0 {
+		return teleCtx.Send(categoryRuleNotMatchedMsg)
+	}
+	return sendPickCategoryKeyboard(teleCtx, teleMsg, categories)
+}
+
+// suggestCategories returns categories learned from the user history which fit the callback data.
+func (c *Client) suggestCategories(ctx context.Context, teleMsg *telebot.Message, exp models.Expense) ([]models.ExpenseCategory, error) {
+	userID := accountID(ctx, teleMsg.Sender)
+	categories, err := c.expUC.SuggestCategories(ctx, userID, exp)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to suggest categories for userID=%d", userID)
+	}
+	out := categories[:0]
+	for _, category := range categories {
+		if fitsPickCategoryData(category) {
+			out = append(out, category)
+		}
+	}
+	return out, nil
+}
+
+func fitsPickCategoryData(category models.ExpenseCategory) bool {
+	return len(callbackEndpoint(pickCategoryUnique))+len("|")+len(category) <= maxCallbackDataLen
+}
+
+func containsCategory(categories []models.ExpenseCategory, category models.ExpenseCategory) bool {
+	for _, c := range categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// sendPickCategoryKeyboard replies to the expense message, so the expense is parsed from it again when the category is picked.
+func sendPickCategoryKeyboard(teleCtx telebotReducedContext, teleMsg *telebot.Message, categories []models.ExpenseCategory) error {
+	markup := &telebot.ReplyMarkup{}
+	btns := make([]telebot.Btn, 0, len(categories))
+	for _, category := range categories {
+		btns = append(btns, markup.Data(string(category), pickCategoryUnique, string(category)))
+	}
+	markup.Inline(markup.Split(maxButtonsInRow, btns)...)
+	return teleCtx.Send(pickCategoryMsg, &telebot.SendOptions{ReplyTo: teleMsg, ReplyMarkup: markup})
+}
+
+// handlePickCategoryCallback creates the expense of the replied message with the picked category.
+func (c *Client) handlePickCategoryCallback(ctx context.Context, teleCtx telebotReducedContext) error {
+	cb := teleCtx.Callback()
+	expMsg := cb.Message.ReplyTo
+	if expMsg == nil || expMsg.Sender == nil {
+		return respondCallback(teleCtx, pickedExpenseNotFoundMsg)
+	}
+	if teleCtx.Sender().ID != expMsg.Sender.ID {
+		return respondCallback(teleCtx, onlyAuthorCanPickMsg)
+	}
+	userID := accountID(ctx, expMsg.Sender)
+	today, err := c.getUserToday(ctx, userID, expMsg.Time())
+	if err != nil {
+		return err
+	}
+	exp, err := c.parsePickedExpense(expMsg, today, models.ExpenseCategory(cb.Data))
+	if err != nil {
+		return respondCallback(teleCtx, err.Error())
+	}
+	if err := exp.Validate(); err != nil {
+		if err := respondCallback(teleCtx, ""); err != nil {
+			return errors.Wrap(err, "failed to respond to callback")
+		}
+		return sendExpenseValidationError(teleCtx, err)
+	}
+	created, rejectedMsg, err := c.addMessageExpense(ctx, expMsg, exp)
+	switch {
+	case errors.Is(err, expense.ErrMessageLinked):
+		// the keyboard can be pressed again before it's removed
+		return respondCallback(teleCtx, categoryAlreadyPickedMsg)
+	case err != nil:
+		return err
+	}
+	if err := respondCallback(teleCtx, ""); err != nil {
+		return errors.Wrap(err, "failed to respond to callback")
+	}
+	if rejectedMsg != "" {
+		// the keyboard is kept to pick the category with the limit not exceeded
+		return teleCtx.Send(rejectedMsg)
+	}
+	if err := teleCtx.Edit(fmt.Sprintf("Category %q is picked", exp.Category)); err != nil {
+		return errors.Wrap(err, "failed to remove category suggestions")
+	}
+	return sendExpenseCreated(teleCtx, created, "")
+}
+
+// parsePickedExpense parses the expense of the /expense command or the free form message the category suggestions reply to.
+// Returned error is suitable to be sent to the user as is.
+func (c *Client) parsePickedExpense(expMsg *telebot.Message, today time.Time, category models.ExpenseCategory) (models.Expense, error) {
+	if args, ok := extractCommandArgs(expMsg.Text, expenseCmd); ok {
+		exp, err := c.parseExpenseArgs(args, today)
+		exp.Category = category
+		return exp, err
+	}
+	text, _ := c.textAddressedToBot(expMsg)
+	parsed, ok := parseFreeFormExpense(text, today)
+	if !ok || parsed.wordsAfterAmount == "" {
+		return models.Expense{}, errors.New(pickedExpenseNotFoundMsg)
+	}
+	exp := parsed.expense
+	if exp.Category != category {
+		// the first word is a part of the comment unless it's picked as the category
+		exp.Comment = parsed.wordsAfterAmount
+	}
+	exp.Category = category
+	return exp, nil
+}
+
+func (c *Client) handleChangeDateCallback(ctx context.Context, teleCtx telebotReducedContext) error {
+	cb := teleCtx.Callback()
+	id, _, err := parseCallbackData(cb.Data)
+	if err != nil {
+		return err
+	}
+	userID := accountID(ctx, teleCtx.Sender())
+	today, err := c.getUserToday(ctx, userID, time.Now())
+	if err != nil {
+		return err
+	}
+	markup := &telebot.ReplyMarkup{}
+	btns := make([]telebot.Btn, 0, maxDaysAgoInKeyboard+1)
+	for daysAgo := 0; daysAgo <= maxDaysAgoInKeyboard; daysAgo++ {
+		day := today.AddDate(0, 0, -daysAgo).Format(dateLayout)
+		text := day
+		switch daysAgo {
+		case 0:
+			text = "Today"
+		case 1:
+			text = "Yesterday"
+		}
+		data, _ := makeCallbackData(setDateUnique, id, day)
+		btns = append(btns, markup.Data(text, setDateUnique, data))
+	}
+	if err := respondCallback(teleCtx, ""); err != nil {
+		return errors.Wrap(err, "failed to respond to callback")
+	}
+	return teleCtx.Edit(cb.Message.Text, makeChoiceMarkup(markup, id, btns))
+}
+
+func (c *Client) handleSetDateCallback(ctx context.Context, teleCtx telebotReducedContext) error {
+	id, dateStr, err := parseCallbackData(teleCtx.Callback().Data)
+	if err != nil {
+		return err
+	}
+	day, err := time.Parse(dateLayout, dateStr)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse date from callback data %q", dateStr)
+	}
+	return c.updateExpenseByCallback(ctx, teleCtx, id, func(exp *models.Expense) {
+		// the time of day is kept as is
+		y, m, d := day.Date()
+		exp.Date = time.Date(y, m, d, exp.Date.Hour(), exp.Date.Minute(), exp.Date.Second(), 0, exp.Date.Location())
+	})
+}
+
+// isExpenseAuthor reports whether the user added the expense, expenses without the author are available to everyone.
+func isExpenseAuthor(exp *models.Expense, sender *telebot.User) bool {
+	return exp.AuthorID == 0 || exp.AuthorID == models.UserID(sender.ID)
+}
+
+func makeChoiceMarkup(markup *telebot.ReplyMarkup, id models.ExpenseID, btns []telebot.Btn) *telebot.ReplyMarkup {
+	data, _ := makeCallbackData(expenseActionsUnique, id, "")
+	rows := markup.Split(maxButtonsInRow, btns)
+	rows = append(rows, markup.Row(markup.Data("Back", expenseActionsUnique, data)))
+	markup.Inline(rows...)
+	return markup
+}
+
+func (c *Client) updateExpenseByCallback(
+	ctx context.Context,
+	teleCtx telebotReducedContext,
+	id models.ExpenseID,
+	update func(exp *models.Expense),
+) error {
+	userID := accountID(ctx, teleCtx.Sender())
+	exp, err := c.expUC.GetExpenseByID(ctx, userID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, expense.ErrDoesNotExist):
+			return respondCallback(teleCtx, expenseNotFoundMsg)
+		default:
+			return errors.Wrapf(err, "failed to get expenseID=%d for userID=%d", id, userID)
+		}
+	}
+	if !isExpenseAuthor(&exp, teleCtx.Sender()) {
+		return respondCallback(teleCtx, onlyAuthorCanChangeMsg)
+	}
+	old := exp
+	update(&exp)
+	if _, err := c.expUC.UpdateExpense(ctx, userID, exp); err != nil {
+		switch {
+		case errors.Is(err, expense.ErrExpensesLimitExcess):
+			// the description of exceeded limits doesn't fit the callback alert
+			if err := respondCallback(teleCtx, ""); err != nil {
+				return errors.Wrap(err, "failed to respond to callback")
+			}
+			return teleCtx.Send(c.describeLimitExcess(err))
+		case errors.Is(err, expense.ErrDoesNotExist):
+			return respondCallback(teleCtx, expenseNotFoundMsg)
+		case errors.Is(err, expense.ErrRefundExceedsExpense):
+			return respondCallback(teleCtx, refundExceedsExpenseMsg)
+		default:
+			return errors.Wrapf(err, "failed to update expenseID=%d for userID=%d", id, userID)
+		}
+	}
+	curr, err := c.userUC.GetUserCurrency(ctx, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get user currency for userID=%d", userID)
+	}
+	if err := respondCallback(teleCtx, ""); err != nil {
+		return errors.Wrap(err, "failed to respond to callback")
+	}
+	return teleCtx.Edit(describeExpenseChanges(old, exp, curr), makeExpenseActionsMarkup(id))
+}
+
+// getRecentCategories returns the most expensive user categories for the last recentCategoriesDaysSpan days.
+func (c *Client) getRecentCategories(ctx context.Context, userID models.UserID) ([]models.ExpenseCategory, error) {
+	till, err := c.getUserToday(ctx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	since := till.AddDate(0, 0, -recentCategoriesDaysSpan)
+	report, err := c.expUC.GetExpensesSummaryByCategorySince(ctx, userID, since, till, "", "")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get expenses summary by categories")
+	}
+	categories := make([]models.ExpenseCategory, 0, len(report))
+	for category := range report {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		a, b := report[categories[i]], report[categories[j]]
+		if a.Equal(b) {
+			return categories[i] < categories[j]
+		}
+		return a.GreaterThan(b)
+	})
+	if len(categories) > maxCategoriesInKeyboard {
+		categories = categories[:maxCategoriesInKeyboard]
+	}
+	return categories, nil
+}
@@ -0,0 +1,186 @@
+package tg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense"
+	clMock "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/generated/mocks/clients"
+	expMock "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/generated/mocks/expense"
+	userMock "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/generated/mocks/user"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+	"gopkg.in/telebot.v3"
+)
+
+func Test_handleUndoExpenseCallback(t *testing.T) {
+	const (
+		userID    = 11
+		expenseID = models.ExpenseID(33)
+	)
+	tests := []struct {
+		name       string
+		sentAt     time.Time
+		authorID   models.UserID
+		shouldUndo bool
+	}{
+		{name: "within time window", sentAt: time.Now(), authorID: userID, shouldUndo: true},
+		{name: "time window expired", sentAt: time.Now().Add(-2 * defaultUndoTimeWindow), shouldUndo: false},
+		{name: "another ledger member", sentAt: time.Now(), authorID: userID + 1, shouldUndo: false},
+	}
+	for _, test := range tests {
+		testCase := test
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+			var (
+				expUCMock   = expMock.NewMockUseCase(ctrl)
+				userUCMock  = userMock.NewMockUseCase(ctrl)
+				teleCtxMock = clMock.NewMocktelebotReducedContext(ctrl)
+			)
+			teleCtxMock.EXPECT().Callback().AnyTimes().Return(&telebot.Callback{
+				Data:    "33",
+				Message: &telebot.Message{Unixtime: testCase.sentAt.Unix()},
+			})
+			teleCtxMock.EXPECT().Sender().AnyTimes().Return(&telebot.User{ID: userID})
+			var getCall *gomock.Call
+			if testCase.authorID != 0 {
+				getCall = expUCMock.EXPECT().GetExpenseByID(ctx, models.UserID(userID), expenseID).Times(1).
+					Return(models.Expense{ID: expenseID, AuthorID: testCase.authorID}, nil)
+			}
+			switch {
+			case testCase.shouldUndo:
+				deleteCall := expUCMock.EXPECT().DeleteExpense(ctx, models.UserID(userID), expenseID).Times(1).Return(nil).After(getCall)
+				respondCall := teleCtxMock.EXPECT().Respond().Times(1).Return(nil).After(deleteCall)
+				teleCtxMock.EXPECT().Edit("Expense #33 successfully undone").Times(1).Return(nil).After(respondCall)
+			case getCall != nil:
+				teleCtxMock.EXPECT().Respond(&telebot.CallbackResponse{Text: onlyAuthorCanChangeMsg}).Times(1).Return(nil).After(getCall)
+			default:
+				teleCtxMock.EXPECT().Respond(&telebot.CallbackResponse{Text: undoTimeWindowExpiredMsg}).Times(1).Return(nil)
+			}
+
+			cl := newClient(ctx, t, expUCMock, userUCMock)
+			err := cl.handleUndoExpenseCallback(ctx, teleCtxMock)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_handleSetCategoryCallback(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	var (
+		expUCMock   = expMock.NewMockUseCase(ctrl)
+		userUCMock  = userMock.NewMockUseCase(ctrl)
+		teleCtxMock = clMock.NewMocktelebotReducedContext(ctrl)
+	)
+	var (
+		userID    = 11
+		expenseID = models.ExpenseID(33)
+		oldExp    = models.Expense{
+			ID:       expenseID,
+			Category: "fod",
+			Amount:   decimal.NewFromInt(250),
+			Date:     time.Date(2022, time.October, 10, 0, 0, 0, 0, time.UTC),
+		}
+		updatedExp = oldExp
+	)
+	updatedExp.Category = "food"
+
+	teleCtxMock.EXPECT().Callback().AnyTimes().Return(&telebot.Callback{Data: "33|food"})
+	teleCtxMock.EXPECT().Sender().AnyTimes().Return(&telebot.User{ID: int64(userID)})
+	getCall := expUCMock.EXPECT().GetExpenseByID(ctx, models.UserID(userID), expenseID).Times(1).Return(oldExp, nil)
+	updateCall := expUCMock.EXPECT().UpdateExpense(ctx, models.UserID(userID), updatedExp).Times(1).
+		Return(updatedExp, nil).After(getCall)
+	currCall := userUCMock.EXPECT().GetUserCurrency(ctx, models.UserID(userID)).Times(1).Return(models.CurrencyCode("RUB"), nil).After(updateCall)
+	respondCall := teleCtxMock.EXPECT().Respond().Times(1).Return(nil).After(currCall)
+	teleCtxMock.EXPECT().Edit("Expense #33 successfully updated:\ncategory: fod -> food", gomock.Any()).Times(1).
+		Return(nil).After(respondCall)
+
+	cl := newClient(ctx, t, expUCMock, userUCMock)
+	err := cl.handleSetCategoryCallback(ctx, teleCtxMock)
+	require.NoError(t, err)
+}
+
+func Test_handlePickCategoryCallback(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	var (
+		expUCMock   = expMock.NewMockUseCase(ctrl)
+		userUCMock  = userMock.NewMockUseCase(ctrl)
+		teleCtxMock = clMock.NewMocktelebotReducedContext(ctrl)
+	)
+	var (
+		userID    = 11
+		messageID = 22
+		day       = time.Date(2023, time.March, 4, 0, 0, 0, 0, time.UTC)
+		expMsg    = &telebot.Message{
+			ID:       messageID,
+			Sender:   &telebot.User{ID: int64(userID)},
+			Text:     "/expense 250 latte",
+			Unixtime: day.Add(10 * time.Hour).Unix(),
+		}
+		expectedExp = models.Expense{
+			Category: "coffee",
+			Amount:   decimal.NewFromInt(250),
+			Date:     day,
+			Comment:  "latte",
+			AuthorID: models.UserID(userID),
+		}
+		createdExp = expectedExp
+	)
+	createdExp.ID = 33
+
+	teleCtxMock.EXPECT().Callback().AnyTimes().Return(&telebot.Callback{
+		Data:    "coffee",
+		Message: &telebot.Message{ReplyTo: expMsg},
+	})
+	teleCtxMock.EXPECT().Sender().AnyTimes().Return(&telebot.User{ID: int64(userID)})
+	tzCall := userUCMock.EXPECT().GetUserTimeZone(ctx, models.UserID(userID)).Times(1).Return(time.UTC, nil)
+	addExpCall := expUCMock.EXPECT().AddExpenseFromMessage(ctx, models.UserID(userID), expectedExp, models.MessageRef{MessageID: models.MessageID(messageID)}).Times(1).
+		Return(createdExp, nil).After(tzCall)
+	respondCall := teleCtxMock.EXPECT().Respond().Times(1).Return(nil).After(addExpCall)
+	editCall := teleCtxMock.EXPECT().Edit(`Category "coffee" is picked`).Times(1).Return(nil).After(respondCall)
+	sendCall := teleCtxMock.EXPECT().Send("Expense successfully created", gomock.Any()).Times(1).Return(nil).After(editCall)
+	// the keyboard is pressed again before it's removed
+	tzCall = userUCMock.EXPECT().GetUserTimeZone(ctx, models.UserID(userID)).Times(1).Return(time.UTC, nil).After(sendCall)
+	linkedCall := expUCMock.EXPECT().AddExpenseFromMessage(ctx, models.UserID(userID), expectedExp, models.MessageRef{MessageID: models.MessageID(messageID)}).Times(1).
+		Return(models.Expense{}, expense.ErrMessageLinked).After(tzCall)
+	teleCtxMock.EXPECT().Respond(&telebot.CallbackResponse{Text: categoryAlreadyPickedMsg}).Times(1).Return(nil).After(linkedCall)
+
+	cl := newClient(ctx, t, expUCMock, userUCMock)
+	err := cl.handlePickCategoryCallback(ctx, teleCtxMock)
+	require.NoError(t, err)
+	err = cl.handlePickCategoryCallback(ctx, teleCtxMock)
+	require.NoError(t, err)
+}
+
+func Test_parsePickedExpense(t *testing.T) {
+	ctx := context.Background()
+	cl := newClient(ctx, t, nil, nil)
+	today := time.Date(2023, time.March, 4, 0, 0, 0, 0, time.UTC)
+
+	exp, err := cl.parsePickedExpense(&telebot.Message{Text: "/expense 250 latte"}, today, "coffee")
+	require.NoError(t, err)
+	assert.Equal(t, models.ExpenseCategory("coffee"), exp.Category)
+	assert.Equal(t, "latte", exp.Comment)
+
+	// the first word of the free form message is the comment unless it's picked as the category
+	exp, err = cl.parsePickedExpense(&telebot.Message{Text: "250 latte to go"}, today, "coffee")
+	require.NoError(t, err)
+	assert.Equal(t, models.ExpenseCategory("coffee"), exp.Category)
+	assert.True(t, decimal.NewFromInt(250).Equal(exp.Amount))
+	assert.Equal(t, "latte to go", exp.Comment)
+
+	exp, err = cl.parsePickedExpense(&telebot.Message{Text: "250 latte to go"}, today, "latte")
+	require.NoError(t, err)
+	assert.Equal(t, models.ExpenseCategory("latte"), exp.Category)
+	assert.Equal(t, "to go", exp.Comment)
+
+	_, err = cl.parsePickedExpense(&telebot.Message{Text: "coffee 250"}, today, "coffee")
+	require.EqualError(t, err, pickedExpenseNotFoundMsg)
+}
@@ -0,0 +1,145 @@
+package tg
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+const (
+	renameCategoryArg = "rename"
+	mergeCategoryArg  = "merge"
+	categoryAliasArg  = "alias"
+
+	categoriesUsageMsg           = "Usage: /categories, /categories " + renameCategoryArg + " <old> <new>, /categories " + mergeCategoryArg + " <from> <to>, /categories " + categoryAliasArg + " <alias, optional> <category or '" + noneLimitValue + "', optional>"
+	noCategoriesFoundMsg         = "There are no expenses yet."
+	noCategoryAliasesFoundMsg    = "There are no category aliases yet."
+	categoryDoesNotExistMsg      = "There are no expenses of the category."
+	categoryExistsMsg            = "The category already exists, use /categories " + mergeCategoryArg + " to move expenses into it."
+	categoryAliasNotFoundMsg     = "Category alias not found."
+	categoryAliasToItselfMsg     = "Category alias must differ from the category."
+	categoryAliasesListHeaderMsg = "Category aliases:\n"
+)
+
+// handleCategoriesCmd lists categories of the user expenses, renames and merges them and manages category aliases.
+func (c *Client) handleCategoriesCmd(ctx context.Context, teleCtx telebotReducedContext) error {
+	args := teleCtx.Args()
+	userID := accountID(ctx, teleCtx.Message().Sender)
+	if len(args) == 0 {
+		return c.sendCategories(ctx, teleCtx, userID)
+	}
+	switch args[0] {
+	case renameCategoryArg, mergeCategoryArg:
+		if len(args) != 3 {
+			return teleCtx.Send(categoriesUsageMsg)
+		}
+		return c.moveCategory(ctx, teleCtx, userID, args[0] == mergeCategoryArg, models.ExpenseCategory(args[1]), models.ExpenseCategory(args[2]))
+	case categoryAliasArg:
+		return c.handleCategoryAlias(ctx, teleCtx, userID, args[1:])
+	default:
+		return teleCtx.Send(categoriesUsageMsg)
+	}
+}
+
+func (c *Client) sendCategories(ctx context.Context, teleCtx telebotReducedContext, userID models.UserID) error {
+	categories, err := c.expUC.GetCategories(ctx, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get categories for userID=%d", userID)
+	}
+	if len(categories) == 0 {
+		return teleCtx.Send(noCategoriesFoundMsg)
+	}
+	curr, err := c.userUC.GetUserCurrency(ctx, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get currency for userID=%d", userID)
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Categories, amounts in %s:\n", curr))
+	for _, stats := range categories {
+		sb.WriteString(fmt.Sprintf("%s - %d expenses, %v\n", stats.Category, stats.Count, stats.Amount.Round(2)))
+	}
+	return teleCtx.Send(sb.String())
+}
+
+func (c *Client) moveCategory(ctx context.Context, teleCtx telebotReducedContext, userID models.UserID, merge bool, from, to models.ExpenseCategory) error {
+	move, action := c.expUC.RenameCategory, "renamed"
+	if merge {
+		move, action = c.expUC.MergeCategory, "merged"
+	}
+	if err := move(ctx, userID, from, to); err != nil {
+		switch {
+		case errors.Is(err, expense.ErrCategoryDoesNotExist):
+			return teleCtx.Send(categoryDoesNotExistMsg)
+		case errors.Is(err, expense.ErrCategoryExists):
+			return teleCtx.Send(categoryExistsMsg)
+		case errors.Is(err, models.ErrExpenseCategoryIsInvalid):
+			return teleCtx.Send(expenseCategoryIsInvalidMsg)
+		default:
+			return errors.Wrapf(err, "failed to move category %q to %q for userID=%d", from, to, userID)
+		}
+	}
+	return teleCtx.Send(fmt.Sprintf("Category %q successfully %s into %q", from, action, to.Normalize()))
+}
+
+// handleCategoryAlias lists aliases without arguments, sets the alias to the category or removes it with noneLimitValue.
+func (c *Client) handleCategoryAlias(ctx context.Context, teleCtx telebotReducedContext, userID models.UserID, args []string) error {
+	switch len(args) {
+	case 0:
+		aliases, err := c.expUC.GetCategoryAliases(ctx, userID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get category aliases for userID=%d", userID)
+		}
+		return teleCtx.Send(formatCategoryAliases(aliases))
+	case 2:
+	default:
+		return teleCtx.Send(categoriesUsageMsg)
+	}
+	alias := models.ExpenseCategory(args[0])
+	if args[1] == noneLimitValue {
+		if err := c.expUC.DeleteCategoryAlias(ctx, userID, alias); err != nil {
+			switch {
+			case errors.Is(err, expense.ErrCategoryAliasDoesNotExist):
+				return teleCtx.Send(categoryAliasNotFoundMsg)
+			default:
+				return errors.Wrapf(err, "failed to delete category alias %q for userID=%d", alias, userID)
+			}
+		}
+		return teleCtx.Send(fmt.Sprintf("Category alias %q successfully removed", alias.Normalize()))
+	}
+	category := models.ExpenseCategory(args[1])
+	if err := c.expUC.SetCategoryAlias(ctx, userID, alias, category); err != nil {
+		switch {
+		case errors.Is(err, models.ErrCategoryAliasToItself):
+			return teleCtx.Send(categoryAliasToItselfMsg)
+		case errors.Is(err, models.ErrExpenseCategoryIsInvalid):
+			return teleCtx.Send(expenseCategoryIsInvalidMsg)
+		default:
+			return errors.Wrapf(err, "failed to set category alias %q for userID=%d", alias, userID)
+		}
+	}
+	return teleCtx.Send(fmt.Sprintf("Expenses of category %q will be added to %q", alias.Normalize(), category.Normalize()))
+}
+
+func formatCategoryAliases(aliases map[models.ExpenseCategory]models.ExpenseCategory) string {
+	if len(aliases) == 0 {
+		return noCategoryAliasesFoundMsg
+	}
+	names := make([]models.ExpenseCategory, 0, len(aliases))
+	for alias := range aliases {
+		names = append(names, alias)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	var sb strings.Builder
+	sb.WriteString(categoryAliasesListHeaderMsg)
+	for _, alias := range names {
+		sb.WriteString(fmt.Sprintf("%s -> %s\n", alias, aliases[alias]))
+	}
+	return sb.String()
+}
@@ -0,0 +1,16 @@
+package tg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+func Test_formatCategoryAliases(t *testing.T) {
+	assert.Equal(t, noCategoryAliasesFoundMsg, formatCategoryAliases(nil))
+	assert.Equal(t, "Category aliases:\neda -> food\nmeal -> food\n", formatCategoryAliases(map[models.ExpenseCategory]models.ExpenseCategory{
+		"meal": "food",
+		"eda":  "food",
+	}))
+}
@@ -0,0 +1,153 @@
+package tg
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+// supported layouts of absolute dates, dateLayout goes first as the canonical one
+var dateLayouts = []string{dateLayout, "2006-01-02", "02.01.2006"}
+
+const (
+	monthLayout     = "2006-01"
+	timeOfDayLayout = "15:04"
+)
+
+var weekdays = func() map[string]time.Weekday {
+	out := make(map[string]time.Weekday, 14)
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		out[name] = day
+		out[name[:3]] = day
+	}
+	return out
+}()
+
+const (
+	dateExprHelp   = "'today', 'yesterday', '-3d', weekday name, 'yyyy.mm.dd', 'yyyy-mm-dd' or 'dd.mm.yyyy'"
+	periodExprHelp = "'week', 'last-week', 'month', 'last-month', 'period', 'last-period', 'year', 'ytd', 'yyyy-mm' or any date"
+)
+
+// parseDate parses the day expression relative to today, which must be a midnight in the user time zone.
+// Supported expressions are described in dateExprHelp, the returned day is a midnight in today location.
+func parseDate(expr string, today time.Time) (time.Time, error) {
+	lowered := strings.ToLower(expr)
+	switch lowered {
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+	if weekday, ok := weekdays[lowered]; ok {
+		daysAgo := (int(today.Weekday()) - int(weekday) + 7) % 7
+		return today.AddDate(0, 0, -daysAgo), nil
+	}
+	if strings.HasPrefix(lowered, "-") && strings.HasSuffix(lowered, "d") {
+		daysAgo, err := strconv.Atoi(lowered[1 : len(lowered)-1])
+		if err == nil && daysAgo >= 0 {
+			return today.AddDate(0, 0, -daysAgo), nil
+		}
+	}
+	for _, layout := range dateLayouts {
+		if day, err := time.ParseInLocation(layout, expr, today.Location()); err == nil {
+			return day, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("unknown date %q, expected one of %s", expr, dateExprHelp)
+}
+
+// parseTimeOfDay parses 'hh:mm' expression and returns the moment of the day in the day location.
+func parseTimeOfDay(expr string, day time.Time) (time.Time, bool) {
+	t, err := time.Parse(timeOfDayLayout, expr)
+	if err != nil {
+		return time.Time{}, false
+	}
+	y, m, d := day.Date()
+	return time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, day.Location()), true
+}
+
+// formatDate formats the date with time of day if it's not a midnight.
+func formatDate(t time.Time) string {
+	if t.Equal(models.StartOfDay(t)) {
+		return t.Format(dateLayout)
+	}
+	return t.Format(dateLayout + " " + timeOfDayLayout)
+}
+
+// parsePeriod parses the period expression relative to today, which must be a midnight in the user time zone.
+// Both returned days are included in the period. Any date expression is treated as one day period.
+// The 'period' expression is the user budget period starting at periodStartDay of month.
+func parsePeriod(expr string, today time.Time, periodStartDay int) (since, till time.Time, err error) {
+	var (
+		year, month, _ = today.Date()
+		monthStart     = time.Date(year, month, 1, 0, 0, 0, 0, today.Location())
+		weekStart      = today.AddDate(0, 0, -(int(today.Weekday())+6)%7) // weeks start on monday
+		yearStart      = time.Date(year, time.January, 1, 0, 0, 0, 0, today.Location())
+	)
+	switch strings.ToLower(expr) {
+	case "week":
+		return weekStart, weekStart.AddDate(0, 0, 6), nil
+	case "last-week":
+		return weekStart.AddDate(0, 0, -7), weekStart.AddDate(0, 0, -1), nil
+	case "month":
+		return monthStart, monthStart.AddDate(0, 1, -1), nil
+	case "last-month":
+		return monthStart.AddDate(0, -1, 0), monthStart.AddDate(0, 0, -1), nil
+	case "period":
+		since, till = models.BudgetPeriodBounds(today, periodStartDay)
+		return since, models.StartOfDay(till), nil
+	case "last-period":
+		currentStart, _ := models.BudgetPeriodBounds(today, periodStartDay)
+		since, till = models.BudgetPeriodBounds(currentStart.AddDate(0, 0, -1), periodStartDay)
+		return since, models.StartOfDay(till), nil
+	case "year":
+		return yearStart, yearStart.AddDate(1, 0, -1), nil
+	case "ytd":
+		return yearStart, today, nil
+	}
+	if start, err := time.ParseInLocation(monthLayout, expr, today.Location()); err == nil {
+		return start, start.AddDate(0, 1, -1), nil
+	}
+	day, err := parseDate(expr, today)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.Errorf("unknown period %q, expected one of %s", expr, periodExprHelp)
+	}
+	return day, day, nil
+}
+
+// cutCategoryArg cuts the category from the end of the report arguments, the last one of several arguments
+// is the category if it isn't a period. Empty category is returned if there is no such argument.
+func cutCategoryArg(args []string, today time.Time, periodStartDay int) ([]string, models.ExpenseCategory) {
+	if len(args) < 2 {
+		return args, ""
+	}
+	last := args[len(args)-1]
+	if _, _, err := parsePeriod(last, today, periodStartDay); err == nil {
+		return args, ""
+	}
+	return args[:len(args)-1], models.ExpenseCategory(last).Normalize()
+}
+
+// parseDateRange parses '<period>' or '<since period> <till period>' arguments.
+func parseDateRange(args []string, today time.Time, periodStartDay int) (since, till time.Time, err error) {
+	switch len(args) {
+	case 1:
+		return parsePeriod(args[0], today, periodStartDay)
+	case 2:
+		since, _, err = parsePeriod(args[0], today, periodStartDay)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.Wrap(err, "since")
+		}
+		_, till, err = parsePeriod(args[1], today, periodStartDay)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.Wrap(err, "till")
+		}
+		return since, till, nil
+	default:
+		return time.Time{}, time.Time{}, errors.Errorf("expected one or two arguments, got %d", len(args))
+	}
+}
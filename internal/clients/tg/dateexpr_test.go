@@ -0,0 +1,165 @@
+package tg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+func utcDay(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func Test_parseDate(t *testing.T) {
+	today := utcDay(2022, time.October, 12) // wednesday
+	tests := []struct {
+		expr     string
+		expected time.Time
+		fails    bool
+	}{
+		{expr: "today", expected: today},
+		{expr: "Yesterday", expected: utcDay(2022, time.October, 11)},
+		{expr: "-3d", expected: utcDay(2022, time.October, 9)},
+		{expr: "-0d", expected: today},
+		{expr: "wednesday", expected: today},
+		{expr: "mon", expected: utcDay(2022, time.October, 10)},
+		{expr: "thursday", expected: utcDay(2022, time.October, 6)},
+		{expr: "2022.10.10", expected: utcDay(2022, time.October, 10)},
+		{expr: "2022-10-10", expected: utcDay(2022, time.October, 10)},
+		{expr: "01.10.2022", expected: utcDay(2022, time.October, 1)},
+		{expr: "-d", fails: true},
+		{expr: "--3d", fails: true},
+		{expr: "tomorrow", fails: true},
+		{expr: "2022/10/10", fails: true},
+	}
+	for _, test := range tests {
+		testCase := test
+		t.Run(testCase.expr, func(t *testing.T) {
+			actual, err := parseDate(testCase.expr, today)
+			if testCase.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, actual)
+		})
+	}
+}
+
+func Test_parseDateRange(t *testing.T) {
+	today := utcDay(2022, time.October, 12) // wednesday
+	tests := []struct {
+		args          []string
+		expectedSince time.Time
+		expectedTill  time.Time
+		fails         bool
+	}{
+		{args: []string{"week"}, expectedSince: utcDay(2022, time.October, 10), expectedTill: utcDay(2022, time.October, 16)},
+		{args: []string{"last-week"}, expectedSince: utcDay(2022, time.October, 3), expectedTill: utcDay(2022, time.October, 9)},
+		{args: []string{"month"}, expectedSince: utcDay(2022, time.October, 1), expectedTill: utcDay(2022, time.October, 31)},
+		{args: []string{"last-month"}, expectedSince: utcDay(2022, time.September, 1), expectedTill: utcDay(2022, time.September, 30)},
+		{args: []string{"ytd"}, expectedSince: utcDay(2022, time.January, 1), expectedTill: today},
+		{args: []string{"2022-02"}, expectedSince: utcDay(2022, time.February, 1), expectedTill: utcDay(2022, time.February, 28)},
+		{args: []string{"yesterday"}, expectedSince: utcDay(2022, time.October, 11), expectedTill: utcDay(2022, time.October, 11)},
+		{args: []string{"2022.10.01", "today"}, expectedSince: utcDay(2022, time.October, 1), expectedTill: today},
+		{args: []string{"2022-08", "last-month"}, expectedSince: utcDay(2022, time.August, 1), expectedTill: utcDay(2022, time.September, 30)},
+		{args: []string{"period"}, expectedSince: utcDay(2022, time.October, 1), expectedTill: utcDay(2022, time.October, 31)},
+		{args: []string{"next-month"}, fails: true},
+		{args: []string{"today", "never"}, fails: true},
+	}
+	for _, test := range tests {
+		testCase := test
+		t.Run(testCase.args[0], func(t *testing.T) {
+			since, till, err := parseDateRange(testCase.args, today, models.DefaultUserPeriodStartDay)
+			if testCase.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expectedSince, since)
+			assert.Equal(t, testCase.expectedTill, till)
+		})
+	}
+}
+
+func Test_cutCategoryArg(t *testing.T) {
+	today := utcDay(2022, time.October, 12)
+	tests := []struct {
+		args             []string
+		expectedArgs     []string
+		expectedCategory models.ExpenseCategory
+	}{
+		{args: []string{"month"}, expectedArgs: []string{"month"}},
+		{args: []string{"month", "food"}, expectedArgs: []string{"month"}, expectedCategory: "food"},
+		{args: []string{"2022-08", "last-month"}, expectedArgs: []string{"2022-08", "last-month"}},
+		{args: []string{"2022-08", "today", "food/restaurants"}, expectedArgs: []string{"2022-08", "today"}, expectedCategory: "food/restaurants"},
+	}
+	for _, test := range tests {
+		args, category := cutCategoryArg(test.args, today, models.DefaultUserPeriodStartDay)
+		assert.Equal(t, test.expectedArgs, args, test.args)
+		assert.Equal(t, test.expectedCategory, category, test.args)
+	}
+}
+
+func Test_parseDateInUserTimeZone(t *testing.T) {
+	loc := time.FixedZone("UTC+10:00", 10*60*60)
+	today := time.Date(2022, time.October, 1, 0, 0, 0, 0, loc)
+
+	day, err := parseDate("2022-09-30", today)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2022, time.September, 30, 0, 0, 0, 0, loc), day)
+
+	since, till, err := parseDateRange([]string{"month"}, today, models.DefaultUserPeriodStartDay)
+	require.NoError(t, err)
+	assert.Equal(t, today, since)
+	assert.Equal(t, time.Date(2022, time.October, 31, 0, 0, 0, 0, loc), till)
+
+	moment, ok := parseTimeOfDay("08:05", today)
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2022, time.October, 1, 8, 5, 0, 0, loc), moment)
+	assert.Equal(t, "2022.10.01 08:05", formatDate(moment))
+	assert.Equal(t, "2022.10.01", formatDate(today))
+
+	_, ok = parseTimeOfDay("25:00", today)
+	require.False(t, ok)
+}
+
+func Test_parsePeriodWithCustomStartDay(t *testing.T) {
+	tests := []struct {
+		expr          string
+		today         time.Time
+		startDay      int
+		expectedSince time.Time
+		expectedTill  time.Time
+	}{
+		{
+			expr: "period", today: utcDay(2022, time.October, 12), startDay: 10,
+			expectedSince: utcDay(2022, time.October, 10), expectedTill: utcDay(2022, time.November, 9),
+		},
+		{
+			expr: "period", today: utcDay(2022, time.October, 9), startDay: 10,
+			expectedSince: utcDay(2022, time.September, 10), expectedTill: utcDay(2022, time.October, 9),
+		},
+		{
+			expr: "last-period", today: utcDay(2022, time.October, 12), startDay: 10,
+			expectedSince: utcDay(2022, time.September, 10), expectedTill: utcDay(2022, time.October, 9),
+		},
+		{
+			// february is shorter, so its period starts on the last day
+			expr: "period", today: utcDay(2022, time.March, 15), startDay: 31,
+			expectedSince: utcDay(2022, time.February, 28), expectedTill: utcDay(2022, time.March, 30),
+		},
+	}
+	for _, test := range tests {
+		testCase := test
+		t.Run(testCase.expr+"/"+testCase.today.Format(dateLayout), func(t *testing.T) {
+			since, till, err := parsePeriod(testCase.expr, testCase.today, testCase.startDay)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expectedSince, since)
+			assert.Equal(t, testCase.expectedTill, till)
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package tg
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+const (
+	splitUsageMsg              = "Usage: /split <amount - float> <category - one word> <@username>... <comment, optional>"
+	settleUsageMsg             = "Usage: /settle <@username> <amount - float, optional>"
+	sharedLedgerOnlyMsg        = "Bills can be split between members of the shared ledger only. Add the bot to your group chat to create one."
+	noDebtsFoundMsg            = "No debts found, everybody is settled up."
+	nothingToSettleMsg         = "You don't owe %s anything."
+	unknownMembersMsg          = "Unknown ledger members: %s. They should write to the group chat with the bot or join the ledger by the invite link first."
+	debtAmountTooBigMsg        = "Amount is too big."
+	debtAmountIsNotPositiveMsg = "Please, provide positive amount."
+	settleWithItselfMsg        = "You can't settle debts with yourself."
+	debtsBalancesHeadline      = "Balances in %s, positive ones are owed to members:"
+	debtsTransfersHeadline     = "Fewest transfers to settle up:"
+)
+
+// getSharedLedger returns the ledger the update is handled for, false is returned for the personal account.
+func (c *Client) getSharedLedger(ctx context.Context, teleCtx telebotReducedContext) (models.Ledger, bool, error) {
+	sender := teleCtx.Message().Sender
+	id := accountID(ctx, sender)
+	if id == models.UserID(sender.ID) {
+		return models.Ledger{}, false, nil
+	}
+	l, err := c.ledgerUC.GetLedger(ctx, id)
+	if err != nil {
+		return models.Ledger{}, false, errors.Wrapf(err, "failed to get ledgerID=%d", id)
+	}
+	return l, true, nil
+}
+
+// resolveMentions returns ledger members mentioned by '@username', the unknown mentions are returned as well.
+func resolveMentions(l *models.Ledger, mentions []string) ([]models.UserID, []string) {
+	var (
+		ids     []models.UserID
+		unknown []string
+	)
+	for _, mention := range mentions {
+		member, ok := l.MemberByUsername(strings.TrimPrefix(mention, "@"))
+		if !ok {
+			unknown = append(unknown, mention)
+			continue
+		}
+		ids = append(ids, member.UserID)
+	}
+	return ids, unknown
+}
+
+func memberNames(l *models.Ledger) map[models.UserID]string {
+	names := make(map[models.UserID]string, len(l.Members))
+	for _, member := range l.Members {
+		names[member.UserID] = member.Name
+	}
+	return names
+}
+
+func nameOfMember(names map[models.UserID]string, id models.UserID) string {
+	if name, ok := names[id]; ok {
+		return name
+	}
+	return fmt.Sprintf("Former member #%d", id)
+}
+
+func sendDebtValidationError(teleCtx telebotReducedContext, err error) error {
+	switch {
+	case errors.Is(err, models.ErrDebtAmountTooBig):
+		return teleCtx.Send(debtAmountTooBigMsg)
+	case errors.Is(err, models.ErrDebtAmountIsNotPositive):
+		return teleCtx.Send(debtAmountIsNotPositiveMsg)
+	case errors.Is(err, models.ErrDebtToItself):
+		return teleCtx.Send(settleWithItselfMsg)
+	default:
+		return errors.Wrapf(err, "unknown debt validation error")
+	}
+}
+
+func (c *Client) handleSplitCmd(ctx context.Context, teleCtx telebotReducedContext) error {
+	args := teleCtx.Args()
+	if len(args) < 3 {
+		return teleCtx.Send(splitUsageMsg)
+	}
+	l, ok, err := c.getSharedLedger(ctx, teleCtx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return teleCtx.Send(sharedLedgerOnlyMsg)
+	}
+	amount, err := decimal.NewFromString(args[0])
+	if err != nil {
+		return teleCtx.Send(fmt.Sprintf("Failed to parse amount: %v\n%s", err, splitUsageMsg))
+	}
+	var mentions, commentWords []string
+	for _, arg := range args[2:] {
+		if strings.HasPrefix(arg, "@") && len(commentWords) == 0 {
+			mentions = append(mentions, arg)
+			continue
+		}
+		commentWords = append(commentWords, arg)
+	}
+	if len(mentions) == 0 {
+		return teleCtx.Send(splitUsageMsg)
+	}
+	participants, unknown := resolveMentions(&l, mentions)
+	if len(unknown) != 0 {
+		return teleCtx.Send(fmt.Sprintf(unknownMembersMsg, strings.Join(unknown, ", ")))
+	}
+	teleMsg := teleCtx.Message()
+	today, err := c.getUserToday(ctx, l.ID, teleMsg.Time())
+	if err != nil {
+		return err
+	}
+	exp := models.Expense{
+		Category: models.ExpenseCategory(args[1]),
+		Amount:   amount,
+		Date:     today,
+		Comment:  strings.Join(commentWords, " "),
+	}
+	if err := exp.Validate(); err != nil {
+		return sendExpenseValidationError(teleCtx, err)
+	}
+	payerID := models.UserID(teleMsg.Sender.ID)
+	shares, err := c.debtUC.SplitBill(ctx, l.ID, payerID, exp, participants)
+	if err != nil {
+		switch {
+		case errors.Is(err, expense.ErrExpensesLimitExcess):
+			return teleCtx.Send(c.describeLimitExcess(err))
+		default:
+			return errors.Wrapf(err, "failed to split bill of userID=%d in ledgerID=%d", payerID, l.ID)
+		}
+	}
+	names := memberNames(&l)
+	lines := make([]string, 0, len(shares))
+	for _, share := range shares {
+		lines = append(lines, fmt.Sprintf("#%d %s %v", share.ID, nameOfMember(names, share.AuthorID), share.OriginalAmount))
+	}
+	return teleCtx.Send(fmt.Sprintf("Bill successfully split between %d members:\n%s", len(shares), strings.Join(lines, "\n")))
+}
+
+func (c *Client) handleDebtsCmd(ctx context.Context, teleCtx telebotReducedContext) error {
+	l, ok, err := c.getSharedLedger(ctx, teleCtx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return teleCtx.Send(sharedLedgerOnlyMsg)
+	}
+	balances, transfers, err := c.debtUC.GetBalances(ctx, l.ID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get debts balances of ledgerID=%d", l.ID)
+	}
+	if len(balances) == 0 {
+		return teleCtx.Send(noDebtsFoundMsg)
+	}
+	curr, err := c.userUC.GetUserCurrency(ctx, l.ID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get currency for userID=%d", l.ID)
+	}
+	return teleCtx.Send(formatBalances(balances, transfers, memberNames(&l), curr))
+}
+
+// formatBalances lists balances from the largest creditor to the largest debtor and the transfers settling them.
+func formatBalances(
+	balances map[models.UserID]decimal.Decimal,
+	transfers []models.Transfer,
+	names map[models.UserID]string,
+	curr models.CurrencyCode,
+) string {
+	ids := make([]models.UserID, 0, len(balances))
+	for id := range balances {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if a, b := balances[ids[i]], balances[ids[j]]; !a.Equal(b) {
+			return a.GreaterThan(b)
+		}
+		return ids[i] < ids[j]
+	})
+	sb := new(strings.Builder)
+	sb.WriteString(fmt.Sprintf(debtsBalancesHeadline, curr))
+	for _, id := range ids {
+		sign := ""
+		if balances[id].IsPositive() {
+			sign = "+"
+		}
+		sb.WriteString(fmt.Sprintf("\n%s: %s%v", nameOfMember(names, id), sign, balances[id]))
+	}
+	sb.WriteString("\n\n" + debtsTransfersHeadline)
+	for _, transfer := range transfers {
+		sb.WriteString(fmt.Sprintf("\n%s -> %s: %v", nameOfMember(names, transfer.From), nameOfMember(names, transfer.To), transfer.Amount))
+	}
+	return sb.String()
+}
+
+// handleSettleCmd records the repayment from the sender to the mentioned member,
+// the amount of the suggested transfer is repaid if the amount is omitted.
+func (c *Client) handleSettleCmd(ctx context.Context, teleCtx telebotReducedContext) error {
+	args := teleCtx.Args()
+	if len(args) < 1 || !strings.HasPrefix(args[0], "@") {
+		return teleCtx.Send(settleUsageMsg)
+	}
+	l, ok, err := c.getSharedLedger(ctx, teleCtx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return teleCtx.Send(sharedLedgerOnlyMsg)
+	}
+	creditors, unknown := resolveMentions(&l, args[:1])
+	if len(unknown) != 0 {
+		return teleCtx.Send(fmt.Sprintf(unknownMembersMsg, strings.Join(unknown, ", ")))
+	}
+	debtorID, creditorID := models.UserID(teleCtx.Message().Sender.ID), creditors[0]
+	var amount decimal.Decimal
+	if len(args) > 1 {
+		if amount, err = decimal.NewFromString(args[1]); err != nil {
+			return teleCtx.Send(fmt.Sprintf("Failed to parse amount: %v\n%s", err, settleUsageMsg))
+		}
+	} else {
+		_, transfers, err := c.debtUC.GetBalances(ctx, l.ID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get debts balances of ledgerID=%d", l.ID)
+		}
+		for _, transfer := range transfers {
+			if transfer.From == debtorID && transfer.To == creditorID {
+				amount = transfer.Amount
+			}
+		}
+		if amount.IsZero() {
+			return teleCtx.Send(fmt.Sprintf(nothingToSettleMsg, args[0]))
+		}
+	}
+	if err := c.debtUC.SettleDebt(ctx, l.ID, debtorID, creditorID, amount); err != nil {
+		switch {
+		case errors.Is(err, models.ErrDebtAmountTooBig), errors.Is(err, models.ErrDebtAmountIsNotPositive),
+			errors.Is(err, models.ErrDebtToItself):
+			return sendDebtValidationError(teleCtx, err)
+		default:
+			return errors.Wrapf(err, "failed to settle debt of userID=%d to userID=%d in ledgerID=%d", debtorID, creditorID, l.ID)
+		}
+	}
+	return teleCtx.Send(fmt.Sprintf("Repayment of %v to %s successfully recorded", amount, nameOfMember(memberNames(&l), creditorID)))
+}
@@ -0,0 +1,32 @@
+package tg
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+func Test_formatBalances(t *testing.T) {
+	balances := map[models.UserID]decimal.Decimal{
+		1: decimal.NewFromInt(2000),
+		2: decimal.NewFromInt(-1000),
+		3: decimal.NewFromInt(-1000),
+	}
+	transfers := []models.Transfer{
+		{From: 2, To: 1, Amount: decimal.NewFromInt(1000)},
+		{From: 3, To: 1, Amount: decimal.NewFromInt(1000)},
+	}
+	names := map[models.UserID]string{1: "Bob", 2: "Alice"}
+	actual := formatBalances(balances, transfers, names, "RUB")
+	require.Equal(t, ""+
+		"Balances in RUB, positive ones are owed to members:\n"+
+		"Bob: +2000\n"+
+		"Alice: -1000\n"+
+		"Former member #3: -1000\n\n"+
+		"Fewest transfers to settle up:\n"+
+		"Alice -> Bob: 1000\n"+
+		"Former member #3 -> Bob: 1000",
+		actual)
+}
@@ -0,0 +1,114 @@
+package tg
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+// freeFormExpense is an expense parsed from the plain text message like 'taxi 430 yesterday airport'.
+type freeFormExpense struct {
+	expense     models.Expense
+	ambiguities []string // human-readable notes about guesses made by the parser
+	// words following the amount, the category rules are applied to them before the first word is used as category
+	wordsAfterAmount string
+}
+
+func (f *freeFormExpense) isAmbiguous() bool {
+	return len(f.ambiguities) != 0
+}
+
+func (f *freeFormExpense) understoodText() string {
+	exp := f.expense
+	sb := new(strings.Builder)
+	_, _ = fmt.Fprintf(sb, "Understood as: category=%s, amount=%v, date=%s", exp.Category, exp.Amount, formatDate(exp.Date))
+	if exp.Comment != "" {
+		_, _ = fmt.Fprintf(sb, ", comment=%q", exp.Comment)
+	}
+	for _, note := range f.ambiguities {
+		sb.WriteString("\n- ")
+		sb.WriteString(note)
+	}
+	return sb.String()
+}
+
+func parseFreeFormAmount(token string) (decimal.Decimal, bool) {
+	// comma is a common decimal separator for our users
+	amount, err := decimal.NewFromString(strings.Replace(token, ",", ".", 1))
+	if err != nil {
+		return decimal.Decimal{}, false
+	}
+	return amount, true
+}
+
+// parseFreeFormExpense parses '<category> <amount> <date, optional> <time, optional> <comment, optional>' in a relaxed way:
+// the amount is the first number in the text, the category is the first word which is not the amount, the date or the time.
+// If the date is absent, today is used. If the text starts with the amount, its words are kept to pick the category by rules.
+func parseFreeFormExpense(text string, today time.Time) (freeFormExpense, bool) {
+	tokens := strings.Fields(text)
+	if len(tokens) < 2 || strings.HasPrefix(tokens[0], "/") {
+		return freeFormExpense{}, false
+	}
+	var (
+		out                    freeFormExpense
+		amountFound, dateFound bool
+		timeOfDay              string
+		severalNumbers         bool
+		severalWordsBefore     bool
+		categoryAfterAmount    bool
+		category               string
+		comment                []string
+	)
+	out.expense.Date = today
+	for _, token := range tokens {
+		if amount, ok := parseFreeFormAmount(token); ok {
+			if !amountFound {
+				out.expense.Amount, amountFound = amount, true
+				continue
+			}
+			severalNumbers = true
+		}
+		if !dateFound {
+			if day, err := parseDate(token, today); err == nil {
+				out.expense.Date, dateFound = day, true
+				continue
+			}
+		}
+		if timeOfDay == "" {
+			if _, ok := parseTimeOfDay(token, today); ok {
+				timeOfDay = token
+				continue
+			}
+		}
+		if category == "" {
+			category, categoryAfterAmount = token, amountFound
+			continue
+		}
+		if !amountFound {
+			severalWordsBefore = true
+		}
+		comment = append(comment, token)
+	}
+	if !amountFound || category == "" {
+		return freeFormExpense{}, false
+	}
+	if timeOfDay != "" {
+		// the time is applied after the loop because the date can follow it
+		out.expense.Date, _ = parseTimeOfDay(timeOfDay, out.expense.Date)
+	}
+	if severalNumbers {
+		out.ambiguities = append(out.ambiguities, fmt.Sprintf("several numbers found, %v is used as amount", out.expense.Amount))
+	}
+	if severalWordsBefore {
+		out.ambiguities = append(out.ambiguities, fmt.Sprintf("several words before amount, %q is used as category", category))
+	}
+	out.expense.Category = models.ExpenseCategory(category)
+	out.expense.Comment = strings.Join(comment, " ")
+	if categoryAfterAmount {
+		out.wordsAfterAmount = strings.Join(append([]string{category}, comment...), " ")
+	}
+	return out, true
+}
@@ -0,0 +1,93 @@
+package tg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+func Test_parseFreeFormExpense(t *testing.T) {
+	today := time.Date(2022, time.October, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		text      string
+		ok        bool
+		expected  models.Expense
+		ambiguous bool
+		words     string
+	}{
+		{text: "coffee", ok: false},
+		{text: "hello there", ok: false},
+		{text: "/expense food 250", ok: false},
+		{text: "250 yesterday", ok: false},
+		{
+			text:     "coffee 250",
+			ok:       true,
+			expected: models.Expense{Category: "coffee", Amount: decimal.NewFromInt(250), Date: today},
+		},
+		{
+			text: "taxi 430 yesterday airport",
+			ok:   true,
+			expected: models.Expense{
+				Category: "taxi", Amount: decimal.NewFromInt(430), Date: today.AddDate(0, 0, -1), Comment: "airport",
+			},
+		},
+		{
+			text:     "12,5 lunch 2022.10.01",
+			ok:       true,
+			expected: models.Expense{Category: "lunch", Amount: decimal.NewFromFloat(12.5), Date: today.AddDate(0, 0, -9)},
+			words:    "lunch",
+		},
+		{
+			text: "taxi 430 yesterday 23:40 airport",
+			ok:   true,
+			expected: models.Expense{
+				Category: "taxi", Amount: decimal.NewFromInt(430), Date: today.Add(-20 * time.Minute), Comment: "airport",
+			},
+		},
+		{
+			text: "250 starbucks latte",
+			ok:   true,
+			expected: models.Expense{
+				Category: "starbucks", Amount: decimal.NewFromInt(250), Date: today, Comment: "latte",
+			},
+			words: "starbucks latte",
+		},
+		{
+			text: "coffee beans 250",
+			ok:   true,
+			expected: models.Expense{
+				Category: "coffee", Amount: decimal.NewFromInt(250), Date: today, Comment: "beans",
+			},
+			ambiguous: true,
+		},
+		{
+			text: "taxi 430 2 people",
+			ok:   true,
+			expected: models.Expense{
+				Category: "taxi", Amount: decimal.NewFromInt(430), Date: today, Comment: "2 people",
+			},
+			ambiguous: true,
+		},
+	}
+	for _, test := range tests {
+		testCase := test
+		t.Run(testCase.text, func(t *testing.T) {
+			parsed, ok := parseFreeFormExpense(testCase.text, today)
+			require.Equal(t, testCase.ok, ok)
+			if !ok {
+				return
+			}
+			exp := parsed.expense
+			assert.Equal(t, testCase.expected.Category, exp.Category)
+			assert.Truef(t, testCase.expected.Amount.Equal(exp.Amount), "want %v, got %v", testCase.expected.Amount, exp.Amount)
+			assert.Equal(t, testCase.expected.Date, exp.Date)
+			assert.Equal(t, testCase.expected.Comment, exp.Comment)
+			assert.Equal(t, testCase.ambiguous, parsed.isAmbiguous())
+			assert.Equal(t, testCase.words, parsed.wordsAfterAmount)
+		})
+	}
+}
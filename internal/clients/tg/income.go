@@ -0,0 +1,150 @@
+package tg
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+const (
+	incomeAmountIsTooBigMsg       = "Income amount is too big."
+	incomeAmountIsNotPositiveMsg  = "Please, provide positive income amount."
+	noCashFlowFoundMsg            = "No income and expenses found."
+	incomeSourceIsMissingMsg      = "Please, provide the income source before the amount."
+	cashFlowHeadline              = "Cash flow in %s:"
+	cashFlowTotalHeadline         = "Total"
+	cashFlowNoSavingsRateTemplate = "savings: %v"
+	cashFlowSavingsRateTemplate   = "savings: %v, %v%% of income"
+)
+
+func sendIncomeValidationError(teleCtx telebotReducedContext, err error) error {
+	switch {
+	case errors.Is(err, models.ErrIncomeAmountTooBig):
+		return teleCtx.Send(incomeAmountIsTooBigMsg)
+	case errors.Is(err, models.ErrIncomeAmountIsNotPositive):
+		return teleCtx.Send(incomeAmountIsNotPositiveMsg)
+	default:
+		return errors.Wrapf(err, "unknown income validation error")
+	}
+}
+
+// handleIncomeCmd adds the income, its arguments are the same as the ones of the expense with the source instead of the category.
+func (c *Client) handleIncomeCmd(ctx context.Context, teleCtx telebotReducedContext) error {
+	args := teleCtx.Args()
+	if len(args) < 3 {
+		return errors.New("not enough arguments to add income")
+	}
+	teleMsg := teleCtx.Message()
+	userID := accountID(ctx, teleMsg.Sender)
+	today, err := c.getUserToday(ctx, userID, teleMsg.Time())
+	if err != nil {
+		return err
+	}
+	exp, err := c.parseExpenseArgs(args, today)
+	if err != nil {
+		return teleCtx.Send(err.Error())
+	}
+	// category rules are not applied to income
+	if exp.Category == "" {
+		return teleCtx.Send(incomeSourceIsMissingMsg)
+	}
+	inc := models.Income{
+		Source:           models.IncomeSource(exp.Category),
+		Amount:           exp.Amount,
+		Date:             exp.Date,
+		Comment:          exp.Comment,
+		AuthorID:         models.UserID(teleMsg.Sender.ID),
+		OriginalAmount:   exp.OriginalAmount,
+		OriginalCurrency: exp.OriginalCurrency,
+	}
+	if err := inc.Validate(); err != nil {
+		return sendIncomeValidationError(teleCtx, err)
+	}
+	created, err := c.incomeUC.AddIncome(ctx, userID, inc)
+	if err != nil {
+		return errors.Wrapf(err, "failed to add income for userID=%d", userID)
+	}
+	return teleCtx.Send(fmt.Sprintf("Income #%d successfully added", created.ID))
+}
+
+func (c *Client) handleBalanceCmd(ctx context.Context, teleCtx telebotReducedContext) error {
+	args := teleCtx.Args()
+	if len(args) < 1 {
+		return errors.New("not enough arguments to create balance report")
+	}
+	teleMsg := teleCtx.Message()
+	userID := accountID(ctx, teleMsg.Sender)
+	today, periodStartDay, err := c.getUserDateContext(ctx, userID, teleMsg.Time())
+	if err != nil {
+		return err
+	}
+	since, till, err := parseDateRange(args, today, periodStartDay)
+	if err != nil {
+		return teleCtx.Send(fmt.Sprintf("Failed to parse dates: %v", err))
+	}
+	flows, err := c.incomeUC.GetCashFlow(ctx, userID, since, till)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get cash flow for userID=%d", userID)
+	}
+	curr, err := c.userUC.GetUserCurrency(ctx, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get currency for userID=%d", userID)
+	}
+	msg := formatCashFlows(flows, curr)
+	if msg == "" {
+		return teleCtx.Send(noCashFlowFoundMsg)
+	}
+	return teleCtx.Send(msg)
+}
+
+// formatCashFlows lists periods with income or expenses and the total of them, empty string is returned if there are none.
+func formatCashFlows(flows []models.CashFlow, curr models.CurrencyCode) string {
+	var (
+		blocks []string
+		total  = models.CashFlow{Sources: make(map[models.IncomeSource]decimal.Decimal)}
+	)
+	for i := range flows {
+		flow := &flows[i]
+		if flow.Income.IsZero() && flow.Expenses.IsZero() {
+			continue
+		}
+		blocks = append(blocks, fmt.Sprintf("%s - %s\n%s", flow.Start.Format(dateLayout), flow.End.Format(dateLayout), formatCashFlow(flow)))
+		total.Income, total.Expenses = total.Income.Add(flow.Income), total.Expenses.Add(flow.Expenses)
+		for source, amount := range flow.Sources {
+			total.Sources[source] = total.Sources[source].Add(amount)
+		}
+	}
+	switch len(blocks) {
+	case 0:
+		return ""
+	case 1:
+	default:
+		blocks = append(blocks, cashFlowTotalHeadline+"\n"+formatCashFlow(&total))
+	}
+	return fmt.Sprintf(cashFlowHeadline, curr) + "\n" + strings.Join(blocks, "\n\n")
+}
+
+func formatCashFlow(flow *models.CashFlow) string {
+	sources := make([]string, 0, len(flow.Sources))
+	for source := range flow.Sources {
+		sources = append(sources, string(source))
+	}
+	sort.Strings(sources)
+	incomeLine := fmt.Sprintf("income: %v", flow.Income.Round(2))
+	if len(sources) != 0 {
+		for i, source := range sources {
+			sources[i] = fmt.Sprintf("%s=%v", source, flow.Sources[models.IncomeSource(source)].Round(2))
+		}
+		incomeLine += " (" + strings.Join(sources, ", ") + ")"
+	}
+	savingsLine := fmt.Sprintf(cashFlowNoSavingsRateTemplate, flow.Savings().Round(2))
+	if rate, ok := flow.SavingsRate(); ok {
+		savingsLine = fmt.Sprintf(cashFlowSavingsRateTemplate, flow.Savings().Round(2), rate.Round(1))
+	}
+	return strings.Join([]string{incomeLine, fmt.Sprintf("expenses: %v", flow.Expenses.Round(2)), savingsLine}, "\n")
+}
@@ -0,0 +1,34 @@
+package tg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+func Test_formatCashFlows(t *testing.T) {
+	jan := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	feb := jan.AddDate(0, 1, 0)
+	mar := feb.AddDate(0, 1, 0)
+	flows := []models.CashFlow{
+		{
+			Start:    jan,
+			End:      models.EndOfDay(feb.AddDate(0, 0, -1)),
+			Income:   decimal.NewFromInt(1200),
+			Sources:  map[models.IncomeSource]decimal.Decimal{"salary": decimal.NewFromInt(1000), "gifts": decimal.NewFromInt(200)},
+			Expenses: decimal.NewFromInt(300),
+		},
+		{Start: feb, End: models.EndOfDay(mar.AddDate(0, 0, -1))},
+		{Start: mar, End: models.EndOfDay(mar), Expenses: decimal.NewFromInt(100)},
+	}
+	require.Equal(t, ""+
+		"Cash flow in RUB:\n"+
+		"2023.01.01 - 2023.01.31\nincome: 1200 (gifts=200, salary=1000)\nexpenses: 300\nsavings: 900, 75% of income\n\n"+
+		"2023.03.01 - 2023.03.01\nincome: 0\nexpenses: 100\nsavings: -100\n\n"+
+		"Total\nincome: 1200 (gifts=200, salary=1000)\nexpenses: 400\nsavings: 800, 66.7% of income",
+		formatCashFlows(flows, "RUB"))
+	require.Empty(t, formatCashFlows(flows[1:2], "RUB"))
+}
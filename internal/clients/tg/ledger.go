@@ -0,0 +1,231 @@
+package tg
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/ledger"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+	"gopkg.in/telebot.v3"
+)
+
+const (
+	joinLedgerPayloadPrefix = "join_"
+	leaveLedgerArg          = "leave"
+	membersReportArg        = "members"
+	unknownMemberName       = "Unknown member"
+)
+
+const (
+	ledgerCreatedMsg        = "Hello! Expenses, limits and settings of this chat are shared by its members now."
+	inviteCodeInvalidMsg    = "Invite link is invalid or expired. Please, ask ledger members for the new one."
+	personalAccountMsg      = "You use your personal account. Open the invite link from the group chat with the bot to join its shared ledger."
+	leaveLedgerInGroupMsg   = "Leave the group chat to leave its shared ledger."
+	ledgerUsageMsg          = "Usage: /ledger or /ledger " + leaveLedgerArg
+	membersReportUsageMsg   = "Usage: /report " + membersReportArg + " <period or since> <till, optional> <currency, optional> <#tag, optional>"
+	ledgerJoinedMsgFormat   = "You have joined the shared ledger %q, your expenses in this chat are added to it now. Send /ledger %s to return to your personal account."
+	ledgerLeftMsgFormat     = "You have left the shared ledger %q and use your personal account now."
+	ledgerDescriptionFormat = "Shared ledger %q\nMembers:\n%s\nInvite link: %s"
+)
+
+func isGroupChat(chat *telebot.Chat) bool {
+	return chat.Type == telebot.ChatGroup || chat.Type == telebot.ChatSuperGroup
+}
+
+func newLedgerMember(sender *telebot.User) models.LedgerMember {
+	return models.LedgerMember{
+		UserID:   models.UserID(sender.ID),
+		Name:     memberName(sender),
+		Username: sender.Username,
+		JoinedAt: time.Now(),
+	}
+}
+
+func memberName(u *telebot.User) string {
+	if name := strings.TrimSpace(u.FirstName + " " + u.LastName); name != "" {
+		return name
+	}
+	if u.Username != "" {
+		return "@" + u.Username
+	}
+	return fmt.Sprintf("User #%d", u.ID)
+}
+
+func (c *Client) makeInviteLink(l models.Ledger) string {
+	return fmt.Sprintf("https://t.me/%s?start=%s%s", c.bot.Me.Username, joinLedgerPayloadPrefix, l.InviteCode)
+}
+
+func (c *Client) handleStartCmd(ctx context.Context, teleCtx telebotReducedContext) error {
+	teleMsg := teleCtx.Message()
+	if isGroupChat(teleMsg.Chat) {
+		chatID := teleMsg.Chat.ID
+		if _, err := c.ledgerUC.JoinChatLedger(ctx, models.UserID(chatID), teleMsg.Chat.Title, newLedgerMember(teleMsg.Sender)); err != nil {
+			return errors.Wrapf(err, "failed to join userID=%d to ledger of chatID=%d", teleMsg.Sender.ID, chatID)
+		}
+		return teleCtx.Send(ledgerCreatedMsg)
+	}
+	userID := models.UserID(teleMsg.Sender.ID)
+	u := models.NewUser(userID, c.baseCurr)
+	exists, err := c.userUC.IsUserExists(ctx, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check whether user with ID=%d exists or not", userID)
+	}
+	if !exists {
+		if _, err := c.userUC.CreateUser(ctx, u); err != nil {
+			return errors.Wrapf(err, "failed to create user with ID=%d if not exist", userID)
+		}
+	}
+	// the deep link payload is passed as the command argument
+	if args := teleCtx.Args(); len(args) != 0 && strings.HasPrefix(args[0], joinLedgerPayloadPrefix) {
+		code := strings.TrimPrefix(args[0], joinLedgerPayloadPrefix)
+		l, err := c.ledgerUC.JoinLedgerByInviteCode(ctx, code, newLedgerMember(teleMsg.Sender))
+		if err != nil {
+			switch {
+			case errors.Is(err, ledger.ErrDoesNotExist):
+				return teleCtx.Send(inviteCodeInvalidMsg)
+			default:
+				return errors.Wrapf(err, "failed to join userID=%d to ledger by invite code", userID)
+			}
+		}
+		return teleCtx.Send(fmt.Sprintf(ledgerJoinedMsgFormat, l.Title, leaveLedgerArg))
+	}
+	if exists {
+		return teleCtx.Send(startAlreadyWeKnowMsg)
+	}
+	return teleCtx.Send(startNowWeKnowMsg)
+}
+
+func (c *Client) handleLedgerCmd(ctx context.Context, teleCtx telebotReducedContext) error {
+	args := teleCtx.Args()
+	teleMsg := teleCtx.Message()
+	if len(args) == 1 && args[0] == leaveLedgerArg {
+		if isGroupChat(teleMsg.Chat) {
+			return teleCtx.Send(leaveLedgerInGroupMsg)
+		}
+		userID := models.UserID(teleMsg.Sender.ID)
+		l, err := c.ledgerUC.LeaveActiveLedger(ctx, userID)
+		if err != nil {
+			switch {
+			case errors.Is(err, ledger.ErrDoesNotExist):
+				return teleCtx.Send(personalAccountMsg)
+			default:
+				return errors.Wrapf(err, "failed to leave active ledger by userID=%d", userID)
+			}
+		}
+		// the member must join again on the next update in the group chat of the ledger
+		if _, err := c.knownChatMembers.Drop(chatMember{chatID: l.ID, userID: userID}); err != nil {
+			return errors.Wrapf(err, "failed to forget member userID=%d of ledgerID=%d", userID, l.ID)
+		}
+		return teleCtx.Send(fmt.Sprintf(ledgerLeftMsgFormat, l.Title))
+	}
+	if len(args) != 0 {
+		return teleCtx.Send(ledgerUsageMsg)
+	}
+	id := accountID(ctx, teleMsg.Sender)
+	if id == models.UserID(teleMsg.Sender.ID) {
+		return teleCtx.Send(personalAccountMsg)
+	}
+	l, err := c.ledgerUC.GetLedger(ctx, id)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get ledgerID=%d", id)
+	}
+	members := make([]string, 0, len(l.Members))
+	for _, member := range l.Members {
+		members = append(members, fmt.Sprintf("%s since %s", member.Name, formatDate(member.JoinedAt)))
+	}
+	return teleCtx.Send(fmt.Sprintf(ledgerDescriptionFormat, l.Title, strings.Join(members, "\n"), c.makeInviteLink(l)))
+}
+
+// sendMembersReport sends the summary report by categories split by authors of expenses in curr, args are the report dates.
+// Only expenses with the tag are reported, if it's not empty.
+func (c *Client) sendMembersReport(
+	ctx context.Context,
+	teleCtx telebotReducedContext,
+	args []string,
+	curr models.CurrencyCode,
+	tag models.ExpenseTag,
+) error {
+	if len(args) < 1 || len(args) > 2 {
+		return teleCtx.Send(membersReportUsageMsg)
+	}
+	teleMsg := teleCtx.Message()
+	userID := accountID(ctx, teleMsg.Sender)
+	today, periodStartDay, err := c.getUserDateContext(ctx, userID, teleMsg.Time())
+	if err != nil {
+		return err
+	}
+	since, till, err := parseDateRange(args, today, periodStartDay)
+	if err != nil {
+		return teleCtx.Send(fmt.Sprintf("Failed to parse dates: %v", err))
+	}
+	report, err := c.expUC.GetExpensesSummaryByAuthorSince(ctx, userID, since, till, curr, tag)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create expenses report by authors for userID=%d", userID)
+	}
+	if len(report) == 0 {
+		return teleCtx.Send(noExpensesFoundMsg)
+	}
+	names := map[models.UserID]string{models.UserID(teleMsg.Sender.ID): memberName(teleMsg.Sender)}
+	if userID != models.UserID(teleMsg.Sender.ID) {
+		l, err := c.ledgerUC.GetLedger(ctx, userID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get ledgerID=%d", userID)
+		}
+		for _, member := range l.Members {
+			names[member.UserID] = member.Name
+		}
+	}
+	msg, err := formatAuthorsReport(report, names)
+	if err != nil {
+		return errors.Wrapf(err, "failed to convert expenses report by authors to text message for userID=%d", userID)
+	}
+	return teleCtx.Send(msg)
+}
+
+// formatAuthorsReport lists authors by name with their totals and reports by categories.
+func formatAuthorsReport(report expense.AuthorsSummaryReport, names map[models.UserID]string) (string, error) {
+	type authorReport struct {
+		name   string
+		total  decimal.Decimal
+		report expense.SummaryReport
+	}
+	authors := make([]authorReport, 0, len(report))
+	for authorID, summary := range report {
+		name, ok := names[authorID]
+		switch {
+		case ok:
+		case authorID == 0:
+			name = unknownMemberName
+		default:
+			name = fmt.Sprintf("Former member #%d", authorID)
+		}
+		var total decimal.Decimal
+		for _, amount := range summary {
+			total = total.Add(amount)
+		}
+		authors = append(authors, authorReport{name: name, total: total, report: summary})
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		return authors[i].name < authors[j].name
+	})
+	sb := new(strings.Builder)
+	for _, author := range authors {
+		text, err := author.report.Text()
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(fmt.Sprintf("%s: %v\n", author.name, author.total))
+		for _, line := range strings.SplitAfter(text, "\n") {
+			if line != "" {
+				sb.WriteString("  " + line)
+			}
+		}
+	}
+	return sb.String(), nil
+}
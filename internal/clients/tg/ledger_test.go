@@ -0,0 +1,28 @@
+package tg
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+func Test_formatAuthorsReport(t *testing.T) {
+	report := expense.AuthorsSummaryReport{
+		0: {"rent": decimal.NewFromInt(500)},
+		1: {"food": decimal.NewFromInt(100), "taxi": decimal.NewFromInt(50)},
+		2: {"food": decimal.NewFromInt(70)},
+		3: {"cinema": decimal.NewFromInt(30)},
+	}
+	names := map[models.UserID]string{1: "Bob", 2: "Alice"}
+	actual, err := formatAuthorsReport(report, names)
+	require.NoError(t, err)
+	require.Equal(t, ""+
+		"Alice: 70\n  food=70\n"+
+		"Bob: 150\n  food=100\n  taxi=50\n"+
+		"Former member #3: 30\n  cinema=30\n"+
+		"Unknown member: 500\n  rent=500\n",
+		actual)
+}
@@ -0,0 +1,404 @@
+package tg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/user"
+)
+
+const (
+	noneLimitValue = "none"
+	limitModeArg   = "mode"
+	rolloverArg    = "rollover"
+	historyArg     = "history"
+	limitSpecHelp  = "'[category/]period=amount[:currency][,rollover[:cap]]' with period 'week', 'month', 'quarter' or 'year' and amount '" +
+		noneLimitValue + "' to remove the limit, the rollover carries unspent or overspent amount to the next period"
+)
+
+const maxLimitHistoryPeriods = 12
+
+const (
+	limitIsNegativeMsg        = "Please, provide not negative limit amount or '" + noneLimitValue + "' to remove the limit."
+	limitIsTooBigMsg          = "Limit is too big."
+	limitModeInvalidMsg       = "Please, provide limit mode 'hard' to reject expenses exceeding limits or 'soft' to accept them with warning."
+	limitUsageMsg             = "Usage: /limit <limit>... with limit " + limitSpecHelp + ", /limit <amount - float or '" + noneLimitValue + "'> <currency, optional> or /limit mode <hard or soft>"
+	noLimitsFoundMsg          = "You have no limits, limit mode is %q"
+	limitsStatusHeadline      = "Your limits in the current periods, limit mode is %q:"
+	categoryBudgetNotFoundMsg = "Category budget not found."
+	noCategoryBudgetsFoundMsg = "No category budgets found."
+	categoryBudgetsHeadline   = "Category limits in the current periods:"
+	categoryBudgetUsageMsg    = "Usage: /budget <category - one word> <amount - float or '" + noneLimitValue + "'> <'" +
+		rolloverArg + "', optional> <rollover cap - float, optional> or /budget " + historyArg
+	rolloverCapIsNegativeMsg = "Please, provide not negative rollover cap."
+	noRolloverLimitsFoundMsg = "No limits with rollover found."
+)
+
+// limitSpec is the limit change requested by the user.
+type limitSpec struct {
+	period   models.LimitPeriod
+	category models.ExpenseCategory
+	amount   *decimal.Decimal    // nil value means the limit removal
+	currency models.CurrencyCode // empty value means the user selected currency
+	rollover *models.Rollover    // the start of the rollover is set on save
+}
+
+// parseLimitSpec parses the limit in format described in limitSpecHelp, periods like 'weekly' are accepted as well.
+func parseLimitSpec(arg string) (limitSpec, error) {
+	key, value, ok := strings.Cut(arg, "=")
+	if !ok {
+		return limitSpec{}, errors.Errorf("expected %s", limitSpecHelp)
+	}
+	var spec limitSpec
+	// the period follows the last separator, because the category may be nested
+	if i := strings.LastIndex(key, models.CategorySeparator); i >= 0 {
+		spec.category, key = models.ExpenseCategory(key[:i]).Normalize(), key[i+1:]
+		if spec.category == "" {
+			return limitSpec{}, errors.New("empty category")
+		}
+	}
+	spec.period = models.LimitPeriod(strings.TrimSuffix(strings.ToLower(key), "ly"))
+	if err := spec.period.Validate(); err != nil {
+		return limitSpec{}, errors.Errorf("unknown period %q", key)
+	}
+	if value == noneLimitValue {
+		return spec, nil
+	}
+	value, rollover, hasRollover := strings.Cut(value, ",")
+	amountStr, currency, _ := strings.Cut(value, ":")
+	amount, err := decimal.NewFromString(amountStr)
+	if err != nil {
+		return limitSpec{}, errors.Wrap(err, "failed to parse amount")
+	}
+	spec.amount = &amount
+	spec.currency = models.CurrencyCode(strings.ToUpper(currency))
+	if hasRollover {
+		spec.rollover, err = parseRollover(strings.Split(rollover, ":"))
+		if err != nil {
+			return limitSpec{}, err
+		}
+	}
+	return spec, nil
+}
+
+// parseRollover parses 'rollover' and 'rollover <cap>' arguments.
+func parseRollover(args []string) (*models.Rollover, error) {
+	if len(args) == 0 || len(args) > 2 || args[0] != rolloverArg {
+		return nil, errors.Errorf("expected '%s[:cap]'", rolloverArg)
+	}
+	rollover := &models.Rollover{}
+	if len(args) == 2 {
+		rolloverCap, err := decimal.NewFromString(args[1])
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse rollover cap")
+		}
+		rollover.Cap = &rolloverCap
+	}
+	return rollover, nil
+}
+
+// parseLimitArgs parses both several limits and '<amount> <currency, optional>' arguments of the monthly limit.
+func parseLimitArgs(args []string) ([]limitSpec, error) {
+	if !strings.Contains(args[0], "=") {
+		if len(args) > 2 {
+			return nil, errors.New("too many arguments")
+		}
+		value := args[0]
+		if len(args) == 2 {
+			value += ":" + args[1]
+		}
+		spec, err := parseLimitSpec(string(models.LimitPeriodMonth) + "=" + value)
+		if err != nil {
+			return nil, err
+		}
+		return []limitSpec{spec}, nil
+	}
+	specs := make([]limitSpec, 0, len(args))
+	for _, arg := range args {
+		spec, err := parseLimitSpec(arg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "limit %q", arg)
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// capitalize makes the first letter of the ASCII text upper case.
+func capitalize(text string) string {
+	if text == "" {
+		return text
+	}
+	return strings.ToUpper(text[:1]) + text[1:]
+}
+
+// formatLimitAmount formats the limit amount with its currency, the base one is used by default.
+func (c *Client) formatLimitAmount(limit *models.Limit, amount decimal.Decimal) string {
+	currency := limit.Currency
+	if currency == "" {
+		currency = c.baseCurr
+	}
+	return fmt.Sprintf("%v %s", amount.Round(2), currency)
+}
+
+func (c *Client) formatLimitStatus(status *models.LimitStatus) string {
+	text := fmt.Sprintf("%s: spent %s of %s, remaining %v (%v%%)", status.Name(),
+		c.formatLimitAmount(&status.Limit, status.Spent), c.formatLimitAmount(&status.Limit, status.Allowance()),
+		status.Remaining().Round(2), status.SpentPercent())
+	if !status.Carried.IsZero() {
+		text += fmt.Sprintf(", carried %v", status.Carried.Round(2))
+	}
+	return text
+}
+
+// describeLimitExcess returns the message with limits exceeded by the rejected expense.
+func (c *Client) describeLimitExcess(err error) string {
+	var excessErr *expense.LimitExcessError
+	if !errors.As(err, &excessErr) {
+		return expensesAmountExceededMsg
+	}
+	lines := make([]string, 0, len(excessErr.Exceeded)+1)
+	lines = append(lines, expensesAmountExceededMsg)
+	for i := range excessErr.Exceeded {
+		status := &excessErr.Exceeded[i]
+		lines = append(lines, fmt.Sprintf("Exceeded %s: spent %s of %s", status.Name(),
+			c.formatLimitAmount(&status.Limit, status.Spent), c.formatLimitAmount(&status.Limit, status.Allowance())))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (c *Client) handleLimitCmd(ctx context.Context, teleCtx telebotReducedContext) error {
+	args := teleCtx.Args()
+	userID := accountID(ctx, teleCtx.Message().Sender)
+	if len(args) == 0 {
+		return c.sendLimitsStatus(ctx, teleCtx, userID)
+	}
+	if args[0] == limitModeArg {
+		return c.handleLimitModeCmd(ctx, teleCtx, userID, args[1:])
+	}
+	specs, err := parseLimitArgs(args)
+	if err != nil {
+		return teleCtx.Send(fmt.Sprintf("Failed to parse limits: %v\n%s", err, limitUsageMsg))
+	}
+	// limits are in the user selected currency unless the currency is given explicitly
+	userCurr, err := c.userUC.GetUserCurrency(ctx, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get currency for userID=%d", userID)
+	}
+	var (
+		limits  []models.Limit
+		removed []models.Limit
+	)
+	for _, spec := range specs {
+		limit := models.Limit{Period: spec.period, Category: spec.category}
+		if spec.amount == nil {
+			removed = append(removed, limit)
+			continue
+		}
+		limit.Amount, limit.Currency, limit.Rollover = *spec.amount, spec.currency, spec.rollover
+		if limit.Rollover != nil {
+			limit.Rollover.Since = time.Now()
+		}
+		if limit.Currency == "" {
+			limit.Currency = userCurr
+		}
+		if _, ok := c.supportedCurr[limit.Currency]; !ok {
+			msg := fmt.Sprintf("Currency %q is not supported. Supported currencies: %v", limit.Currency, c.supportedCurrSlice)
+			return teleCtx.Send(msg)
+		}
+		if err := limit.Validate(); err != nil {
+			return sendLimitValidationError(teleCtx, err)
+		}
+		limits = append(limits, limit)
+	}
+	notFound, err := c.userUC.UpdateUserLimits(ctx, userID, limits, removed)
+	if err != nil {
+		return errors.Wrapf(err, "failed to update limits for userID=%d", userID)
+	}
+	lines := make([]string, 0, len(specs))
+	for i := range limits {
+		lines = append(lines, fmt.Sprintf("%s successfully set to %q",
+			capitalize(limits[i].Name()), c.formatLimitAmount(&limits[i], limits[i].Amount)))
+	}
+	for i := range removed {
+		if containsLimit(notFound, &removed[i]) {
+			lines = append(lines, fmt.Sprintf("%s not found", capitalize(removed[i].Name())))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s successfully removed", capitalize(removed[i].Name())))
+		}
+	}
+	return teleCtx.Send(strings.Join(lines, "\n"))
+}
+
+// containsLimit reports whether the limits have one with the same period and category.
+func containsLimit(limits []models.Limit, limit *models.Limit) bool {
+	for i := range limits {
+		if limits[i].Period == limit.Period && limits[i].Category == limit.Category {
+			return true
+		}
+	}
+	return false
+}
+
+func sendLimitValidationError(teleCtx telebotReducedContext, err error) error {
+	switch {
+	case errors.Is(err, models.ErrLimitTooBig):
+		return teleCtx.Send(limitIsTooBigMsg)
+	case errors.Is(err, models.ErrLimitIsNegative):
+		return teleCtx.Send(limitIsNegativeMsg)
+	case errors.Is(err, models.ErrRolloverCapIsNegative):
+		return teleCtx.Send(rolloverCapIsNegativeMsg)
+	case errors.Is(err, models.ErrExpenseCategoryIsInvalid):
+		return teleCtx.Send(expenseCategoryIsInvalidMsg)
+	default:
+		return errors.Wrapf(err, "unknown limit validation error")
+	}
+}
+
+func (c *Client) sendLimitsStatus(ctx context.Context, teleCtx telebotReducedContext, userID models.UserID) error {
+	mode, err := c.userUC.GetUserLimitMode(ctx, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get limit mode for userID=%d", userID)
+	}
+	statuses, err := c.expUC.GetLimitsStatus(ctx, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get limits status for userID=%d", userID)
+	}
+	if len(statuses) == 0 {
+		return teleCtx.Send(fmt.Sprintf(noLimitsFoundMsg, mode))
+	}
+	lines := make([]string, 0, len(statuses)+1)
+	lines = append(lines, fmt.Sprintf(limitsStatusHeadline, mode))
+	for i := range statuses {
+		lines = append(lines, c.formatLimitStatus(&statuses[i]))
+	}
+	return teleCtx.Send(strings.Join(lines, "\n"))
+}
+
+func (c *Client) handleLimitModeCmd(ctx context.Context, teleCtx telebotReducedContext, userID models.UserID, args []string) error {
+	if len(args) != 1 {
+		return teleCtx.Send(limitUsageMsg)
+	}
+	mode := models.LimitMode(args[0])
+	if err := models.ValidateUserLimitMode(mode); err != nil {
+		return teleCtx.Send(limitModeInvalidMsg)
+	}
+	if err := c.userUC.SetUserLimitMode(ctx, userID, mode); err != nil {
+		return errors.Wrapf(err, "failed to set limit mode %q for userID=%d", mode, userID)
+	}
+	return teleCtx.Send(fmt.Sprintf("Limit mode successfully changed to %q", mode))
+}
+
+// handleBudgetCmd manages monthly category limits in the user selected currency, which are called category budgets.
+func (c *Client) handleBudgetCmd(ctx context.Context, teleCtx telebotReducedContext) error {
+	args := teleCtx.Args()
+	userID := accountID(ctx, teleCtx.Message().Sender)
+	if len(args) == 0 {
+		return c.sendCategoryBudgetsStatus(ctx, teleCtx, userID)
+	}
+	if len(args) == 1 && args[0] == historyArg {
+		return c.sendLimitsHistory(ctx, teleCtx, userID)
+	}
+	if len(args) < 2 || (args[1] == noneLimitValue && len(args) > 2) {
+		return teleCtx.Send(categoryBudgetUsageMsg)
+	}
+	category := models.ExpenseCategory(args[0]).Normalize()
+	if args[1] == noneLimitValue {
+		if err := c.userUC.DeleteUserLimit(ctx, userID, models.LimitPeriodMonth, category); err != nil {
+			switch {
+			case errors.Is(err, user.ErrLimitDoesNotExist):
+				return teleCtx.Send(categoryBudgetNotFoundMsg)
+			default:
+				return errors.Wrapf(err, "failed to delete category %q budget for userID=%d", category, userID)
+			}
+		}
+		return teleCtx.Send(fmt.Sprintf("Budget of category %q successfully removed", category))
+	}
+	amount, err := decimal.NewFromString(args[1])
+	if err != nil {
+		return teleCtx.Send(fmt.Sprintf("Failed to parse budget amount: %v", err))
+	}
+	// budgets are in the user selected currency like amounts of expenses
+	userCurr, err := c.userUC.GetUserCurrency(ctx, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get currency for userID=%d", userID)
+	}
+	limit := models.Limit{Period: models.LimitPeriodMonth, Category: category, Amount: amount, Currency: userCurr}
+	if len(args) > 2 {
+		limit.Rollover, err = parseRollover(args[2:])
+		if err != nil {
+			return teleCtx.Send(fmt.Sprintf("Failed to parse budget rollover: %v\n%s", err, categoryBudgetUsageMsg))
+		}
+		limit.Rollover.Since = time.Now()
+	}
+	if err := limit.Validate(); err != nil {
+		return sendLimitValidationError(teleCtx, err)
+	}
+	if err := c.userUC.SetUserLimits(ctx, userID, []models.Limit{limit}); err != nil {
+		return errors.Wrapf(err, "failed to set category %q budget for userID=%d", category, userID)
+	}
+	msg := fmt.Sprintf("Budget of category %q successfully set to %s", category, c.formatLimitAmount(&limit, limit.Amount))
+	if limit.Rollover != nil {
+		msg += " with rollover"
+	}
+	return teleCtx.Send(msg)
+}
+
+func (c *Client) sendCategoryBudgetsStatus(ctx context.Context, teleCtx telebotReducedContext, userID models.UserID) error {
+	statuses, err := c.expUC.GetLimitsStatus(ctx, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get limits status for userID=%d", userID)
+	}
+	lines := make([]string, 0, len(statuses)+1)
+	lines = append(lines, categoryBudgetsHeadline)
+	for i := range statuses {
+		if statuses[i].Category != "" {
+			lines = append(lines, c.formatLimitStatus(&statuses[i]))
+		}
+	}
+	if len(lines) == 1 {
+		return teleCtx.Send(noCategoryBudgetsFoundMsg)
+	}
+	return teleCtx.Send(strings.Join(lines, "\n"))
+}
+
+// sendLimitsHistory sends the last periods of limits with rollover.
+func (c *Client) sendLimitsHistory(ctx context.Context, teleCtx telebotReducedContext, userID models.UserID) error {
+	limits, err := c.userUC.GetUserLimits(ctx, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get limits for userID=%d", userID)
+	}
+	var lines []string
+	for i := range limits {
+		limit := &limits[i]
+		if limit.Rollover == nil {
+			continue
+		}
+		history, err := c.expUC.GetLimitHistory(ctx, userID, limit.Period, limit.Category)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get %s history for userID=%d", limit.Name(), userID)
+		}
+		if len(history) > maxLimitHistoryPeriods {
+			history = history[len(history)-maxLimitHistoryPeriods:]
+		}
+		if len(lines) != 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, capitalize(limit.Name())+":")
+		for _, status := range history {
+			lines = append(lines, fmt.Sprintf("%s - %s: allowance %s (carried %v), spent %v",
+				status.Start.Format(dateLayout), status.End.Format(dateLayout),
+				c.formatLimitAmount(limit, status.Allowance()), status.Carried.Round(2), status.Spent.Round(2)))
+		}
+	}
+	if len(lines) == 0 {
+		return teleCtx.Send(noRolloverLimitsFoundMsg)
+	}
+	return teleCtx.Send(strings.Join(lines, "\n"))
+}
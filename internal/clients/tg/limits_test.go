@@ -0,0 +1,130 @@
+package tg
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clMock "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/generated/mocks/clients"
+	expMock "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/generated/mocks/expense"
+	userMock "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/generated/mocks/user"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+	"gopkg.in/telebot.v3"
+)
+
+func Test_parseLimitArgs(t *testing.T) {
+	amount := func(value int64) *decimal.Decimal {
+		d := decimal.NewFromInt(value)
+		return &d
+	}
+	tests := []struct {
+		args     string
+		expected []limitSpec
+		fails    bool
+	}{
+		{args: "1000", expected: []limitSpec{{period: models.LimitPeriodMonth, amount: amount(1000)}}},
+		{args: "1000 usd", expected: []limitSpec{{period: models.LimitPeriodMonth, amount: amount(1000), currency: "USD"}}},
+		{args: "none", expected: []limitSpec{{period: models.LimitPeriodMonth}}},
+		{
+			args: "week=100 food/monthly=300:usd year=none",
+			expected: []limitSpec{
+				{period: models.LimitPeriodWeek, amount: amount(100)},
+				{period: models.LimitPeriodMonth, category: "food", amount: amount(300), currency: "USD"},
+				{period: models.LimitPeriodYear},
+			},
+		},
+		{args: "quarter=5", expected: []limitSpec{{period: models.LimitPeriodQuarter, amount: amount(5)}}},
+		{args: "food/restaurants/week=50", expected: []limitSpec{{period: models.LimitPeriodWeek, category: "food/restaurants", amount: amount(50)}}},
+		{
+			args: "week=100,rollover food/month=300:usd,rollover:50",
+			expected: []limitSpec{
+				{period: models.LimitPeriodWeek, amount: amount(100), rollover: &models.Rollover{}},
+				{period: models.LimitPeriodMonth, category: "food", amount: amount(300), currency: "USD", rollover: &models.Rollover{Cap: amount(50)}},
+			},
+		},
+		{args: "week=100,carry", fails: true},
+		{args: "week=100,rollover:abc", fails: true},
+		{args: "1000 usd week", fails: true},
+		{args: "day=100", fails: true},
+		{args: "/week=100", fails: true},
+		{args: "week=abc", fails: true},
+		{args: "week=100 200", fails: true},
+	}
+	for _, test := range tests {
+		testCase := test
+		t.Run(testCase.args, func(t *testing.T) {
+			actual, err := parseLimitArgs(strings.Fields(testCase.args))
+			if testCase.fails {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, actual)
+		})
+	}
+}
+
+func Test_handleBudgetCmd(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	var (
+		expUCMock   = expMock.NewMockUseCase(ctrl)
+		userUCMock  = userMock.NewMockUseCase(ctrl)
+		teleCtxMock = clMock.NewMocktelebotReducedContext(ctrl)
+	)
+	userID := 11
+	expectedLimit := models.Limit{
+		Period:   models.LimitPeriodMonth,
+		Category: "food",
+		Amount:   decimal.NewFromInt(500),
+		Currency: "USD",
+	}
+
+	teleCtxMock.EXPECT().Args().Times(1).Return([]string{"Food", "500"})
+	teleCtxMock.EXPECT().Message().Times(1).Return(&telebot.Message{Sender: &telebot.User{ID: int64(userID)}})
+	// the budget is in the user selected currency, not in the base one
+	currCall := userUCMock.EXPECT().GetUserCurrency(ctx, models.UserID(userID)).Times(1).Return(models.CurrencyCode("USD"), nil)
+	setCall := userUCMock.EXPECT().SetUserLimits(ctx, models.UserID(userID), []models.Limit{expectedLimit}).Times(1).
+		Return(nil).After(currCall)
+	teleCtxMock.EXPECT().Send(`Budget of category "food" successfully set to 500 USD`).Times(1).Return(nil).After(setCall)
+
+	cl := newClient(ctx, t, expUCMock, userUCMock)
+	err := cl.handleBudgetCmd(ctx, teleCtxMock)
+	require.NoError(t, err)
+}
+
+func Test_handleLimitCmd(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	var (
+		expUCMock   = expMock.NewMockUseCase(ctrl)
+		userUCMock  = userMock.NewMockUseCase(ctrl)
+		teleCtxMock = clMock.NewMocktelebotReducedContext(ctrl)
+	)
+	userID := 11
+	setLimits := []models.Limit{{Period: models.LimitPeriodWeek, Amount: decimal.NewFromInt(100), Currency: "stub"}}
+	removedLimits := []models.Limit{
+		{Period: models.LimitPeriodMonth, Category: "food"},
+		{Period: models.LimitPeriodYear},
+	}
+
+	teleCtxMock.EXPECT().Args().Times(1).Return([]string{"week=100", "food/month=none", "year=none"})
+	teleCtxMock.EXPECT().Message().Times(1).Return(&telebot.Message{Sender: &telebot.User{ID: int64(userID)}})
+	currCall := userUCMock.EXPECT().GetUserCurrency(ctx, models.UserID(userID)).Times(1).Return(models.CurrencyCode("stub"), nil)
+	// limits are set and removed by one call to be changed together
+	updateCall := userUCMock.EXPECT().UpdateUserLimits(ctx, models.UserID(userID), setLimits, removedLimits).Times(1).
+		Return(removedLimits[1:], nil).After(currCall)
+	teleCtxMock.EXPECT().Send(strings.Join([]string{
+		`Weekly limit successfully set to "100 stub"`,
+		`Monthly limit of category "food" successfully removed`,
+		`Yearly limit not found`,
+	}, "\n")).Times(1).Return(nil).After(updateCall)
+
+	cl := newClient(ctx, t, expUCMock, userUCMock)
+	err := cl.handleLimitCmd(ctx, teleCtxMock)
+	require.NoError(t, err)
+}
@@ -12,6 +12,9 @@ import (
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/pkg/errors"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/clients/tg/metrics"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/common/cache"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/common/cache/lru"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/ledger"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/user"
 	"go.uber.org/zap"
@@ -24,6 +27,7 @@ const (
 	updateIDKeyName  = "update_id"
 	messageIDKeyName = "message_id"
 	senderIDKeyName  = "sender_id"
+	accountIDKeyName = "account_id"
 )
 
 func createEndpointTracingMiddleware(endpoint string) func(endpointHandler) endpointHandler {
@@ -155,18 +159,101 @@ func createRequireArgsCountMiddleware(minArgsCount, maxArgsCount int) telebot.Mi
 	}
 }
 
-func createIsUserExistsMiddleware(ctx context.Context, userUC user.UseCase) telebot.MiddlewareFunc {
+// chatMember identifies the member of the group chat ledger.
+type chatMember struct {
+	chatID models.UserID
+	userID models.UserID
+}
+
+type chatMembersCache = cache.ThreadSafeCache[chatMember, models.LedgerMember, *lru.Cache[chatMember, models.LedgerMember]]
+
+// knownChatMembersCapacity limits the number of group chat members remembered as joined to the chat ledgers.
+const knownChatMembersCapacity = 10000
+
+func newChatMembersCache() (chatMembersCache, error) {
+	inner, err := lru.New[chatMember, models.LedgerMember](knownChatMembersCapacity)
+	if err != nil {
+		return nil, err
+	}
+	return cache.NewThreadSafeCache[chatMember, models.LedgerMember, *lru.Cache[chatMember, models.LedgerMember]](inner), nil
+}
+
+// isKnownChatMember reports whether the member has already joined the chat ledger with the same name and username.
+func isKnownChatMember(knownMembers chatMembersCache, key chatMember, member *models.LedgerMember) (bool, error) {
+	item, ok, err := knownMembers.Get(key)
+	if err != nil || !ok {
+		return false, err
+	}
+	known := item.Value()
+	return known.Name == member.Name && known.Username == member.Username, nil
+}
+
+// createAccountMiddleware resolves the account the update is handled for: the shared ledger of the group chat,
+// the ledger joined by the invite link in the private chat or the personal account of the user.
+// Users must introduce themselves to use the bot in the private chat, but not in group chats.
+// Group chat members are joined to the ledger only once, unless their name changes, known ones are kept in the cache.
+func createAccountMiddleware(
+	ctx context.Context,
+	userUC user.UseCase,
+	ledgerUC ledger.UseCase,
+	knownMembers chatMembersCache,
+) telebot.MiddlewareFunc {
 	return func(next telebot.HandlerFunc) telebot.HandlerFunc {
 		return func(teleCtx telebot.Context) error {
-			userID := models.UserID(teleCtx.Message().Sender.ID)
+			sender := teleCtx.Sender()
+			if chat := teleCtx.Chat(); chat != nil && isGroupChat(chat) {
+				member := newLedgerMember(sender)
+				// the ledger of the group chat has the chat ID
+				key := chatMember{chatID: models.UserID(chat.ID), userID: member.UserID}
+				known, err := isKnownChatMember(knownMembers, key, &member)
+				if err != nil {
+					return errors.Wrapf(err, "failed to get in middleware known member userID=%d of chatID=%d", sender.ID, chat.ID)
+				}
+				if !known {
+					if _, err := ledgerUC.JoinChatLedger(ctx, key.chatID, chat.Title, member); err != nil {
+						return errors.Wrapf(err, "failed to join in middleware userID=%d to ledger of chatID=%d", sender.ID, chat.ID)
+					}
+					if err := knownMembers.Set(key, member); err != nil {
+						return errors.Wrapf(err, "failed to remember in middleware member userID=%d of chatID=%d", sender.ID, chat.ID)
+					}
+				}
+				teleCtx.Set(accountIDKeyName, key.chatID)
+				return next(teleCtx)
+			}
+			userID := models.UserID(sender.ID)
 			exists, err := userUC.IsUserExists(ctx, userID)
 			if err != nil {
 				return errors.Wrapf(err, "failed to check in middleware whether the user with ID=%d exists", userID)
 			}
-			if exists {
-				return next(teleCtx)
+			if !exists {
+				return teleCtx.Send(unknownUserMsg)
+			}
+			l, err := ledgerUC.GetActiveLedger(ctx, userID)
+			switch {
+			case err == nil:
+				teleCtx.Set(accountIDKeyName, l.ID)
+			case !errors.Is(err, ledger.ErrDoesNotExist):
+				return errors.Wrapf(err, "failed to get in middleware active ledger of userID=%d", userID)
 			}
-			return teleCtx.Send(unknownUserMsg)
+			return next(teleCtx)
 		}
 	}
 }
+
+type accountIDKey struct{}
+
+// withAccountID passes the account ID resolved by the middleware to the handler context.
+func withAccountID(ctx context.Context, teleCtx telebot.Context) context.Context {
+	if id, ok := teleCtx.Get(accountIDKeyName).(models.UserID); ok {
+		return context.WithValue(ctx, accountIDKey{}, id)
+	}
+	return ctx
+}
+
+// accountID returns the ID of the account the update is handled for, it's the sender ID by default.
+func accountID(ctx context.Context, sender *telebot.User) models.UserID {
+	if id, ok := ctx.Value(accountIDKey{}).(models.UserID); ok {
+		return id
+	}
+	return models.UserID(sender.ID)
+}
@@ -0,0 +1,60 @@
+package tg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/ledger"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+	"gopkg.in/telebot.v3"
+)
+
+// chatLedgerJoiner records members joined to the chat ledgers.
+type chatLedgerJoiner struct {
+	ledger.UseCase
+	joined []models.LedgerMember
+}
+
+func (j *chatLedgerJoiner) JoinChatLedger(
+	_ context.Context,
+	chatID models.UserID,
+	title string,
+	member models.LedgerMember,
+) (models.Ledger, error) {
+	j.joined = append(j.joined, member)
+	return models.Ledger{ID: chatID, Title: title, Members: []models.LedgerMember{member}}, nil
+}
+
+func Test_createAccountMiddlewareJoinsChatLedgerOnce(t *testing.T) {
+	ctx := context.Background()
+	bot, err := telebot.NewBot(telebot.Settings{Offline: true})
+	require.NoError(t, err)
+	knownMembers, err := newChatMembersCache()
+	require.NoError(t, err)
+	joiner := &chatLedgerJoiner{}
+	handler := createAccountMiddleware(ctx, nil, joiner, knownMembers)(func(teleCtx telebot.Context) error {
+		require.Equal(t, models.UserID(-100), teleCtx.Get(accountIDKeyName))
+		return nil
+	})
+
+	chat := &telebot.Chat{ID: -100, Type: telebot.ChatGroup, Title: "flat"}
+	sender := &telebot.User{ID: 1, FirstName: "Bob"}
+	message := &telebot.Message{Chat: chat, Sender: sender}
+	require.NoError(t, handler(bot.NewContext(telebot.Update{Message: message})))
+	require.NoError(t, handler(bot.NewContext(telebot.Update{EditedMessage: message})))
+	require.NoError(t, handler(bot.NewContext(telebot.Update{Callback: &telebot.Callback{Sender: sender, Message: message}})))
+	require.Len(t, joiner.joined, 1)
+
+	// the changed name is updated in the ledger
+	renamed := &telebot.Message{Chat: chat, Sender: &telebot.User{ID: 1, FirstName: "Robert"}}
+	require.NoError(t, handler(bot.NewContext(telebot.Update{Message: renamed})))
+	require.Len(t, joiner.joined, 2)
+	require.Equal(t, "Robert", joiner.joined[1].Name)
+
+	// the member joins again after leaving the ledger
+	_, err = knownMembers.Drop(chatMember{chatID: -100, userID: 1})
+	require.NoError(t, err)
+	require.NoError(t, handler(bot.NewContext(telebot.Update{Message: renamed})))
+	require.Len(t, joiner.joined, 3)
+}
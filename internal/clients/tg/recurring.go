@@ -0,0 +1,202 @@
+package tg
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/recurring"
+)
+
+const (
+	recurringRuleHelp = "'monthly:<day of month>', 'weekly:<weekday name>' or 'every:<days count>'"
+	recurringUsage    = "" +
+		"Usage:\n" +
+		"/recurring add <category - one word> <amount - float> <rule> <comment, optional>\n" +
+		"/recurring list\n" +
+		"/recurring pause <ID>\n" +
+		"/recurring resume <ID>\n" +
+		"/recurring delete <ID>\n" +
+		"Rule can be " + recurringRuleHelp + "."
+)
+
+const (
+	noRecurringExpensesFoundMsg   = "No recurring expenses found."
+	recurringExpenseNotFoundMsg   = "Recurring expense not found."
+	recurringExpenseIsPausedMsg   = "Recurring expense is already paused."
+	recurringExpenseIsActiveMsg   = "Recurring expense is already active."
+	recurringRuleIsInvalidMsg     = "Unknown recurrence rule, expected one of " + recurringRuleHelp + "."
+	recurringExpenseIsCreatedFmt  = "Recurring expense #%d successfully created, the first one is on %s"
+	recurringExpenseIsResumedFmt  = "Recurring expense #%d successfully resumed, the next one is on %s"
+	recurringExpenseIsPausedFmt   = "Recurring expense #%d successfully paused"
+	recurringExpenseIsDeletedFmt  = "Recurring expense #%d successfully deleted"
+	recurringExpensesListHeadline = "Your recurring expenses:"
+)
+
+// parseRecurrenceRule parses the rule in one of formats described in recurringRuleHelp.
+func parseRecurrenceRule(expr string) (models.RecurrenceRule, error) {
+	kind, value, ok := strings.Cut(strings.ToLower(expr), ":")
+	if !ok {
+		return models.RecurrenceRule{}, errors.Wrapf(models.ErrRecurrenceRuleInvalid, "rule %q", expr)
+	}
+	rule := models.RecurrenceRule{Kind: models.RecurrenceKind(kind)}
+	if rule.Kind == models.RecurrenceWeekly {
+		weekday, ok := weekdays[value]
+		if !ok {
+			return models.RecurrenceRule{}, errors.Wrapf(models.ErrRecurrenceRuleInvalid, "weekday %q", value)
+		}
+		rule.Value = int(weekday)
+	} else {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return models.RecurrenceRule{}, errors.Wrapf(models.ErrRecurrenceRuleInvalid, "value %q", value)
+		}
+		rule.Value = n
+	}
+	if err := rule.Validate(); err != nil {
+		return models.RecurrenceRule{}, err
+	}
+	return rule, nil
+}
+
+// parseRecurringExpenseArgs parses '<category> <amount> <rule> <comment, optional>' arguments.
+// Returned error is suitable to be sent to the user as is.
+func parseRecurringExpenseArgs(args []string) (models.RecurringExpense, error) {
+	if len(args) < 3 {
+		return models.RecurringExpense{}, errors.New("Not enough arguments to parse recurring expense")
+	}
+	category, strAmount, ruleExpr, commentWords := args[0], args[1], args[2], args[3:]
+
+	amount, err := decimal.NewFromString(strAmount)
+	if err != nil {
+		return models.RecurringExpense{}, errors.Wrap(err, "Failed to parse amount")
+	}
+	rule, err := parseRecurrenceRule(ruleExpr)
+	if err != nil {
+		return models.RecurringExpense{}, errors.New(recurringRuleIsInvalidMsg)
+	}
+	rec := models.RecurringExpense{
+		Category: models.ExpenseCategory(category),
+		Amount:   amount,
+		Comment:  strings.Join(commentWords, " "),
+		Rule:     rule,
+	}
+	return rec, nil
+}
+
+func parseRecurringExpenseID(arg string) (models.RecurringExpenseID, error) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(arg, "#"), 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to parse recurring expense ID")
+	}
+	return models.RecurringExpenseID(id), nil
+}
+
+func printRecurringExpense(rec models.RecurringExpense) string {
+	out := fmt.Sprintf("#%d %s %v %s", rec.ID, rec.Category, rec.Amount, rec.Rule)
+	if rec.Comment != "" {
+		out += fmt.Sprintf(" %q", rec.Comment)
+	}
+	if rec.Paused {
+		return out + ", paused"
+	}
+	return out + ", next on " + formatDate(rec.NextDate)
+}
+
+func (c *Client) handleRecurringCmd(ctx context.Context, teleCtx telebotReducedContext) error {
+	args := teleCtx.Args()
+	if len(args) == 0 {
+		return errors.New("not enough arguments to handle recurring expenses")
+	}
+	subcommand, args := args[0], args[1:]
+	switch {
+	case subcommand == "add" && len(args) >= 3:
+		return c.handleAddRecurringExpense(ctx, teleCtx, args)
+	case subcommand == "list" && len(args) == 0:
+		return c.handleListRecurringExpenses(ctx, teleCtx)
+	case (subcommand == "pause" || subcommand == "resume" || subcommand == "delete") && len(args) == 1:
+		id, err := parseRecurringExpenseID(args[0])
+		if err != nil {
+			return teleCtx.Send(err.Error())
+		}
+		return c.handleChangeRecurringExpense(ctx, teleCtx, subcommand, id)
+	default:
+		return teleCtx.Send(recurringUsage)
+	}
+}
+
+func (c *Client) handleAddRecurringExpense(ctx context.Context, teleCtx telebotReducedContext, args []string) error {
+	rec, err := parseRecurringExpenseArgs(args)
+	if err != nil {
+		return teleCtx.Send(err.Error())
+	}
+	exp := rec.Expense(rec.NextDate)
+	if err := exp.Validate(); err != nil {
+		return sendExpenseValidationError(teleCtx, err)
+	}
+	userID := accountID(ctx, teleCtx.Message().Sender)
+	created, err := c.recUC.AddRecurringExpense(ctx, userID, rec)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create recurring expense for userID=%d", userID)
+	}
+	return teleCtx.Send(fmt.Sprintf(recurringExpenseIsCreatedFmt, created.ID, formatDate(created.NextDate)))
+}
+
+func (c *Client) handleListRecurringExpenses(ctx context.Context, teleCtx telebotReducedContext) error {
+	userID := accountID(ctx, teleCtx.Message().Sender)
+	recs, err := c.recUC.GetRecurringExpenses(ctx, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get recurring expenses for userID=%d", userID)
+	}
+	if len(recs) == 0 {
+		return teleCtx.Send(noRecurringExpensesFoundMsg)
+	}
+	lines := make([]string, 0, len(recs)+1)
+	lines = append(lines, recurringExpensesListHeadline)
+	for _, rec := range recs {
+		lines = append(lines, printRecurringExpense(rec))
+	}
+	return teleCtx.Send(strings.Join(lines, "\n"))
+}
+
+func (c *Client) handleChangeRecurringExpense(
+	ctx context.Context,
+	teleCtx telebotReducedContext,
+	subcommand string,
+	id models.RecurringExpenseID,
+) error {
+	userID := accountID(ctx, teleCtx.Message().Sender)
+	var (
+		msg string
+		err error
+	)
+	switch subcommand {
+	case "pause":
+		err = c.recUC.PauseRecurringExpense(ctx, userID, id)
+		msg = fmt.Sprintf(recurringExpenseIsPausedFmt, id)
+	case "resume":
+		var rec models.RecurringExpense
+		rec, err = c.recUC.ResumeRecurringExpense(ctx, userID, id)
+		msg = fmt.Sprintf(recurringExpenseIsResumedFmt, id, formatDate(rec.NextDate))
+	default:
+		err = c.recUC.DeleteRecurringExpense(ctx, userID, id)
+		msg = fmt.Sprintf(recurringExpenseIsDeletedFmt, id)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, recurring.ErrDoesNotExist):
+			return teleCtx.Send(recurringExpenseNotFoundMsg)
+		case errors.Is(err, recurring.ErrAlreadyPaused):
+			return teleCtx.Send(recurringExpenseIsPausedMsg)
+		case errors.Is(err, recurring.ErrAlreadyActive):
+			return teleCtx.Send(recurringExpenseIsActiveMsg)
+		default:
+			return errors.Wrapf(err, "failed to %s recurring expenseID=%d for userID=%d", subcommand, id, userID)
+		}
+	}
+	return teleCtx.Send(msg)
+}
@@ -0,0 +1,40 @@
+package tg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+func Test_parseRecurrenceRule(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected models.RecurrenceRule
+		fails    bool
+	}{
+		{expr: "monthly:25", expected: models.RecurrenceRule{Kind: models.RecurrenceMonthly, Value: 25}},
+		{expr: "weekly:Mon", expected: models.RecurrenceRule{Kind: models.RecurrenceWeekly, Value: int(time.Monday)}},
+		{expr: "weekly:sunday", expected: models.RecurrenceRule{Kind: models.RecurrenceWeekly, Value: int(time.Sunday)}},
+		{expr: "every:14", expected: models.RecurrenceRule{Kind: models.RecurrenceEveryDays, Value: 14}},
+		{expr: "monthly:32", fails: true},
+		{expr: "monthly", fails: true},
+		{expr: "weekly:1", fails: true},
+		{expr: "every:0", fails: true},
+		{expr: "daily:1", fails: true},
+	}
+	for _, test := range tests {
+		testCase := test
+		t.Run(testCase.expr, func(t *testing.T) {
+			actual, err := parseRecurrenceRule(testCase.expr)
+			if testCase.fails {
+				require.ErrorIs(t, err, models.ErrRecurrenceRuleInvalid)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, actual)
+		})
+	}
+}
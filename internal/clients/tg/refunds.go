@@ -0,0 +1,52 @@
+package tg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+const (
+	refundAmountIsNotPositiveMsg = "Please, provide positive refund amount."
+	refundExceedsExpenseMsg      = "Can't refund more than the rest of the expense."
+)
+
+func (c *Client) handleRefundCmd(ctx context.Context, teleCtx telebotReducedContext) error {
+	args := teleCtx.Args()
+	if len(args) < 1 {
+		return errors.New("not enough arguments to refund expense")
+	}
+	id, err := parseExpenseID(args[0])
+	if err != nil {
+		return teleCtx.Send(err.Error())
+	}
+	// zero amount refunds the rest of the expense
+	var amount decimal.Decimal
+	if len(args) > 1 {
+		if amount, err = decimal.NewFromString(args[1]); err != nil {
+			return teleCtx.Send(fmt.Sprintf("Failed to parse amount: %v", err))
+		}
+		if !amount.IsPositive() {
+			return teleCtx.Send(refundAmountIsNotPositiveMsg)
+		}
+	}
+	userID := accountID(ctx, teleCtx.Message().Sender)
+	exp, err := c.expUC.RefundExpense(ctx, userID, id, amount)
+	if err != nil {
+		switch {
+		case errors.Is(err, expense.ErrDoesNotExist):
+			return teleCtx.Send(expenseNotFoundMsg)
+		case errors.Is(err, expense.ErrRefundExceedsExpense):
+			return teleCtx.Send(refundExceedsExpenseMsg)
+		case errors.Is(err, models.ErrRefundAmountIsNotPositive):
+			return teleCtx.Send(refundAmountIsNotPositiveMsg)
+		default:
+			return errors.Wrapf(err, "failed to refund expenseID=%d for userID=%d", id, userID)
+		}
+	}
+	return teleCtx.Send("Refund successfully recorded\n" + printExpense(exp, ""))
+}
@@ -0,0 +1,133 @@
+package tg
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+const (
+	addRuleArg    = "add"
+	listRulesArg  = "list"
+	deleteRuleArg = "delete"
+
+	rulesUsageMsg                 = "Usage: /rules " + addRuleArg + " <category> <keyword or /regex/>, /rules " + listRulesArg + ", /rules " + deleteRuleArg + " <ID>"
+	noCategoryRulesFoundMsg       = "There are no category rules yet."
+	categoryRuleNotFoundMsg       = "Category rule not found."
+	categoryRulePatternIsEmptyMsg = "Please, provide the keyword or the regex of the rule."
+	categoryRuleNotMatchedMsg     = "No category rule matches the comment, please, provide the category or add the rule with /rules."
+)
+
+// handleRulesCmd manages rules picking the category of the expense by its comment when the category is omitted.
+func (c *Client) handleRulesCmd(ctx context.Context, teleCtx telebotReducedContext) error {
+	args := teleCtx.Args()
+	userID := accountID(ctx, teleCtx.Message().Sender)
+	switch {
+	case args[0] == listRulesArg && len(args) == 1:
+		rules, err := c.expUC.GetCategoryRules(ctx, userID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get category rules for userID=%d", userID)
+		}
+		return teleCtx.Send(formatCategoryRules(rules))
+	case args[0] == addRuleArg && len(args) > 2:
+		return c.addCategoryRule(ctx, teleCtx, userID, models.ExpenseCategory(args[1]), strings.Join(args[2:], " "))
+	case args[0] == deleteRuleArg && len(args) == 2:
+		id, err := strconv.ParseInt(strings.TrimPrefix(args[1], "#"), 10, 64)
+		if err != nil {
+			return teleCtx.Send(fmt.Sprintf("Failed to parse rule ID: %v", err))
+		}
+		if err := c.expUC.DeleteCategoryRule(ctx, userID, models.CategoryRuleID(id)); err != nil {
+			switch {
+			case errors.Is(err, expense.ErrCategoryRuleDoesNotExist):
+				return teleCtx.Send(categoryRuleNotFoundMsg)
+			default:
+				return errors.Wrapf(err, "failed to delete category ruleID=%d for userID=%d", id, userID)
+			}
+		}
+		return teleCtx.Send(fmt.Sprintf("Category rule #%d successfully removed", id))
+	default:
+		return teleCtx.Send(rulesUsageMsg)
+	}
+}
+
+func (c *Client) addCategoryRule(
+	ctx context.Context,
+	teleCtx telebotReducedContext,
+	userID models.UserID,
+	category models.ExpenseCategory,
+	pattern string,
+) error {
+	rule := models.CategoryRule{Pattern: pattern, Category: category}
+	if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		rule.Pattern, rule.IsRegex = pattern[1:len(pattern)-1], true
+	}
+	rule, err := c.expUC.AddCategoryRule(ctx, userID, rule)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrCategoryRulePatternIsEmpty):
+			return teleCtx.Send(categoryRulePatternIsEmptyMsg)
+		case errors.Is(err, models.ErrCategoryRuleRegexIsInvalid):
+			return teleCtx.Send(fmt.Sprintf("Failed to parse regex: %v", err))
+		case errors.Is(err, models.ErrExpenseCategoryIsInvalid):
+			return teleCtx.Send(expenseCategoryIsInvalidMsg)
+		default:
+			return errors.Wrapf(err, "failed to add category rule for userID=%d", userID)
+		}
+	}
+	return teleCtx.Send(fmt.Sprintf("Category rule #%d %s successfully added", rule.ID, rule))
+}
+
+func formatCategoryRules(rules []models.CategoryRule) string {
+	if len(rules) == 0 {
+		return noCategoryRulesFoundMsg
+	}
+	var sb strings.Builder
+	sb.WriteString("Category rules in the order they're applied:\n")
+	for _, rule := range rules {
+		if rule.ID == 0 {
+			sb.WriteString(fmt.Sprintf("default: %s\n", rule))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("#%d: %s\n", rule.ID, rule))
+	}
+	return sb.String()
+}
+
+// categorizeByRules picks the category of the expense without one by the category rule matching its comment.
+// The returned note describes the applied rule, the category is left empty if no rule matches.
+func (c *Client) categorizeByRules(ctx context.Context, userID models.UserID, exp *models.Expense) (string, error) {
+	if exp.Category != "" {
+		return "", nil
+	}
+	rule, ok, err := c.expUC.MatchCategoryRule(ctx, userID, exp.Comment)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to match category rules for userID=%d", userID)
+	}
+	if !ok {
+		return "", nil
+	}
+	exp.Category = rule.Category
+	return fmt.Sprintf("category is picked by the rule %s", rule), nil
+}
+
+// categorizeFreeFormByRules picks the category of the free form expense starting with the amount by the rule
+// matching its words, the first word stays the category if no rule matches. It reports whether the rule is applied.
+func (c *Client) categorizeFreeFormByRules(ctx context.Context, userID models.UserID, parsed *freeFormExpense) (bool, error) {
+	if parsed.wordsAfterAmount == "" {
+		return false, nil
+	}
+	exp := parsed.expense
+	exp.Category, exp.Comment = "", parsed.wordsAfterAmount
+	note, err := c.categorizeByRules(ctx, userID, &exp)
+	if err != nil || exp.Category == "" {
+		return false, err
+	}
+	parsed.expense = exp
+	parsed.ambiguities = append(parsed.ambiguities, note)
+	return true, nil
+}
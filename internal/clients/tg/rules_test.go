@@ -0,0 +1,68 @@
+package tg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clMock "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/generated/mocks/clients"
+	expMock "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/generated/mocks/expense"
+	userMock "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/generated/mocks/user"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+	"gopkg.in/telebot.v3"
+)
+
+func Test_handleExpenseCmd_categoryRule(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	var (
+		expUCMock   = expMock.NewMockUseCase(ctrl)
+		userUCMock  = userMock.NewMockUseCase(ctrl)
+		teleCtxMock = clMock.NewMocktelebotReducedContext(ctrl)
+	)
+	var (
+		userID      = 11
+		messageID   = 22
+		day         = time.Date(2023, time.February, 25, 0, 0, 0, 0, time.UTC)
+		rule        = models.CategoryRule{ID: 5, Pattern: "starbucks", Category: "food/coffee"}
+		expectedExp = models.Expense{
+			Category: rule.Category,
+			Amount:   decimal.NewFromInt(250),
+			Date:     day,
+			Comment:  "starbucks latte",
+			AuthorID: models.UserID(userID),
+		}
+		createdExp = expectedExp
+	)
+	createdExp.ID = 33
+
+	argCall := teleCtxMock.EXPECT().Args().Times(1).Return([]string{"250", "starbucks", "latte"})
+	msgCall := teleCtxMock.EXPECT().Message().Times(1).Return(&telebot.Message{
+		ID:       messageID,
+		Sender:   &telebot.User{ID: int64(userID)},
+		Unixtime: day.Add(10 * time.Hour).Unix(),
+	}).After(argCall)
+	tzCall := userUCMock.EXPECT().GetUserTimeZone(ctx, models.UserID(userID)).Times(1).Return(time.UTC, nil).After(msgCall)
+	matchCall := expUCMock.EXPECT().MatchCategoryRule(ctx, models.UserID(userID), "starbucks latte").Times(1).
+		Return(rule, true, nil).After(tzCall)
+	addExpCall := expUCMock.EXPECT().AddExpenseFromMessage(ctx, models.UserID(userID), expectedExp, models.MessageRef{MessageID: models.MessageID(messageID)}).Times(1).
+		Return(createdExp, nil).After(matchCall)
+	teleCtxMock.EXPECT().Send("Expense successfully created\nThe category is picked by the rule \"starbucks\" -> food/coffee", gomock.Any()).
+		Times(1).Return(nil).After(addExpCall)
+
+	cl := newClient(ctx, t, expUCMock, userUCMock)
+	err := cl.handleExpenseCmd(ctx, teleCtxMock)
+	require.NoError(t, err)
+}
+
+func Test_formatCategoryRules(t *testing.T) {
+	assert.Equal(t, noCategoryRulesFoundMsg, formatCategoryRules(nil))
+	assert.Equal(t, "Category rules in the order they're applied:\n#3: /^uber/ -> taxi\ndefault: \"starbucks\" -> coffee\n", formatCategoryRules([]models.CategoryRule{
+		{ID: 3, Pattern: "^uber", IsRegex: true, Category: "taxi"},
+		{Pattern: "starbucks", Category: "coffee"},
+	}))
+}
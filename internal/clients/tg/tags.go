@@ -0,0 +1,59 @@
+package tg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+const (
+	tagsReportArg      = "tags"
+	tagsReportUsageMsg = "Usage: /report " + tagsReportArg + " <period or since> <till, optional> <currency, optional>"
+	noTagsFoundMsg     = "No tagged expenses found. Add #hashtags to expense comments to tag them."
+)
+
+// cutTagArg cuts the last #hashtag from the arguments, empty tag is returned if there is no such argument.
+func cutTagArg(args []string) ([]string, models.ExpenseTag) {
+	for i := len(args) - 1; i >= 0; i-- {
+		if len(args[i]) < 2 || !strings.HasPrefix(args[i], "#") {
+			continue
+		}
+		tag := models.ExpenseTag(strings.ToLower(args[i][1:]))
+		out := make([]string, 0, len(args)-1)
+		out = append(out, args[:i]...)
+		return append(out, args[i+1:]...), tag
+	}
+	return args, ""
+}
+
+// sendTagsReport sends the summary report by tags in curr, args are the report dates.
+func (c *Client) sendTagsReport(ctx context.Context, teleCtx telebotReducedContext, args []string, curr models.CurrencyCode) error {
+	if len(args) < 1 || len(args) > 2 {
+		return teleCtx.Send(tagsReportUsageMsg)
+	}
+	teleMsg := teleCtx.Message()
+	userID := accountID(ctx, teleMsg.Sender)
+	today, periodStartDay, err := c.getUserDateContext(ctx, userID, teleMsg.Time())
+	if err != nil {
+		return err
+	}
+	since, till, err := parseDateRange(args, today, periodStartDay)
+	if err != nil {
+		return teleCtx.Send(fmt.Sprintf("Failed to parse dates: %v", err))
+	}
+	report, err := c.expUC.GetExpensesSummaryByTagSince(ctx, userID, since, till, curr)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create expenses report by tags for userID=%d", userID)
+	}
+	if len(report) == 0 {
+		return teleCtx.Send(noTagsFoundMsg)
+	}
+	msg, err := report.Text()
+	if err != nil {
+		return errors.Wrapf(err, "failed to convert expenses report by tags to text message for userID=%d", userID)
+	}
+	return teleCtx.Send(msg)
+}
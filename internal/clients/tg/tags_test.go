@@ -0,0 +1,27 @@
+package tg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+func Test_cutTagArg(t *testing.T) {
+	tests := []struct {
+		args         []string
+		expectedArgs []string
+		expectedTag  models.ExpenseTag
+	}{
+		{args: []string{"month"}, expectedArgs: []string{"month"}},
+		{args: []string{"month", "#Trip-Italy"}, expectedArgs: []string{"month"}, expectedTag: "trip-italy"},
+		{args: []string{"month", "#work", "USD"}, expectedArgs: []string{"month", "USD"}, expectedTag: "work"},
+		{args: []string{"#work"}, expectedArgs: []string{}, expectedTag: "work"},
+		{args: []string{"month", "#"}, expectedArgs: []string{"month", "#"}},
+	}
+	for _, test := range tests {
+		args, tag := cutTagArg(test.args)
+		assert.Equal(t, test.expectedArgs, args, test.args)
+		assert.Equal(t, test.expectedTag, tag, test.args)
+	}
+}
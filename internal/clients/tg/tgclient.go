@@ -4,15 +4,21 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/debt"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/income"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/ledger"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/recurring"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/user"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
@@ -27,28 +33,42 @@ type Client struct {
 	supportedCurrSlice []models.CurrencyCode
 	expUC              expense.UseCase
 	userUC             user.UseCase
+	recUC              recurring.UseCase
+	ledgerUC           ledger.UseCase
+	debtUC             debt.UseCase
+	incomeUC           income.UseCase
 	logger             *zap.Logger
+	undoTimeWindow     time.Duration
+	knownChatMembers   chatMembersCache
 }
 
+const defaultUndoTimeWindow = 5 * time.Minute
+
 type Options struct {
-	Logger     *zap.Logger
-	LogUpdates bool
-	BlackList  []int64
-	WhiteList  []int64
-	Debug      bool
-	offline    bool
+	Logger         *zap.Logger
+	LogUpdates     bool
+	BlackList      []int64
+	WhiteList      []int64
+	Debug          bool
+	UndoTimeWindow time.Duration // zero value means defaultUndoTimeWindow
+	offline        bool
 }
 
 func NewWithOptions(
 	token string,
 	baseCurr models.CurrencyCode, supported []models.CurrencyCode,
-	expUC expense.UseCase, userUC user.UseCase,
+	expUC expense.UseCase, userUC user.UseCase, recUC recurring.UseCase, ledgerUC ledger.UseCase, debtUC debt.UseCase,
+	incomeUC income.UseCase,
 	opts Options,
 ) (*Client, error) {
 	logger := opts.Logger
 	if logger == nil {
 		logger = zap.L()
 	}
+	undoTimeWindow := opts.UndoTimeWindow
+	if undoTimeWindow == 0 {
+		undoTimeWindow = defaultUndoTimeWindow
+	}
 
 	pref := telebot.Settings{
 		Token:   token,
@@ -102,6 +122,10 @@ func NewWithOptions(
 	sort.Slice(supported, func(i, j int) bool {
 		return supported[i] < supported[j]
 	})
+	knownChatMembers, err := newChatMembersCache()
+	if err != nil {
+		return nil, errors.Wrap(err, "creating chat members cache")
+	}
 	client := &Client{
 		bot:                bot,
 		baseCurr:           baseCurr,
@@ -109,7 +133,13 @@ func NewWithOptions(
 		supportedCurrSlice: supported,
 		expUC:              expUC,
 		userUC:             userUC,
+		recUC:              recUC,
+		ledgerUC:           ledgerUC,
+		debtUC:             debtUC,
+		incomeUC:           incomeUC,
 		logger:             logger,
+		undoTimeWindow:     undoTimeWindow,
+		knownChatMembers:   knownChatMembers,
 	}
 	return client, nil
 }
@@ -123,26 +153,55 @@ const (
 	expensesAmountExceededMsg     = "Can't add expense. Expenses amount exceeded."
 	expenseAmountIsNotPositiveMsg = "Please, provide positive expense amount."
 	expenseAmountIsTooBigMsg      = "Expense amount is too big"
-	monthlyLimitIsNegativeMsg     = "Please, provide not negative limit amount or absense of amount."
-	monthlyLimitIsTooBigMsg       = "Monthly limit is too big."
-)
-
-const (
-	noneUserMonthlyLimitValue = "none"
+	expenseCategoryIsInvalidMsg   = "Please, provide nested category like 'food/restaurants' without empty parts."
+	expenseNotFoundMsg            = "Expense not found."
+	expenseAlreadyCreatedMsg      = "Expense of this message is already created."
+	editedMessageNotLinkedMsg     = "Can't find expense created by the edited message."
+	editedMessageNotParsedMsg     = "Can't understand expense in the edited message."
+	unknownTimeZoneMsg            = "Unknown time zone, please use IANA name like 'Europe/Moscow' or UTC offset like 'UTC+3'."
+	periodStartDayInvalidMsg      = "Please, provide budget period start day from 1 to 31."
 )
 
 func makeHelpMsg(baseCurr models.CurrencyCode) string {
 	const helpMsgFormat = "" +
 		"List of supported commands:\n" +
-		"/start - send hello and register new user with default selected currency %q\n" +
+		"/start - send hello and register new user with default selected currency %q, in group chat create the ledger shared by its members\n" +
 		"/hello - send hello\n" +
 		"/help - print this help\n" +
 		"/currency - show selected currency or change it to the new one. Usage: /currency <currency - optional>\n" +
-		"/expense - create new expense. Usage: /expense <category - one word> <amount - float> <date - format 'yyyy.mm.dd'> <comment, optional>\n" +
-		"/report - summary report by categories since and till some dates. Usage: /report <since - format 'yyyy.mm.dd'> <till - format 'yyyy.mm.dd'>\n" +
-		"/list - list expenses since and till some dates. Usage: /list <since - format 'yyyy.mm.dd'> <till - format 'yyyy.mm.dd'>\n" +
-		"/limit - show expenses amount monthly limit in default currency %q or change it to another one. Usage: /limit <amount - float or '%s', optional>\n"
-	return fmt.Sprintf(helpMsgFormat, baseCurr, baseCurr, noneUserMonthlyLimitValue)
+		"/period - show the day of month your budget period starts or change it. Usage: /period <day from 1 to 31, optional>\n" +
+		"/timezone - show your time zone or change it to the new one. Usage: /timezone <IANA name or UTC offset, optional>\n" +
+		"/expense - create new expense, the amount is in your selected currency unless the other one is given, e.g. 12.5EUR. Usage: /expense <category - one word> <amount - float> <date> <time hh:mm, optional> <currency, optional> <comment, optional>\n" +
+		"/expense - create new expense with the category picked by /rules matching the comment or chosen from the ones suggested by your history. Usage: /expense <amount - float> <date, optional> <time hh:mm, optional> <currency, optional> <comment>\n" +
+		"/edit - change existing expense. Usage: /edit <expense ID> <category - one word> <amount - float> <date> <time hh:mm, optional> <currency, optional> <comment, optional>\n" +
+		"/delete - delete existing expense. Usage: /delete <expense ID>\n" +
+		"/refund - record the refund of the expense, the whole rest of the expense is refunded by default. Usage: /refund <expense ID> <amount in the expense currency - float, optional>\n" +
+		"/report - summary report by categories for the period or since and till some dates, optionally split by members who added expenses, only of the category and its children, only of expenses with the #tag or in the other currency. Usage: /report <'" + membersReportArg + "', optional> <period or since> <till, optional> <category, optional> <currency, optional> <#tag, optional>\n" +
+		"/report " + tagsReportArg + " - summary report by #tags of expense comments. Usage: /report " + tagsReportArg + " <period or since> <till, optional> <currency, optional>\n" +
+		"/list - list expenses with their IDs for the period or since and till some dates, optionally only the ones with the #tag. Usage: /list <period or since> <till, optional> <currency, optional> <#tag, optional>\n" +
+		"/limit - show spent and remaining amounts of your limits in the current periods or change several limits at once. Usage: /limit <limit, optional>..., e.g. /limit week=100 food/month=300:USD\n" +
+		"/limit - change the monthly limit of all expenses. Usage: /limit <amount - float or '%s'> <currency, optional>\n" +
+		"/limit mode - change what happens with expenses exceeding limits: 'hard' rejects them, 'soft' accepts them with warning. Usage: /limit mode <hard or soft>\n" +
+		"/budget - show spent and remaining amounts of category limits in the current periods or change the monthly category limit in your currency. Usage: /budget <category - one word, optional> <amount - float or '%s', optional> <'rollover', optional> <rollover cap - float, optional>\n" +
+		"/budget history - show amounts carried to the last periods by limits with rollover\n" +
+		"/ledger - show members and the invite link of the shared ledger or leave the ledger joined by the link. Usage: /ledger <'" + leaveLedgerArg + "', optional>\n" +
+		"/income - add new income, the arguments are the same as the ones of /expense. Usage: /income <source - one word> <amount - float> <date> <time hh:mm, optional> <currency, optional> <comment, optional>\n" +
+		"/balance - show income by sources, expenses, savings and savings rate by budget months for the period or since and till some dates. Usage: /balance <period or since> <till, optional>\n" +
+		"/split - add the expense paid by you and split it equally between you and the mentioned members of the shared ledger. Usage: /split <amount - float> <category - one word> <@username>... <comment, optional>\n" +
+		"/debts - show net balances of the shared ledger members and the fewest transfers to settle up\n" +
+		"/settle - record your repayment to the member, the suggested transfer amount is used by default. Usage: /settle <@username> <amount - float, optional>\n" +
+		"/recurring - manage recurring expenses. Usage: /recurring add <category - one word> <amount - float> <rule> <comment, optional>, /recurring list, /recurring pause|resume|delete <ID>\n" +
+		"/rules - manage rules picking the category by the expense comment when the category is omitted, your rules are applied before the default ones. Usage: /rules " + addRuleArg + " <category> <keyword or /regex/>, /rules " + listRulesArg + ", /rules " + deleteRuleArg + " <ID>\n" +
+		"/categories - list categories with expenses counts and totals, rename or merge categories with their children in all expenses, show or change category aliases applied to new expenses. Usage: /categories, /categories " + renameCategoryArg + " <old> <new>, /categories " + mergeCategoryArg + " <from> <to>, /categories " + categoryAliasArg + " <alias, optional> <category or '" + noneLimitValue + "', optional>\n" +
+		"\nDate can be " + dateExprHelp + ".\n" +
+		"Period can be " + periodExprHelp + ".\n" +
+		"Recurrence rule can be " + recurringRuleHelp + ".\n" +
+		"Limit can be " + limitSpecHelp + ".\n" +
+		"\nAdd the bot to a group chat to share expenses, limits and settings between its members.\n" +
+		"Categories can be nested like 'food/restaurants', reports show subtotals of parent categories and budgets of them cover their children. Case of categories is ignored.\n" +
+		"#hashtags in expense comments tag the expense, e.g. 'hotel #trip-italy'.\n" +
+		"Expense can also be sent as a plain text: <category> <amount> <date, optional> <comment, optional>, e.g. 'taxi 430 yesterday airport', or starting with the amount to apply /rules, e.g. '250 starbucks latte'\n"
+	return fmt.Sprintf(helpMsgFormat, baseCurr, noneLimitValue, noneLimitValue)
 }
 
 func makeDefaultMsg(baseCurr models.CurrencyCode) string {
@@ -150,7 +209,7 @@ func makeDefaultMsg(baseCurr models.CurrencyCode) string {
 }
 
 func (c *Client) initHandlers(ctx context.Context) {
-	checkUser := createIsUserExistsMiddleware(ctx, c.userUC)
+	checkUser := createAccountMiddleware(ctx, c.userUC, c.ledgerUC, c.knownChatMembers)
 
 	c.handle(ctx, "/hello", func(_ context.Context, teleCtx telebotReducedContext) error {
 		return teleCtx.Send(helloMsg)
@@ -158,22 +217,43 @@ func (c *Client) initHandlers(ctx context.Context) {
 	c.handle(ctx, "/help", func(_ context.Context, teleCtx telebotReducedContext) error {
 		return teleCtx.Send(makeHelpMsg(c.baseCurr))
 	})
-	c.handle(ctx, telebot.OnText, func(_ context.Context, teleCtx telebotReducedContext) error {
-		return teleCtx.Send(makeDefaultMsg(c.baseCurr))
-	})
-	c.handle(ctx, "/start", c.handleStartCmd, createRequireArgsCountMiddleware(0, 0))
+	c.handle(ctx, telebot.OnText, c.handleTextMessage, checkUser)
+	c.handle(ctx, "/start", c.handleStartCmd, createRequireArgsCountMiddleware(0, 1))
+	c.handle(ctx, "/ledger", c.handleLedgerCmd, checkUser, createRequireArgsCountMiddleware(0, 1))
 	c.handle(ctx, "/currency", c.handleCurrencyCmd, checkUser, createRequireArgsCountMiddleware(0, 1))
-	c.handle(ctx, "/expense", c.handleExpenseCmd, checkUser, createRequireArgsCountMiddleware(3, 258))
-	c.handle(ctx, "/report", c.handleExpensesReportCmd, checkUser, createRequireArgsCountMiddleware(2, 2))
-	c.handle(ctx, "/list", c.handleExpensesListCmd, checkUser, createRequireArgsCountMiddleware(2, 2))
-	c.handle(ctx, "/limit", c.handleLimitCmd, checkUser, createRequireArgsCountMiddleware(0, 1))
+	c.handle(ctx, "/timezone", c.handleTimeZoneCmd, checkUser, createRequireArgsCountMiddleware(0, 1))
+	c.handle(ctx, "/period", c.handlePeriodCmd, checkUser, createRequireArgsCountMiddleware(0, 1))
+	c.handle(ctx, expenseCmd, c.handleExpenseCmd, checkUser, createRequireArgsCountMiddleware(2, 258))
+	c.handle(ctx, telebot.OnEdited, c.handleEditedMessage, checkUser)
+	c.handle(ctx, "/edit", c.handleEditExpenseCmd, checkUser, createRequireArgsCountMiddleware(3, 259))
+	c.handle(ctx, "/delete", c.handleDeleteExpenseCmd, checkUser, createRequireArgsCountMiddleware(1, 1))
+	c.handle(ctx, "/refund", c.handleRefundCmd, checkUser, createRequireArgsCountMiddleware(1, 2))
+	c.handle(ctx, "/report", c.handleExpensesReportCmd, checkUser, createRequireArgsCountMiddleware(1, 5))
+	c.handle(ctx, "/list", c.handleExpensesListCmd, checkUser, createRequireArgsCountMiddleware(1, 4))
+	c.handle(ctx, "/limit", c.handleLimitCmd, checkUser, createRequireArgsCountMiddleware(0, 20))
+	c.handle(ctx, "/budget", c.handleBudgetCmd, checkUser, createRequireArgsCountMiddleware(0, 4))
+	c.handle(ctx, "/income", c.handleIncomeCmd, checkUser, createRequireArgsCountMiddleware(3, 258))
+	c.handle(ctx, "/balance", c.handleBalanceCmd, checkUser, createRequireArgsCountMiddleware(1, 2))
+	c.handle(ctx, "/split", c.handleSplitCmd, checkUser, createRequireArgsCountMiddleware(3, 259))
+	c.handle(ctx, "/debts", c.handleDebtsCmd, checkUser, createRequireArgsCountMiddleware(0, 0))
+	c.handle(ctx, "/settle", c.handleSettleCmd, checkUser, createRequireArgsCountMiddleware(1, 2))
+	c.handle(ctx, "/recurring", c.handleRecurringCmd, checkUser, createRequireArgsCountMiddleware(1, 259))
+	c.handle(ctx, "/categories", c.handleCategoriesCmd, checkUser, createRequireArgsCountMiddleware(0, 3))
+	c.handle(ctx, "/rules", c.handleRulesCmd, checkUser, createRequireArgsCountMiddleware(1, 258))
+	c.handle(ctx, callbackEndpoint(expenseActionsUnique), c.handleExpenseActionsCallback, checkUser)
+	c.handle(ctx, callbackEndpoint(undoExpenseUnique), c.handleUndoExpenseCallback, checkUser)
+	c.handle(ctx, callbackEndpoint(changeCategoryUnique), c.handleChangeCategoryCallback, checkUser)
+	c.handle(ctx, callbackEndpoint(setCategoryUnique), c.handleSetCategoryCallback, checkUser)
+	c.handle(ctx, callbackEndpoint(changeDateUnique), c.handleChangeDateCallback, checkUser)
+	c.handle(ctx, callbackEndpoint(setDateUnique), c.handleSetDateCallback, checkUser)
+	c.handle(ctx, callbackEndpoint(pickCategoryUnique), c.handlePickCategoryCallback, checkUser)
 	var reportHandler endpointHandler
 	if _, isExtendedExpensesUC := c.expUC.(expense.ExtendedUseCase); isExtendedExpensesUC {
 		reportHandler = c.handleExpensesReportCmdAsync
 	} else {
 		reportHandler = c.handleExpensesReportCmd
 	}
-	c.handle(ctx, "/report", reportHandler, checkUser, createRequireArgsCountMiddleware(2, 2))
+	c.handle(ctx, "/report", reportHandler, checkUser, createRequireArgsCountMiddleware(1, 5))
 }
 
 type endpointHandler func(context.Context, telebotReducedContext) error
@@ -185,7 +265,7 @@ func (c *Client) handle(ctx context.Context, endpoint string, handler endpointHa
 	wrap := func(inner func(context.Context, telebotReducedContext) error) telebot.HandlerFunc {
 		innerWithTracing := tracingMiddleware(inner)
 		return logTriggeredHandler(metricsMiddleware(func(teleCtx telebot.Context) error {
-			return innerWithTracing(ctx, teleCtx)
+			return innerWithTracing(withAccountID(ctx, teleCtx), teleCtx)
 		}))
 	}
 	c.bot.Handle(endpoint, wrap(handler), m...)
@@ -194,84 +274,536 @@ func (c *Client) handle(ctx context.Context, endpoint string, handler endpointHa
 type telebotReducedContext interface {
 	Args() []string
 	Send(what interface{}, opts ...interface{}) error
+	Edit(what interface{}, opts ...interface{}) error
+	Respond(resp ...*telebot.CallbackResponse) error
 	Update() telebot.Update
 	Message() *telebot.Message
+	Callback() *telebot.Callback
 	Sender() *telebot.User
 }
 
-const dateLayout = "2006.01.02"
+const (
+	dateLayout = "2006.01.02"
+	expenseCmd = "/expense"
+)
 
-func (c *Client) handleExpenseCmd(ctx context.Context, teleCtx telebotReducedContext) error {
-	args := teleCtx.Args()
-	if len(args) < 3 {
-		return errors.New("not enough arguments to create expense")
+// parseExpenseArgs parses '<category> <amount><currency, optional> <date> <time, optional> <currency, optional> <comment, optional>'
+// arguments, the date is relative to today. The amount in the currency other than the user selected one
+// is returned in the original amount and currency of the expense.
+// If the arguments start with the amount, the category is left empty to be picked by the category rules
+// and the date is optional, today is used if it's absent.
+// Returned error is suitable to be sent to the user as is.
+func (c *Client) parseExpenseArgs(args []string, today time.Time) (models.Expense, error) {
+	if len(args) < 2 {
+		return models.Expense{}, errors.New("Not enough arguments to parse expense")
+	}
+	var category string
+	if !isAmountArg(args[0]) {
+		if len(args) < 3 {
+			return models.Expense{}, errors.New("Not enough arguments to parse expense")
+		}
+		category, args = args[0], args[1:]
 	}
-	category, strAmount, date, commentWords := args[0], args[1], args[2], args[3:]
+	strAmount, commentWords := args[0], args[1:]
 
-	amount, err := decimal.NewFromString(strAmount)
+	amount, curr, err := c.parseAmountWithCurrency(strAmount)
 	if err != nil {
-		return teleCtx.Send(fmt.Sprintf("Failed to parse amount: %v", err))
+		return models.Expense{}, err
 	}
 
-	day, err := time.Parse(dateLayout, date)
-	if err != nil {
-		return teleCtx.Send(fmt.Sprintf("Failed to parse date: %v", err))
+	day := today
+	if category != "" {
+		day, err = parseDate(commentWords[0], today)
+		if err != nil {
+			return models.Expense{}, errors.Wrap(err, "Failed to parse date")
+		}
+		commentWords = commentWords[1:]
+	} else if len(commentWords) != 0 {
+		if date, err := parseDate(commentWords[0], today); err == nil {
+			day, commentWords = date, commentWords[1:]
+		}
+	}
+	if len(commentWords) != 0 {
+		if moment, ok := parseTimeOfDay(commentWords[0], day); ok {
+			day, commentWords = moment, commentWords[1:]
+		}
+	}
+	// the currency token is recognized only if it's supported, otherwise it's the comment word
+	if len(commentWords) != 0 && curr == "" {
+		if code := models.CurrencyCode(strings.ToUpper(commentWords[0])); c.isSupportedCurrency(code) {
+			curr, commentWords = code, commentWords[1:]
+		}
 	}
 
 	comment := strings.Join(commentWords, " ")
 
-	teleMsg := teleCtx.Message()
 	exp := models.Expense{
-		ID:       models.ExpenseID(teleMsg.ID),
 		Category: models.ExpenseCategory(category),
 		Amount:   amount,
 		Date:     day,
 		Comment:  comment,
 	}
+	if curr != "" {
+		exp.OriginalAmount, exp.OriginalCurrency = amount, curr
+	}
+	return exp, nil
+}
+
+// isAmountArg reports whether the argument is the amount optionally followed by the currency code.
+func isAmountArg(arg string) bool {
+	_, err := decimal.NewFromString(strings.TrimRightFunc(arg, unicode.IsLetter))
+	return err == nil
+}
+
+// parseAmountWithCurrency parses the amount optionally followed by the currency code, e.g. '12.5EUR'.
+// Returned error is suitable to be sent to the user as is.
+func (c *Client) parseAmountWithCurrency(arg string) (decimal.Decimal, models.CurrencyCode, error) {
+	strAmount := strings.TrimRightFunc(arg, unicode.IsLetter)
+	amount, err := decimal.NewFromString(strAmount)
+	if err != nil {
+		return decimal.Decimal{}, "", errors.Wrap(err, "Failed to parse amount")
+	}
+	curr := models.CurrencyCode(strings.ToUpper(arg[len(strAmount):]))
+	if curr != "" && !c.isSupportedCurrency(curr) {
+		return decimal.Decimal{}, "", errors.Errorf("Currency %q is not supported. Supported currencies: %v", curr, c.supportedCurrSlice)
+	}
+	return amount, curr, nil
+}
+
+func (c *Client) isSupportedCurrency(code models.CurrencyCode) bool {
+	_, ok := c.supportedCurr[code]
+	return ok
+}
+
+// cutCurrencyArg cuts the supported currency from the end of the arguments, which are dates otherwise.
+// Empty currency is returned if there is no such argument.
+func (c *Client) cutCurrencyArg(args []string) ([]string, models.CurrencyCode) {
+	if len(args) < 2 {
+		return args, ""
+	}
+	if code := models.CurrencyCode(strings.ToUpper(args[len(args)-1])); c.isSupportedCurrency(code) {
+		return args[:len(args)-1], code
+	}
+	return args, ""
+}
+
+func parseExpenseID(arg string) (models.ExpenseID, error) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(arg, "#"), 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to parse expense ID")
+	}
+	return models.ExpenseID(id), nil
+}
+
+func sendExpenseValidationError(teleCtx telebotReducedContext, err error) error {
+	switch {
+	case errors.Is(err, models.ErrExpenseAmountTooBig):
+		return teleCtx.Send(expenseAmountIsTooBigMsg)
+	case errors.Is(err, models.ErrExpenseAmountIsNotPositive):
+		return teleCtx.Send(expenseAmountIsNotPositiveMsg)
+	case errors.Is(err, models.ErrExpenseCategoryIsInvalid):
+		return teleCtx.Send(expenseCategoryIsInvalidMsg)
+	default:
+		return errors.Wrapf(err, "unknown expense validation error")
+	}
+}
+
+func (c *Client) handleExpenseCmd(ctx context.Context, teleCtx telebotReducedContext) error {
+	args := teleCtx.Args()
+	if len(args) < 2 {
+		return errors.New("not enough arguments to create expense")
+	}
+	teleMsg := teleCtx.Message()
+	userID := accountID(ctx, teleMsg.Sender)
+	today, err := c.getUserToday(ctx, userID, teleMsg.Time())
+	if err != nil {
+		return err
+	}
+	exp, err := c.parseExpenseArgs(args, today)
+	if err != nil {
+		return teleCtx.Send(err.Error())
+	}
+	note, err := c.categorizeByRules(ctx, userID, &exp)
+	if err != nil {
+		return err
+	}
+	if exp.Category == "" {
+		return c.sendCategorySuggestions(ctx, teleCtx, teleMsg, exp)
+	}
+	if note != "" {
+		note = "The " + note
+	}
+	return c.createExpense(ctx, teleCtx, teleMsg, exp, note)
+}
+
+func (c *Client) handleTextMessage(ctx context.Context, teleCtx telebotReducedContext) error {
+	teleMsg := teleCtx.Message()
+	text, ok := c.textAddressedToBot(teleMsg)
+	if !ok {
+		return nil // group chat members talk to each other
+	}
+	userID := accountID(ctx, teleMsg.Sender)
+	today, err := c.getUserToday(ctx, userID, teleMsg.Time())
+	if err != nil {
+		return err
+	}
+	parsed, ok := parseFreeFormExpense(text, today)
+	if !ok {
+		return teleCtx.Send(makeDefaultMsg(c.baseCurr))
+	}
+	ruleApplied, err := c.categorizeFreeFormByRules(ctx, userID, &parsed)
+	if err != nil {
+		return err
+	}
+	if parsed.wordsAfterAmount != "" && !ruleApplied {
+		exp := parsed.expense
+		exp.Category, exp.Comment = "", parsed.wordsAfterAmount
+		categories, err := c.suggestCategories(ctx, teleMsg, exp)
+		if err != nil {
+			return err
+		}
+		if len(categories) != 0 {
+			// the first word is offered too, it's the category when there is nothing to suggest
+			if first := parsed.expense.Category; fitsPickCategoryData(first) && !containsCategory(categories, first) {
+				categories = append(categories, first)
+			}
+			return sendPickCategoryKeyboard(teleCtx, teleMsg, categories)
+		}
+	}
+	var understood string
+	if parsed.isAmbiguous() {
+		understood = parsed.understoodText()
+	}
+	return c.createExpense(ctx, teleCtx, teleMsg, parsed.expense, understood)
+}
+
+// textAddressedToBot returns the text of the plain text message without mentions of the bot. Messages of group chats
+// are addressed to the bot only if they mention it or reply to its message, false is returned for the other ones.
+func (c *Client) textAddressedToBot(teleMsg *telebot.Message) (string, bool) {
+	if teleMsg.Chat == nil || teleMsg.Chat.Type == telebot.ChatPrivate {
+		return teleMsg.Text, true
+	}
+	me := c.bot.Me
+	repliesToBot := teleMsg.ReplyTo != nil && teleMsg.ReplyTo.Sender != nil && teleMsg.ReplyTo.Sender.ID == me.ID
+	words := strings.Fields(teleMsg.Text)
+	text := make([]string, 0, len(words))
+	for _, word := range words {
+		if me.Username != "" && strings.EqualFold(word, "@"+me.Username) {
+			continue
+		}
+		text = append(text, word)
+	}
+	if !repliesToBot && len(text) == len(words) {
+		return "", false
+	}
+	return strings.Join(text, " "), true
+}
+
+// createExpense validates and creates the expense, links it to the message and sends the confirmation with note.
+func (c *Client) createExpense(
+	ctx context.Context,
+	teleCtx telebotReducedContext,
+	teleMsg *telebot.Message,
+	exp models.Expense,
+	note string,
+) error {
 	if err := exp.Validate(); err != nil {
+		return sendExpenseValidationError(teleCtx, err)
+	}
+	created, rejectedMsg, err := c.addMessageExpense(ctx, teleMsg, exp)
+	switch {
+	case errors.Is(err, expense.ErrMessageLinked):
+		return teleCtx.Send(expenseAlreadyCreatedMsg)
+	case err != nil:
+		return err
+	case rejectedMsg != "":
+		return teleCtx.Send(rejectedMsg)
+	}
+	return sendExpenseCreated(teleCtx, created, note)
+}
+
+// addMessageExpense adds the valid expense and links the message to it, the returned text is not empty if the expense
+// is rejected by limits. expense.ErrMessageLinked is returned if the expense of the message is already created.
+func (c *Client) addMessageExpense(ctx context.Context, teleMsg *telebot.Message, exp models.Expense) (models.Expense, string, error) {
+	userID := accountID(ctx, teleMsg.Sender)
+	exp.AuthorID = models.UserID(teleMsg.Sender.ID)
+	// the link allows to update the expense when the user edits the message
+	created, err := c.expUC.AddExpenseFromMessage(ctx, userID, exp, messageRef(teleMsg))
+	if err != nil {
 		switch {
-		case errors.Is(err, models.ErrExpenseAmountTooBig):
-			return teleCtx.Send(expenseAmountIsTooBigMsg)
-		case errors.Is(err, models.ErrExpenseAmountIsNotPositive):
-			return teleCtx.Send(expenseAmountIsNotPositiveMsg)
+		case errors.Is(err, expense.ErrExpensesLimitExcess):
+			return models.Expense{}, c.describeLimitExcess(err), nil
+		case errors.Is(err, expense.ErrMessageLinked):
+			return models.Expense{}, "", err
 		default:
-			return errors.Wrapf(err, "unknown expense validation error")
+			return models.Expense{}, "", errors.Wrapf(err, "failed to create expense for userID=%d", userID)
 		}
 	}
-	userID := models.UserID(teleMsg.Sender.ID)
-	if _, err := c.expUC.AddExpense(ctx, userID, exp); err != nil {
+	return created, "", nil
+}
+
+func sendExpenseCreated(teleCtx telebotReducedContext, created models.Expense, note string) error {
+	msg := "Expense successfully created"
+	if note != "" {
+		msg += "\n" + note
+	}
+	return teleCtx.Send(msg, makeExpenseActionsMarkup(created.ID))
+}
+
+// messageRef identifies the message within its chat, members of the ledger send expenses from different chats.
+func messageRef(teleMsg *telebot.Message) models.MessageRef {
+	ref := models.MessageRef{MessageID: models.MessageID(teleMsg.ID)}
+	if teleMsg.Chat != nil {
+		ref.ChatID = teleMsg.Chat.ID
+	}
+	return ref
+}
+
+// getUserToday returns the midnight of the t day in the user time zone.
+func (c *Client) getUserToday(ctx context.Context, userID models.UserID, t time.Time) (time.Time, error) {
+	loc, err := c.userUC.GetUserTimeZone(ctx, userID)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to get time zone for userID=%d", userID)
+	}
+	return models.StartOfDay(t.In(loc)), nil
+}
+
+// getUserDateContext returns the user today and the day of month when the user budget period starts.
+func (c *Client) getUserDateContext(ctx context.Context, userID models.UserID, t time.Time) (time.Time, int, error) {
+	today, err := c.getUserToday(ctx, userID, t)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	periodStartDay, err := c.userUC.GetUserPeriodStartDay(ctx, userID)
+	if err != nil {
+		return time.Time{}, 0, errors.Wrapf(err, "failed to get period start day for userID=%d", userID)
+	}
+	return today, periodStartDay, nil
+}
+
+// extractCommandArgs splits the command text to arguments in the same way as telebot does it for new messages.
+func extractCommandArgs(text, command string) ([]string, bool) {
+	cmd, payload, _ := strings.Cut(text, " ")
+	cmd, _, _ = strings.Cut(cmd, "@") // command can be addressed to the bot: '/expense@bot_name'
+	if cmd != command {
+		return nil, false
+	}
+	payload = strings.Trim(payload, " ")
+	if payload == "" {
+		return nil, true
+	}
+	return strings.Split(payload, " "), true
+}
+
+// describeExpenseChanges compares amounts in currencies the user typed them in, curr is used for amounts typed without one.
+func describeExpenseChanges(old, updated models.Expense, curr models.CurrencyCode) string {
+	var changes []string
+	if old.Category != updated.Category {
+		changes = append(changes, fmt.Sprintf("category: %s -> %s", old.Category, updated.Category))
+	}
+	oldAmount, oldCurr := typedAmount(old, curr)
+	newAmount, newCurr := typedAmount(updated, curr)
+	if oldCurr != newCurr || !oldAmount.Equal(newAmount) {
+		changes = append(changes, fmt.Sprintf("amount: %v %s -> %v %s", oldAmount, oldCurr, newAmount, newCurr))
+	}
+	if oldDate, newDate := formatDate(old.Date), formatDate(updated.Date); oldDate != newDate {
+		changes = append(changes, fmt.Sprintf("date: %s -> %s", oldDate, newDate))
+	}
+	if old.Comment != updated.Comment {
+		changes = append(changes, fmt.Sprintf("comment: %q -> %q", old.Comment, updated.Comment))
+	}
+	if len(changes) == 0 {
+		return fmt.Sprintf("Expense #%d has not changed", updated.ID)
+	}
+	return fmt.Sprintf("Expense #%d successfully updated:\n%s", updated.ID, strings.Join(changes, "\n"))
+}
+
+func typedAmount(exp models.Expense, curr models.CurrencyCode) (decimal.Decimal, models.CurrencyCode) {
+	if exp.HasOriginal() {
+		return exp.OriginalAmount, exp.OriginalCurrency
+	}
+	return exp.Amount, curr
+}
+
+func (c *Client) handleEditedMessage(ctx context.Context, teleCtx telebotReducedContext) error {
+	teleMsg := teleCtx.Message()
+	args, isExpenseCmd := extractCommandArgs(teleMsg.Text, expenseCmd)
+	userID := accountID(ctx, teleMsg.Sender)
+	msg := messageRef(teleMsg)
+	id, err := c.expUC.GetExpenseIDByMessage(ctx, userID, msg)
+	if err != nil {
+		switch {
+		case errors.Is(err, expense.ErrDoesNotExist) && isExpenseCmd:
+			return teleCtx.Send(editedMessageNotLinkedMsg)
+		case errors.Is(err, expense.ErrDoesNotExist):
+			return nil // the edited plain text message has never been an expense
+		default:
+			return errors.Wrapf(err, "failed to get expenseID by messageID=%d for userID=%d", msg.MessageID, userID)
+		}
+	}
+	// telegram keeps the original message date for edited messages
+	today, err := c.getUserToday(ctx, userID, teleMsg.Time())
+	if err != nil {
+		return err
+	}
+	var exp models.Expense
+	if isExpenseCmd {
+		exp, err = c.parseExpenseArgs(args, today)
+		if err != nil {
+			return teleCtx.Send(err.Error())
+		}
+		if _, err := c.categorizeByRules(ctx, userID, &exp); err != nil {
+			return err
+		}
+		if exp.Category == "" {
+			return teleCtx.Send(categoryRuleNotMatchedMsg)
+		}
+	} else {
+		text, _ := c.textAddressedToBot(teleMsg)
+		parsed, ok := parseFreeFormExpense(text, today)
+		if !ok {
+			return teleCtx.Send(editedMessageNotParsedMsg)
+		}
+		if _, err := c.categorizeFreeFormByRules(ctx, userID, &parsed); err != nil {
+			return err
+		}
+		exp = parsed.expense
+	}
+	exp.ID = id
+	if err := exp.Validate(); err != nil {
+		return sendExpenseValidationError(teleCtx, err)
+	}
+	old, err := c.expUC.GetExpenseByID(ctx, userID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, expense.ErrDoesNotExist):
+			return teleCtx.Send(expenseNotFoundMsg)
+		default:
+			return errors.Wrapf(err, "failed to get expenseID=%d for userID=%d", id, userID)
+		}
+	}
+	// messages of members in private chats with the bot may have the same IDs
+	if !isExpenseAuthor(&old, teleMsg.Sender) {
+		return teleCtx.Send(editedMessageNotLinkedMsg)
+	}
+	if _, err := c.expUC.UpdateExpense(ctx, userID, exp); err != nil {
+		switch {
+		case errors.Is(err, expense.ErrExpensesLimitExcess):
+			return teleCtx.Send(c.describeLimitExcess(err))
+		case errors.Is(err, expense.ErrDoesNotExist):
+			return teleCtx.Send(expenseNotFoundMsg)
+		case errors.Is(err, expense.ErrRefundExceedsExpense):
+			return teleCtx.Send(refundExceedsExpenseMsg)
+		default:
+			return errors.Wrapf(err, "failed to update expenseID=%d for userID=%d", id, userID)
+		}
+	}
+	curr, err := c.userUC.GetUserCurrency(ctx, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get user currency for userID=%d", userID)
+	}
+	return teleCtx.Send(describeExpenseChanges(old, exp, curr))
+}
+
+func (c *Client) handleEditExpenseCmd(ctx context.Context, teleCtx telebotReducedContext) error {
+	args := teleCtx.Args()
+	if len(args) < 3 {
+		return errors.New("not enough arguments to edit expense")
+	}
+	id, err := parseExpenseID(args[0])
+	if err != nil {
+		return teleCtx.Send(err.Error())
+	}
+	teleMsg := teleCtx.Message()
+	userID := accountID(ctx, teleMsg.Sender)
+	today, err := c.getUserToday(ctx, userID, teleMsg.Time())
+	if err != nil {
+		return err
+	}
+	exp, err := c.parseExpenseArgs(args[1:], today)
+	if err != nil {
+		return teleCtx.Send(err.Error())
+	}
+	if _, err := c.categorizeByRules(ctx, userID, &exp); err != nil {
+		return err
+	}
+	if exp.Category == "" {
+		return teleCtx.Send(categoryRuleNotMatchedMsg)
+	}
+	exp.ID = id
+	if err := exp.Validate(); err != nil {
+		return sendExpenseValidationError(teleCtx, err)
+	}
+	if _, err := c.expUC.UpdateExpense(ctx, userID, exp); err != nil {
 		switch {
-		case errors.Is(err, expense.ErrExpensesMonthlyLimitExcess):
-			return teleCtx.Send(expensesAmountExceededMsg)
+		case errors.Is(err, expense.ErrExpensesLimitExcess):
+			return teleCtx.Send(c.describeLimitExcess(err))
+		case errors.Is(err, expense.ErrDoesNotExist):
+			return teleCtx.Send(expenseNotFoundMsg)
+		case errors.Is(err, expense.ErrRefundExceedsExpense):
+			return teleCtx.Send(refundExceedsExpenseMsg)
 		default:
-			return errors.Wrapf(err, "failed to create expense for userID=%d", userID)
+			return errors.Wrapf(err, "failed to update expenseID=%d for userID=%d", id, userID)
 		}
 	}
-	return teleCtx.Send("Expense successfully created")
+	return teleCtx.Send("Expense successfully updated")
+}
+
+func (c *Client) handleDeleteExpenseCmd(ctx context.Context, teleCtx telebotReducedContext) error {
+	args := teleCtx.Args()
+	if len(args) < 1 {
+		return errors.New("not enough arguments to delete expense")
+	}
+	id, err := parseExpenseID(args[0])
+	if err != nil {
+		return teleCtx.Send(err.Error())
+	}
+	userID := accountID(ctx, teleCtx.Message().Sender)
+	if err := c.expUC.DeleteExpense(ctx, userID, id); err != nil {
+		switch {
+		case errors.Is(err, expense.ErrDoesNotExist):
+			return teleCtx.Send(expenseNotFoundMsg)
+		default:
+			return errors.Wrapf(err, "failed to delete expenseID=%d for userID=%d", id, userID)
+		}
+	}
+	return teleCtx.Send("Expense successfully deleted")
 }
 
 func (c *Client) handleExpensesReportCmdAsync(ctx context.Context, teleCtx telebotReducedContext) error {
 	args := teleCtx.Args()
-	if len(args) < 2 {
+	if len(args) < 1 {
 		return errors.New("not enough arguments to create expenses report")
 	}
+	args, tag := cutTagArg(args)
+	args, curr := c.cutCurrencyArg(args)
+	switch {
+	case len(args) > 0 && args[0] == membersReportArg:
+		return c.sendMembersReport(ctx, teleCtx, args[1:], curr, tag)
+	case len(args) > 0 && args[0] == tagsReportArg:
+		return c.sendTagsReport(ctx, teleCtx, args[1:], curr)
+	}
 	extendedExpUC, ok := c.expUC.(expense.ExtendedUseCase)
 	if !ok {
 		return errors.Errorf("(%T) does not implement (%T)", c.expUC, extendedExpUC)
 	}
-	sinceStr, tillStr := args[0], args[1]
-	since, err := time.Parse(dateLayout, sinceStr)
+	msg := teleCtx.Message()
+	userID := accountID(ctx, msg.Sender)
+	today, periodStartDay, err := c.getUserDateContext(ctx, userID, msg.Time())
 	if err != nil {
-		return teleCtx.Send(fmt.Sprintf("Failed to parse since date: %v", err))
+		return err
 	}
-	till, err := time.Parse(dateLayout, tillStr)
+	args, category := cutCategoryArg(args, today, periodStartDay)
+	since, till, err := parseDateRange(args, today, periodStartDay)
 	if err != nil {
-		return teleCtx.Send(fmt.Sprintf("Failed to parse till date: %v", err))
+		return teleCtx.Send(fmt.Sprintf("Failed to parse dates: %v", err))
+	}
+	if category != "" {
+		// the drill down is rare, so it isn't sent to the reports service
+		return c.sendSummaryReport(ctx, teleCtx, userID, since, till, curr, tag, category)
 	}
-	msg := teleCtx.Message()
-	userID := models.UserID(msg.Sender.ID)
 	chatID := msg.Chat.ID
-	if err := extendedExpUC.SendGetExpensesSummaryByCategorySinceRequest(ctx, chatID, userID, since, till); err != nil {
+	if err := extendedExpUC.SendGetExpensesSummaryByCategorySinceRequest(ctx, chatID, userID, since, till, curr, tag); err != nil {
 		return errors.Wrapf(err, "failed to send expenses summary by category since request for chatID=%d and userID=%d", chatID, userID)
 	}
 	return nil
@@ -279,23 +811,49 @@ func (c *Client) handleExpensesReportCmdAsync(ctx context.Context, teleCtx teleb
 
 func (c *Client) handleExpensesReportCmd(ctx context.Context, teleCtx telebotReducedContext) error {
 	args := teleCtx.Args()
-	if len(args) < 2 {
+	if len(args) < 1 {
 		return errors.New("not enough arguments to create expenses report")
 	}
-	sinceStr, tillStr := args[0], args[1]
-	since, err := time.Parse(dateLayout, sinceStr)
+	args, tag := cutTagArg(args)
+	args, curr := c.cutCurrencyArg(args)
+	switch {
+	case len(args) > 0 && args[0] == membersReportArg:
+		return c.sendMembersReport(ctx, teleCtx, args[1:], curr, tag)
+	case len(args) > 0 && args[0] == tagsReportArg:
+		return c.sendTagsReport(ctx, teleCtx, args[1:], curr)
+	}
+	teleMsg := teleCtx.Message()
+	userID := accountID(ctx, teleMsg.Sender)
+	today, periodStartDay, err := c.getUserDateContext(ctx, userID, teleMsg.Time())
 	if err != nil {
-		return teleCtx.Send(fmt.Sprintf("Failed to parse since date: %v", err))
+		return err
 	}
-	till, err := time.Parse(dateLayout, tillStr)
+	args, category := cutCategoryArg(args, today, periodStartDay)
+	since, till, err := parseDateRange(args, today, periodStartDay)
 	if err != nil {
-		return teleCtx.Send(fmt.Sprintf("Failed to parse till date: %v", err))
+		return teleCtx.Send(fmt.Sprintf("Failed to parse dates: %v", err))
 	}
-	userID := models.UserID(teleCtx.Message().Sender.ID)
-	report, err := c.expUC.GetExpensesSummaryByCategorySince(ctx, userID, since, till)
+	return c.sendSummaryReport(ctx, teleCtx, userID, since, till, curr, tag, category)
+}
+
+// sendSummaryReport sends the summary report by categories, only the category and its children
+// are reported if it's not empty.
+func (c *Client) sendSummaryReport(
+	ctx context.Context,
+	teleCtx telebotReducedContext,
+	userID models.UserID,
+	since, till time.Time,
+	curr models.CurrencyCode,
+	tag models.ExpenseTag,
+	category models.ExpenseCategory,
+) error {
+	report, err := c.expUC.GetExpensesSummaryByCategorySince(ctx, userID, since, till, curr, tag)
 	if err != nil {
 		return errors.Wrapf(err, "failed to create expenses report for userID=%d", userID)
 	}
+	if category != "" {
+		report = report.Within(category)
+	}
 	if len(report) == 0 {
 		return teleCtx.Send(noExpensesFoundMsg)
 	}
@@ -311,26 +869,43 @@ const (
 	limitSenders    = 8
 )
 
-func printExpense(exp models.Expense) string {
-	return fmt.Sprintf("%s %v %s %s", exp.Category, exp.Amount, exp.Date.Format(dateLayout), exp.Comment)
+// printExpense prints the amount as the user typed it, if it's known and the amount isn't converted to curr.
+// Refunds of the expense are printed on the next line.
+func printExpense(exp models.Expense, curr models.CurrencyCode) string {
+	amount, refunded := exp.Amount.String(), exp.Refunded.Round(2).String()
+	switch {
+	case curr != "":
+		amount = fmt.Sprintf("%v %s", exp.Amount.Round(2), curr)
+		refunded = fmt.Sprintf("%v %s", exp.Refunded.Round(2), curr)
+	case exp.HasOriginal():
+		amount = fmt.Sprintf("%v %s", exp.OriginalAmount, exp.OriginalCurrency)
+		refunded = fmt.Sprintf("%v %s", exp.OriginalRefunded().Round(2), exp.OriginalCurrency)
+	}
+	out := fmt.Sprintf("#%d %s %s %s %s", exp.ID, exp.Category, amount, formatDate(exp.Date), exp.Comment)
+	if exp.Refunded.IsPositive() {
+		out += "\n    refunded " + refunded
+	}
+	return out
 }
 
 func (c *Client) handleExpensesListCmd(ctx context.Context, teleCtx telebotReducedContext) error {
 	args := teleCtx.Args()
-	if len(args) < 2 {
+	if len(args) < 1 {
 		return errors.New("not enough arguments to create expenses list")
 	}
-	sinceStr, tillStr := args[0], args[1]
-	since, err := time.Parse(dateLayout, sinceStr)
+	args, tag := cutTagArg(args)
+	args, curr := c.cutCurrencyArg(args)
+	teleMsg := teleCtx.Message()
+	userID := accountID(ctx, teleMsg.Sender)
+	today, periodStartDay, err := c.getUserDateContext(ctx, userID, teleMsg.Time())
 	if err != nil {
-		return teleCtx.Send(fmt.Sprintf("Failed to parse since date: %v", err))
+		return err
 	}
-	till, err := time.Parse(dateLayout, tillStr)
+	since, till, err := parseDateRange(args, today, periodStartDay)
 	if err != nil {
-		return teleCtx.Send(fmt.Sprintf("Failed to parse till date: %v", err))
+		return teleCtx.Send(fmt.Sprintf("Failed to parse dates: %v", err))
 	}
-	userID := models.UserID(teleCtx.Message().Sender.ID)
-	expenses, err := c.expUC.GetExpensesAscendSinceTill(ctx, userID, since, till, maxExpensesList)
+	expenses, err := c.expUC.GetExpensesAscendSinceTill(ctx, userID, since, till, curr, tag, maxExpensesList)
 	if err != nil {
 		return errors.Wrapf(err, "failed to create expenses report for userID=%d", userID)
 	}
@@ -352,7 +927,7 @@ func (c *Client) handleExpensesListCmd(ctx context.Context, teleCtx telebotReduc
 			case <-ctx.Done():
 				return ctx.Err()
 			}
-			msg := printExpense(exp)
+			msg := printExpense(exp, curr)
 			if err := teleCtx.Send(msg); err != nil {
 				return errors.Wrapf(err, "failed to send for userID=%d category info '%s'", userID, msg)
 			}
@@ -362,25 +937,9 @@ func (c *Client) handleExpensesListCmd(ctx context.Context, teleCtx telebotReduc
 	return eg.Wait()
 }
 
-func (c *Client) handleStartCmd(ctx context.Context, teleCtx telebotReducedContext) error {
-	userID := models.UserID(teleCtx.Message().Sender.ID)
-	u := models.NewUser(userID, c.baseCurr)
-	exists, err := c.userUC.IsUserExists(ctx, userID)
-	if err != nil {
-		return errors.Wrapf(err, "failed to check whether user with ID=%d exists or not", userID)
-	}
-	if exists {
-		return teleCtx.Send(startAlreadyWeKnowMsg)
-	}
-	if _, err := c.userUC.CreateUser(ctx, u); err != nil {
-		return errors.Wrapf(err, "failed to create user with ID=%d if not exist", userID)
-	}
-	return teleCtx.Send(startNowWeKnowMsg)
-}
-
 func (c *Client) handleCurrencyCmd(ctx context.Context, teleCtx telebotReducedContext) error {
 	args := teleCtx.Args()
-	userID := models.UserID(teleCtx.Message().Sender.ID)
+	userID := accountID(ctx, teleCtx.Message().Sender)
 	if len(args) == 0 {
 		curr, err := c.userUC.GetUserCurrency(ctx, userID)
 		if err != nil {
@@ -399,42 +958,44 @@ func (c *Client) handleCurrencyCmd(ctx context.Context, teleCtx telebotReducedCo
 	return teleCtx.Send(fmt.Sprintf("Currency successfully changed to %q", currency))
 }
 
-func (c *Client) handleLimitCmd(ctx context.Context, teleCtx telebotReducedContext) error {
+func (c *Client) handleTimeZoneCmd(ctx context.Context, teleCtx telebotReducedContext) error {
 	args := teleCtx.Args()
-	userID := models.UserID(teleCtx.Message().Sender.ID)
+	userID := accountID(ctx, teleCtx.Message().Sender)
 	if len(args) == 0 {
-		limit, err := c.userUC.GetUserMonthlyLimit(ctx, userID)
+		loc, err := c.userUC.GetUserTimeZone(ctx, userID)
 		if err != nil {
-			return errors.Wrapf(err, "failed to get monthly limit for userID=%d", userID)
+			return errors.Wrapf(err, "failed to get time zone for userID=%d", userID)
 		}
-		limitArg := noneUserMonthlyLimitValue
-		if limit != nil {
-			limitArg = fmt.Sprintf("%v", *limit)
-		}
-		return teleCtx.Send(fmt.Sprintf("Your monthly limit is %q in %q", limitArg, c.baseCurr))
+		return teleCtx.Send(fmt.Sprintf("Your time zone is %q", loc))
 	}
-	var limit *decimal.Decimal
-	if limitArg := args[0]; limitArg != noneUserMonthlyLimitValue {
-		limitValue, err := decimal.NewFromString(limitArg)
+	loc, err := models.ParseTimeZone(args[0])
+	if err != nil {
+		return teleCtx.Send(unknownTimeZoneMsg)
+	}
+	if err := c.userUC.SetUserTimeZone(ctx, userID, loc); err != nil {
+		return errors.Wrapf(err, "failed to set time zone %q for userID=%d", loc, userID)
+	}
+	return teleCtx.Send(fmt.Sprintf("Time zone successfully changed to %q", loc))
+}
+
+func (c *Client) handlePeriodCmd(ctx context.Context, teleCtx telebotReducedContext) error {
+	args := teleCtx.Args()
+	userID := accountID(ctx, teleCtx.Message().Sender)
+	if len(args) == 0 {
+		day, err := c.userUC.GetUserPeriodStartDay(ctx, userID)
 		if err != nil {
-			return teleCtx.Send(fmt.Sprintf("Failed to parse monthly limit: %v", err))
+			return errors.Wrapf(err, "failed to get period start day for userID=%d", userID)
 		}
-		limit = &limitValue
+		return teleCtx.Send(fmt.Sprintf("Your budget period starts on day %d of month", day))
 	}
-	if err := models.ValidateUserMonthlyLimit(limit); err != nil {
-		switch {
-		case errors.Is(err, models.ErrUserMonthlyLimitTooBig):
-			return teleCtx.Send(monthlyLimitIsTooBigMsg)
-		case errors.Is(err, models.ErrUserMonthlyLimitIsNegative):
-			return teleCtx.Send(monthlyLimitIsNegativeMsg)
-		default:
-			return errors.Wrapf(err, "unknown user monthly limit validation error")
-		}
+	day, err := strconv.Atoi(args[0])
+	if err != nil || models.ValidateUserPeriodStartDay(day) != nil {
+		return teleCtx.Send(periodStartDayInvalidMsg)
 	}
-	if err := c.userUC.SetUserMonthlyLimit(ctx, userID, limit); err != nil {
-		return errors.Wrapf(err, "failed to set monthly limit for userID=%d", userID)
+	if err := c.userUC.SetUserPeriodStartDay(ctx, userID, day); err != nil {
+		return errors.Wrapf(err, "failed to set period start day %d for userID=%d", day, userID)
 	}
-	return teleCtx.Send("Monthly limit successfully set")
+	return teleCtx.Send(fmt.Sprintf("Budget period start day successfully changed to %d", day))
 }
 
 func (c *Client) SendMessage(chatID int64, message string) error {
@@ -20,7 +20,7 @@ import (
 )
 
 func newClient(ctx context.Context, t *testing.T, expUC expense.UseCase, userUC user.UseCase) *Client {
-	cl, err := NewWithOptions("stub", "stub", []models.CurrencyCode{"stub"}, expUC, userUC, Options{offline: true})
+	cl, err := NewWithOptions("stub", "stub", []models.CurrencyCode{"stub"}, expUC, userUC, nil, nil, nil, nil, Options{offline: true})
 	require.NoError(t, err)
 	go cl.Start(ctx)
 	t.Cleanup(cl.Stop)
@@ -42,14 +42,17 @@ func Test_handleExpenseCmd(t *testing.T) {
 		category    = "test"
 		amount      = decimal.NewFromFloat(111.1)
 		day         = time.Date(2022, time.October, 3, 0, 0, 0, 0, time.UTC)
+		expenseID   = models.ExpenseID(33)
 		expectedExp = models.Expense{
-			ID:       models.ExpenseID(messageID),
 			Category: models.ExpenseCategory(category),
 			Amount:   amount,
 			Date:     day,
 			Comment:  comment,
+			AuthorID: models.UserID(userID),
 		}
+		createdExp = expectedExp
 	)
+	createdExp.ID = expenseID
 	args := []string{category, fmt.Sprintf("%v", amount), day.Format(dateLayout)}
 	args = append(args, strings.Split(comment, " ")...)
 
@@ -58,8 +61,10 @@ func Test_handleExpenseCmd(t *testing.T) {
 		ID:     messageID,
 		Sender: &telebot.User{ID: int64(userID)},
 	}).After(argCall)
-	addExpCall := expUCMock.EXPECT().AddExpense(ctx, models.UserID(userID), expectedExp).MaxTimes(1).Return(expectedExp, nil).After(msgCall)
-	teleCtxMock.EXPECT().Send("Expense successfully created").Times(1).After(addExpCall) // send call
+	tzCall := userUCMock.EXPECT().GetUserTimeZone(ctx, models.UserID(userID)).Times(1).Return(time.UTC, nil).After(msgCall)
+	addExpCall := expUCMock.EXPECT().AddExpenseFromMessage(ctx, models.UserID(userID), expectedExp, models.MessageRef{MessageID: models.MessageID(messageID)}).MaxTimes(1).
+		Return(createdExp, nil).After(tzCall)
+	teleCtxMock.EXPECT().Send("Expense successfully created", gomock.Any()).Times(1).After(addExpCall) // send call
 
 	cl := newClient(ctx, t, expUCMock, userUCMock)
 	err := cl.handleExpenseCmd(ctx, teleCtxMock)
@@ -93,8 +98,11 @@ func Test_handleExpensesReportCmd(t *testing.T) {
 		ID:     messageID,
 		Sender: &telebot.User{ID: int64(userID)},
 	}).After(argCall)
-	reportCall := expUCMock.EXPECT().GetExpensesSummaryByCategorySince(ctx, models.UserID(userID), since, till).Times(1).
-		Return(report, nil).After(msgCall)
+	tzCall := userUCMock.EXPECT().GetUserTimeZone(ctx, models.UserID(userID)).Times(1).Return(time.UTC, nil).After(msgCall)
+	periodCall := userUCMock.EXPECT().GetUserPeriodStartDay(ctx, models.UserID(userID)).Times(1).
+		Return(models.DefaultUserPeriodStartDay, nil).After(tzCall)
+	reportCall := expUCMock.EXPECT().GetExpensesSummaryByCategorySince(ctx, models.UserID(userID), since, till, models.CurrencyCode(""), models.ExpenseTag("")).Times(1).
+		Return(report, nil).After(periodCall)
 	teleCtxMock.EXPECT().Send(reportMsg).Times(1).Return(nil).After(reportCall)
 
 	cl := newClient(ctx, t, expUCMock, userUCMock)
@@ -134,11 +142,205 @@ func Test_handleExpensesListCmd(t *testing.T) {
 		ID:     messageID,
 		Sender: &telebot.User{ID: int64(userID)},
 	}).After(argCall)
-	reportCall := expUCMock.EXPECT().GetExpensesAscendSinceTill(ctx, models.UserID(userID), since, till, maxExpensesList).Times(1).
-		Return([]models.Expense{expectedExp, expectedExp}, nil).After(msgCall)
-	teleCtxMock.EXPECT().Send(printExpense(expectedExp)).Times(2).Return(nil).After(reportCall)
+	tzCall := userUCMock.EXPECT().GetUserTimeZone(ctx, models.UserID(userID)).Times(1).Return(time.UTC, nil).After(msgCall)
+	periodCall := userUCMock.EXPECT().GetUserPeriodStartDay(ctx, models.UserID(userID)).Times(1).
+		Return(models.DefaultUserPeriodStartDay, nil).After(tzCall)
+	reportCall := expUCMock.EXPECT().GetExpensesAscendSinceTill(ctx, models.UserID(userID), since, till, models.CurrencyCode(""), models.ExpenseTag(""), maxExpensesList).Times(1).
+		Return([]models.Expense{expectedExp, expectedExp}, nil).After(periodCall)
+	teleCtxMock.EXPECT().Send(printExpense(expectedExp, "")).Times(2).Return(nil).After(reportCall)
 
 	cl := newClient(ctx, t, expUCMock, userUCMock)
 	err := cl.handleExpensesListCmd(ctx, teleCtxMock)
 	require.NoError(t, err)
 }
+
+func Test_handleDeleteExpenseCmd(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	var (
+		expUCMock   = expMock.NewMockUseCase(ctrl)
+		userUCMock  = userMock.NewMockUseCase(ctrl)
+		teleCtxMock = clMock.NewMocktelebotReducedContext(ctrl)
+	)
+	var (
+		userID    = 11
+		messageID = 22
+		expenseID = models.ExpenseID(33)
+	)
+
+	argCall := teleCtxMock.EXPECT().Args().Times(1).Return([]string{fmt.Sprintf("#%d", expenseID)})
+	msgCall := teleCtxMock.EXPECT().Message().Times(1).Return(&telebot.Message{
+		ID:     messageID,
+		Sender: &telebot.User{ID: int64(userID)},
+	}).After(argCall)
+	deleteCall := expUCMock.EXPECT().DeleteExpense(ctx, models.UserID(userID), expenseID).Times(1).
+		Return(expense.ErrDoesNotExist).After(msgCall)
+	teleCtxMock.EXPECT().Send(expenseNotFoundMsg).Times(1).Return(nil).After(deleteCall)
+
+	cl := newClient(ctx, t, expUCMock, userUCMock)
+	err := cl.handleDeleteExpenseCmd(ctx, teleCtxMock)
+	require.NoError(t, err)
+}
+
+func Test_handleRefundCmd(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	var (
+		expUCMock   = expMock.NewMockUseCase(ctrl)
+		userUCMock  = userMock.NewMockUseCase(ctrl)
+		teleCtxMock = clMock.NewMocktelebotReducedContext(ctrl)
+	)
+	var (
+		userID    = 11
+		expenseID = models.ExpenseID(33)
+		refunded  = models.Expense{
+			ID:               expenseID,
+			Category:         "shoes",
+			Amount:           decimal.NewFromInt(50),
+			OriginalAmount:   decimal.NewFromInt(50),
+			OriginalCurrency: "EUR",
+			Refunded:         decimal.NewFromInt(20),
+			Date:             time.Date(2023, 2, 4, 0, 0, 0, 0, time.UTC),
+		}
+	)
+
+	argCall := teleCtxMock.EXPECT().Args().Times(1).Return([]string{fmt.Sprintf("%d", expenseID), "20"})
+	msgCall := teleCtxMock.EXPECT().Message().Times(1).Return(&telebot.Message{
+		Sender: &telebot.User{ID: int64(userID)},
+	}).After(argCall)
+	refundCall := expUCMock.EXPECT().RefundExpense(ctx, models.UserID(userID), expenseID, decimal.NewFromInt(20)).Times(1).
+		Return(refunded, nil).After(msgCall)
+	teleCtxMock.EXPECT().Send("Refund successfully recorded\n#33 shoes 50 EUR 2023.02.04 \n    refunded 20 EUR").
+		Times(1).Return(nil).After(refundCall)
+
+	cl := newClient(ctx, t, expUCMock, userUCMock)
+	err := cl.handleRefundCmd(ctx, teleCtxMock)
+	require.NoError(t, err)
+}
+
+func Test_handleEditedMessage(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	var (
+		expUCMock   = expMock.NewMockUseCase(ctrl)
+		userUCMock  = userMock.NewMockUseCase(ctrl)
+		teleCtxMock = clMock.NewMocktelebotReducedContext(ctrl)
+	)
+	var (
+		userID    = 11
+		messageID = 22
+		expenseID = models.ExpenseID(33)
+		day       = time.Date(2022, time.October, 10, 0, 0, 0, 0, time.UTC)
+		oldExp    = models.Expense{
+			ID:       expenseID,
+			Category: "food",
+			Amount:   decimal.NewFromInt(2500),
+			Date:     day,
+			Comment:  "lunch",
+		}
+		updatedExp = oldExp
+	)
+	updatedExp.Amount = decimal.NewFromInt(250)
+
+	msgCall := teleCtxMock.EXPECT().Message().Times(1).Return(&telebot.Message{
+		ID:     messageID,
+		Text:   "/expense food 250 2022.10.10 lunch",
+		Sender: &telebot.User{ID: int64(userID)},
+	})
+	idCall := expUCMock.EXPECT().GetExpenseIDByMessage(ctx, models.UserID(userID), models.MessageRef{MessageID: models.MessageID(messageID)}).Times(1).
+		Return(expenseID, nil).After(msgCall)
+	tzCall := userUCMock.EXPECT().GetUserTimeZone(ctx, models.UserID(userID)).Times(1).Return(time.UTC, nil).After(idCall)
+	getCall := expUCMock.EXPECT().GetExpenseByID(ctx, models.UserID(userID), expenseID).Times(1).
+		Return(oldExp, nil).After(tzCall)
+	updateCall := expUCMock.EXPECT().UpdateExpense(ctx, models.UserID(userID), updatedExp).Times(1).
+		Return(updatedExp, nil).After(getCall)
+	currCall := userUCMock.EXPECT().GetUserCurrency(ctx, models.UserID(userID)).Times(1).Return(models.CurrencyCode("RUB"), nil).After(updateCall)
+	teleCtxMock.EXPECT().Send("Expense #33 successfully updated:\namount: 2500 RUB -> 250 RUB").Times(1).Return(nil).After(currCall)
+
+	cl := newClient(ctx, t, expUCMock, userUCMock)
+	err := cl.handleEditedMessage(ctx, teleCtxMock)
+	require.NoError(t, err)
+}
+
+func Test_describeExpenseChanges(t *testing.T) {
+	old := models.Expense{
+		ID:               33,
+		Category:         "food",
+		Amount:           decimal.NewFromInt(750),
+		OriginalAmount:   decimal.NewFromInt(10),
+		OriginalCurrency: "USD",
+	}
+	updated := old
+	updated.Amount, updated.OriginalAmount, updated.OriginalCurrency = decimal.NewFromInt(10), decimal.Zero, ""
+	require.Equal(t, "Expense #33 successfully updated:\namount: 10 USD -> 10 RUB", describeExpenseChanges(old, updated, "RUB"))
+
+	// the amount in the user currency is converted from the amount typed in another one
+	updated.Amount, updated.OriginalAmount, updated.OriginalCurrency = decimal.NewFromInt(750), decimal.NewFromInt(10), "USD"
+	require.Equal(t, "Expense #33 has not changed", describeExpenseChanges(old, updated, "RUB"))
+}
+
+func Test_parseExpenseArgs(t *testing.T) {
+	cl := &Client{supportedCurr: map[models.CurrencyCode]struct{}{"RUB": {}, "EUR": {}}}
+	today := time.Date(2022, time.October, 12, 0, 0, 0, 0, time.UTC)
+	day := time.Date(2022, time.October, 10, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name             string
+		args             []string
+		expectedCurrency models.CurrencyCode
+		expectedComment  string
+		expectedErr      bool
+	}{
+		{name: "lower case currency token", args: []string{"food", "12.5", "2022.10.10", "eur", "lunch"}, expectedCurrency: "EUR", expectedComment: "lunch"},
+		{name: "currency token", args: []string{"food", "12.5", "2022.10.10", "EUR", "lunch"}, expectedCurrency: "EUR", expectedComment: "lunch"},
+		{name: "currency suffix", args: []string{"food", "12.5EUR", "2022.10.10", "lunch"}, expectedCurrency: "EUR", expectedComment: "lunch"},
+		{name: "unsupported currency token is comment", args: []string{"food", "12.5", "2022.10.10", "USD", "lunch"}, expectedComment: "USD lunch"},
+		{name: "unsupported currency suffix", args: []string{"food", "12.5USD", "2022.10.10"}, expectedErr: true},
+		{name: "no category", args: []string{"12.5", "2022.10.10", "starbucks"}, expectedComment: "starbucks"},
+		{name: "no category unsupported currency suffix", args: []string{"12.5USD", "2022.10.10", "starbucks"}, expectedErr: true},
+		{name: "no date", args: []string{"food", "12.5", "lunch"}, expectedErr: true},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			exp, err := cl.parseExpenseArgs(testCase.args, today)
+			if testCase.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, decimal.RequireFromString("12.5").Equal(exp.Amount))
+			require.Equal(t, day, exp.Date)
+			require.Equal(t, testCase.expectedCurrency, exp.OriginalCurrency)
+			require.Equal(t, testCase.expectedComment, exp.Comment)
+		})
+	}
+}
+
+func TestClient_textAddressedToBot(t *testing.T) {
+	cl := &Client{bot: &telebot.Bot{Me: &telebot.User{ID: 42, Username: "expense_bot"}}}
+	group := &telebot.Chat{Type: telebot.ChatGroup}
+	tests := []struct {
+		name     string
+		msg      *telebot.Message
+		expected string
+		ok       bool
+	}{
+		{name: "private chat", msg: &telebot.Message{Chat: &telebot.Chat{Type: telebot.ChatPrivate}, Text: "taxi 430"}, expected: "taxi 430", ok: true},
+		{name: "group chat", msg: &telebot.Message{Chat: group, Text: "meet 5 pm"}},
+		{name: "mention", msg: &telebot.Message{Chat: group, Text: "@Expense_bot taxi 430"}, expected: "taxi 430", ok: true},
+		{
+			name:     "reply to bot",
+			msg:      &telebot.Message{Chat: group, Text: "taxi 430", ReplyTo: &telebot.Message{Sender: &telebot.User{ID: 42}}},
+			expected: "taxi 430",
+			ok:       true,
+		},
+		{name: "reply to member", msg: &telebot.Message{Chat: group, Text: "taxi 430", ReplyTo: &telebot.Message{Sender: &telebot.User{ID: 7}}}},
+	}
+	for _, test := range tests {
+		testCase := test
+		t.Run(testCase.name, func(t *testing.T) {
+			text, ok := cl.textAddressedToBot(testCase.msg)
+			require.Equal(t, testCase.ok, ok)
+			require.Equal(t, testCase.expected, text)
+		})
+	}
+}
@@ -25,6 +25,10 @@ type Values struct {
 	RedisConfig                 *RedisConfig          `yaml:"redis-config"`
 	GRPCEndpoint                string                `yaml:"grpc-endpoint"`
 	KafkaConfig                 *KafkaConfig          `yaml:"kafka-config"`
+	UndoTimeWindow              time.Duration         `yaml:"undo-time-window"`
+	RecurringExpensesInterval   time.Duration         `yaml:"recurring-expenses-interval"`
+	LimitThresholds             []int                 `yaml:"limit-thresholds,flow"`
+	CategoryRulesPath           string                `yaml:"category-rules-path"`
 }
 
 type RedisConfig struct {
@@ -39,6 +43,37 @@ type KafkaConfig struct {
 	ConsumerGroup string   `yaml:"consumer-group"`
 }
 
+// CategoryRule is the default category rule, either the keyword or the regex is set.
+type CategoryRule struct {
+	Keyword  string                 `yaml:"keyword"`
+	Regex    string                 `yaml:"regex"`
+	Category models.ExpenseCategory `yaml:"category"`
+}
+
+type categoryRules struct {
+	Rules []CategoryRule `yaml:"rules"`
+}
+
+// NewCategoryRulesFromReader parses the default category rules applied to expenses of all users.
+func NewCategoryRulesFromReader(r io.Reader) ([]models.CategoryRule, error) {
+	var parsed categoryRules
+	if err := yaml.NewDecoder(r).Decode(&parsed); err != nil {
+		return nil, errors.Wrap(err, "parsing yaml")
+	}
+	out := make([]models.CategoryRule, 0, len(parsed.Rules))
+	for i, rule := range parsed.Rules {
+		if (rule.Keyword == "") == (rule.Regex == "") {
+			return nil, errors.Errorf("rule #%d: exactly one of 'keyword' and 'regex' parameters is required", i+1)
+		}
+		out = append(out, models.CategoryRule{
+			Pattern:  rule.Keyword + rule.Regex,
+			IsRegex:  rule.Regex != "",
+			Category: rule.Category,
+		})
+	}
+	return out, nil
+}
+
 type config struct {
 	Token  string `yaml:"token"`
 	Values `yaml:",inline"`
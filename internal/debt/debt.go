@@ -0,0 +1,35 @@
+package debt
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+var (
+	ErrNotEnoughParticipants = errors.New("bill is split between less than two participants")
+)
+
+type Repository interface {
+	Isolated(ctx context.Context, callback func(ctx context.Context) error) error
+	AddDebts(ctx context.Context, userID models.UserID, debts []models.Debt) ([]models.Debt, error)
+	// GetDebts returns all the debts between members of the user account ordered by date.
+	GetDebts(ctx context.Context, userID models.UserID) ([]models.Debt, error)
+}
+
+type UseCase interface {
+	// SplitBill adds equal shares of the expense as expenses of participants with them as authors
+	// and debts of participants to the payer. The expense amount is in the user selected currency.
+	SplitBill(
+		ctx context.Context,
+		userID, payerID models.UserID,
+		exp models.Expense,
+		participants []models.UserID,
+	) ([]models.Expense, error)
+	// SettleDebt records the repayment of the amount in the user selected currency from the debtor to the creditor.
+	SettleDebt(ctx context.Context, userID, debtorID, creditorID models.UserID, amount decimal.Decimal) error
+	// GetBalances returns net balances of members and the transfers settling them in the user selected currency.
+	GetBalances(ctx context.Context, userID models.UserID) (map[models.UserID]decimal.Decimal, []models.Transfer, error)
+}
@@ -0,0 +1,55 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+type Repository struct {
+	mu         *sync.RWMutex
+	isolatedMu *sync.Mutex
+	lastID     models.DebtID
+	debts      map[models.UserID][]models.Debt
+}
+
+func New() (*Repository, error) {
+	return &Repository{
+		mu:         &sync.RWMutex{},
+		isolatedMu: &sync.Mutex{},
+		debts:      make(map[models.UserID][]models.Debt),
+	}, nil
+}
+
+func (r *Repository) Isolated(ctx context.Context, callback func(ctx context.Context) error) error {
+	r.isolatedMu.Lock()
+	defer r.isolatedMu.Unlock()
+	return callback(ctx)
+}
+
+func (r *Repository) AddDebts(ctx context.Context, userID models.UserID, debts []models.Debt) ([]models.Debt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]models.Debt, 0, len(debts))
+	for _, d := range debts {
+		r.lastID++
+		d.ID = r.lastID
+		out = append(out, d)
+	}
+	all := append(r.debts[userID], out...)
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Date.Before(all[j].Date)
+	})
+	r.debts[userID] = all
+	return out, nil
+}
+
+func (r *Repository) GetDebts(ctx context.Context, userID models.UserID) ([]models.Debt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]models.Debt(nil), r.debts[userID]...), nil
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/common/database/postgres"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+type Repository struct {
+	db postgres.DBDoer
+}
+
+func New(db postgres.DBDoer) (*Repository, error) {
+	return &Repository{db: db}, nil
+}
+
+func (r *Repository) Isolated(ctx context.Context, callback func(ctx context.Context) error) error {
+	return r.db.DoIsolated(ctx, nil, callback)
+}
+
+func (r *Repository) AddDebts(ctx context.Context, userID models.UserID, debts []models.Debt) ([]models.Debt, error) {
+	out := make([]models.Debt, 0, len(debts))
+	err := r.Isolated(ctx, func(ctx context.Context) error {
+		for _, d := range debts {
+			err := r.db.Do(ctx).QueryRowContext(ctx,
+				`INSERT INTO debts (user_id, debtor_id, creditor_id, amount, kind, date, comment)
+				VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+				userID, d.DebtorID, d.CreditorID, d.Amount, d.Kind, d.Date.UTC(), d.Comment,
+			).Scan(&d.ID)
+			if err != nil {
+				return err
+			}
+			out = append(out, d)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to add debts for userID=%d to db", userID)
+	}
+	return out, nil
+}
+
+func (r *Repository) GetDebts(ctx context.Context, userID models.UserID) (_ []models.Debt, err error) {
+	rows, err := r.db.Do(ctx).QueryContext(ctx,
+		"SELECT id, debtor_id, creditor_id, amount, kind, date, comment FROM debts WHERE user_id = $1 ORDER BY date, id",
+		userID,
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get debts for userID=%d from db", userID)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = errors.Wrap(closeErr, "failed to close rows")
+		}
+	}()
+	var out []models.Debt
+	for rows.Next() {
+		var d models.Debt
+		if err := rows.Scan(&d.ID, &d.DebtorID, &d.CreditorID, &d.Amount, &d.Kind, &d.Date, &d.Comment); err != nil {
+			return nil, errors.Wrapf(err, "failed to scan debt for userID=%d", userID)
+		}
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to get debts for userID=%d from db", userID)
+	}
+	return out, nil
+}
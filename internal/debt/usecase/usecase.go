@@ -0,0 +1,196 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/debt"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/exrate"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/user"
+)
+
+const (
+	userIDSpanTagKey            = "user_id"
+	payerIDSpanTagKey           = "payer_id"
+	participantsCountSpanTagKey = "participants_count"
+	debtorIDSpanTagKey          = "debtor_id"
+	creditorIDSpanTagKey        = "creditor_id"
+)
+
+type UseCase struct {
+	baseCurrency models.CurrencyCode
+	repo         debt.Repository
+	expUC        expense.UseCase
+	userRepo     user.Repository
+	exrateRepo   exrate.Repository
+}
+
+func New(
+	baseCurrency models.CurrencyCode,
+	repo debt.Repository,
+	expUC expense.UseCase, userRepo user.Repository, exrateRepo exrate.Repository,
+) (*UseCase, error) {
+	return &UseCase{
+		baseCurrency: baseCurrency,
+		repo:         repo,
+		expUC:        expUC,
+		userRepo:     userRepo,
+		exrateRepo:   exrateRepo,
+	}, nil
+}
+
+// SplitBill adds the payer share first, so it gets the rounding remainder. Duplicate participants are ignored.
+func (u *UseCase) SplitBill(
+	ctx context.Context,
+	userID, payerID models.UserID,
+	exp models.Expense,
+	participants []models.UserID,
+) (_ []models.Expense, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SplitBill")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+	span.SetTag(payerIDSpanTagKey, payerID)
+
+	sharers := []models.UserID{payerID}
+	seen := map[models.UserID]struct{}{payerID: {}}
+	for _, participantID := range participants {
+		if _, ok := seen[participantID]; !ok {
+			seen[participantID] = struct{}{}
+			sharers = append(sharers, participantID)
+		}
+	}
+	span.SetTag(participantsCountSpanTagKey, len(sharers))
+	if len(sharers) < 2 {
+		return nil, debt.ErrNotEnoughParticipants
+	}
+	if err := exp.Validate(); err != nil {
+		return nil, errors.Wrap(err, "expense validation failed")
+	}
+	comment := strings.TrimSpace(string(exp.Category) + " " + exp.Comment)
+	shares := models.SplitAmount(exp.Amount, len(sharers))
+	var (
+		out           []models.Expense
+		notifications []string
+	)
+	err = u.repo.Isolated(ctx, func(ctx context.Context) error {
+		debts := make([]models.Debt, 0, len(sharers)-1)
+		for i, participantID := range sharers {
+			share := exp
+			share.Amount, share.AuthorID = shares[i], participantID
+			// notifications are sent after the commit, because a later share can roll back the earlier ones
+			created, notification, err := u.expUC.AddExpenseDeferringNotification(ctx, userID, share)
+			if err != nil {
+				return errors.Wrapf(err, "failed to add share of userID=%d", participantID)
+			}
+			out = append(out, created)
+			notifications = append(notifications, notification)
+			if participantID == payerID {
+				continue
+			}
+			// the created expense amount is in the base currency
+			debts = append(debts, models.Debt{
+				DebtorID:   participantID,
+				CreditorID: payerID,
+				Amount:     created.Amount,
+				Kind:       models.DebtKindSplit,
+				Date:       exp.Date,
+				Comment:    comment,
+			})
+		}
+		_, err := u.repo.AddDebts(ctx, userID, debts)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to split bill of userID=%d", userID)
+	}
+	for _, notification := range notifications {
+		u.expUC.SendLimitNotification(ctx, userID, notification)
+	}
+	return out, nil
+}
+
+func (u *UseCase) SettleDebt(ctx context.Context, userID, debtorID, creditorID models.UserID, amount decimal.Decimal) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SettleDebt")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+	span.SetTag(debtorIDSpanTagKey, debtorID)
+	span.SetTag(creditorIDSpanTagKey, creditorID)
+
+	now := time.Now()
+	rate, err := u.getRate(ctx, userID, now)
+	if err != nil {
+		return err
+	}
+	// the repayment is the debt of the creditor to the one who paid
+	repayment := models.Debt{
+		DebtorID:   creditorID,
+		CreditorID: debtorID,
+		Amount:     rate.ConvertToBase(amount),
+		Kind:       models.DebtKindSettlement,
+		Date:       now,
+	}
+	if err := repayment.Validate(); err != nil {
+		return errors.Wrap(err, "repayment validation failed")
+	}
+	if _, err := u.repo.AddDebts(ctx, userID, []models.Debt{repayment}); err != nil {
+		return errors.Wrapf(err, "failed to settle debt of userID=%d to userID=%d", debtorID, creditorID)
+	}
+	return nil
+}
+
+func (u *UseCase) GetBalances(ctx context.Context, userID models.UserID) (_ map[models.UserID]decimal.Decimal, _ []models.Transfer, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "GetBalances")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+
+	debts, err := u.repo.GetDebts(ctx, userID)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to get debts of userID=%d", userID)
+	}
+	rate, err := u.getRate(ctx, userID, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	// balances are settled in the base currency to be independent of the rate changes
+	balances := models.NetBalances(debts)
+	transfers := models.SettlementTransfers(balances)
+	for memberID, balance := range balances {
+		balances[memberID] = rate.ConvertFromBase(balance).Round(2)
+	}
+	for i := range transfers {
+		transfers[i].Amount = rate.ConvertFromBase(transfers[i].Amount).Round(2)
+	}
+	return balances, transfers, nil
+}
+
+// getRate returns the rate of the user selected currency at the moment, the identity rate is used for the base currency.
+func (u *UseCase) getRate(ctx context.Context, userID models.UserID, t time.Time) (models.ExchangeRate, error) {
+	curr, err := u.userRepo.GetUserCurrency(ctx, userID)
+	if err != nil {
+		return models.ExchangeRate{}, errors.Wrapf(err, "failed to get selected user currency by userID=%d", userID)
+	}
+	if curr == u.baseCurrency {
+		return models.NewExchangeRate(curr, decimal.NewFromInt(1), t), nil
+	}
+	rate, err := u.exrateRepo.GetRate(ctx, curr, t)
+	if err != nil {
+		return models.ExchangeRate{}, errors.Wrapf(err, "failed to get exchange rate for currency=%q at time=%v", curr, t)
+	}
+	return rate, nil
+}
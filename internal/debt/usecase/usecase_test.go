@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/debt"
+	debtInMemRepo "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/debt/repository/inmemory"
+	expenseInMemRepo "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense/repository/inmemory"
+	expenseUseCase "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense/usecase"
+	exrateInMemRepo "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/exrate/repository/inmemory"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+	userInMemRepo "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/user/repository/inmemory"
+)
+
+const (
+	ledgerID = models.UserID(-100)
+	alice    = models.UserID(1)
+	bob      = models.UserID(2)
+	carol    = models.UserID(3)
+	baseCurr = models.CurrencyCode("RUB")
+)
+
+func newUC(t *testing.T) (*UseCase, *expenseUseCase.UseCase) {
+	ctx := context.Background()
+
+	userRepo, err := userInMemRepo.New()
+	require.NoError(t, err)
+	_, err = userRepo.CreateUser(ctx, models.NewUser(ledgerID, baseCurr))
+	require.NoError(t, err)
+	expRepo, err := expenseInMemRepo.New()
+	require.NoError(t, err)
+	ratesRepo, err := exrateInMemRepo.New()
+	require.NoError(t, err)
+	expUC, err := expenseUseCase.New(baseCurr, expRepo, userRepo, ratesRepo)
+	require.NoError(t, err)
+
+	repo, err := debtInMemRepo.New()
+	require.NoError(t, err)
+	uc, err := New(baseCurr, repo, expUC, userRepo, ratesRepo)
+	require.NoError(t, err)
+	return uc, expUC
+}
+
+func TestUseCase_SplitBill(t *testing.T) {
+	ctx := context.Background()
+	uc, expUC := newUC(t)
+	now := time.Now()
+	dinner := models.Expense{Category: "dinner", Amount: decimal.NewFromInt(3000), Date: now}
+
+	_, err := uc.SplitBill(ctx, ledgerID, alice, dinner, []models.UserID{alice})
+	require.ErrorIs(t, err, debt.ErrNotEnoughParticipants)
+
+	shares, err := uc.SplitBill(ctx, ledgerID, alice, dinner, []models.UserID{bob, carol, bob})
+	require.NoError(t, err)
+	require.Len(t, shares, 3)
+	report, err := expUC.GetExpensesSummaryByAuthorSince(ctx, ledgerID, now, now, "", "")
+	require.NoError(t, err)
+	for _, memberID := range []models.UserID{alice, bob, carol} {
+		assert.Truef(t, decimal.NewFromInt(1000).Equal(report[memberID]["dinner"]), "userID=%d share", memberID)
+	}
+
+	balances, transfers, err := uc.GetBalances(ctx, ledgerID)
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(2000).Equal(balances[alice]))
+	assert.True(t, decimal.NewFromInt(-1000).Equal(balances[bob]))
+	assert.Len(t, transfers, 2)
+
+	require.NoError(t, uc.SettleDebt(ctx, ledgerID, bob, alice, decimal.NewFromInt(1000)))
+	require.ErrorIs(t, uc.SettleDebt(ctx, ledgerID, bob, bob, decimal.NewFromInt(1)), models.ErrDebtToItself)
+	balances, transfers, err = uc.GetBalances(ctx, ledgerID)
+	require.NoError(t, err)
+	assert.Len(t, balances, 2)
+	require.Len(t, transfers, 1)
+	assert.Equal(t, carol, transfers[0].From)
+	assert.Equal(t, alice, transfers[0].To)
+	assert.True(t, decimal.NewFromInt(1000).Equal(transfers[0].Amount))
+}
+
+type messagesRecorder struct {
+	messages []string
+}
+
+func (r *messagesRecorder) SendMessage(_ int64, message string) error {
+	r.messages = append(r.messages, message)
+	return nil
+}
+
+func TestUseCase_SplitBillLimitNotifications(t *testing.T) {
+	ctx := context.Background()
+	limit := models.Limit{Period: models.LimitPeriodMonth, Amount: decimal.NewFromInt(2500), Currency: baseCurr}
+	dinner := models.Expense{Category: "dinner", Amount: decimal.NewFromInt(3000), Date: time.Now()}
+
+	// the second share reaches 80% of the limit, but the third one exceeds it and the bill is rolled back
+	uc, expUC := newUC(t)
+	require.NoError(t, uc.userRepo.SetUserLimits(ctx, ledgerID, []models.Limit{limit}))
+	recorder := &messagesRecorder{}
+	require.NoError(t, expUC.EnableLimitNotifications(recorder, nil))
+	_, err := uc.SplitBill(ctx, ledgerID, alice, dinner, []models.UserID{bob, carol})
+	require.Error(t, err)
+	assert.Empty(t, recorder.messages)
+
+	uc, expUC = newUC(t)
+	require.NoError(t, uc.userRepo.SetUserLimits(ctx, ledgerID, []models.Limit{limit}))
+	recorder = &messagesRecorder{}
+	require.NoError(t, expUC.EnableLimitNotifications(recorder, nil))
+	dinner.Amount = decimal.NewFromInt(1500)
+	_, err = uc.SplitBill(ctx, ledgerID, alice, dinner, []models.UserID{bob, carol})
+	require.NoError(t, err)
+	assert.Len(t, recorder.messages, 1)
+}
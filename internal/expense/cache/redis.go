@@ -3,7 +3,6 @@ package cache
 import (
 	"context"
 	"strconv"
-	"time"
 
 	"github.com/fxamacker/cbor/v2"
 	"github.com/go-redis/redis/v8"
@@ -26,11 +25,12 @@ func makeUserReportsHashSetName(userID models.UserID) string {
 	return "user_reports_" + strconv.FormatInt(int64(userID), 10)
 }
 
-func makeUserReportHashSetKey(since, till time.Time) string {
-	return strconv.FormatInt(since.UTC().Unix(), 10) + ":" + strconv.FormatInt(till.UTC().Unix(), 10)
+func makeUserReportHashSetKey(key expense.ReportCacheKey) string {
+	return strconv.FormatInt(key.Since.UTC().Unix(), 10) + ":" + strconv.FormatInt(key.Till.UTC().Unix(), 10) +
+		":" + string(key.Currency) + ":" + string(key.UserCurrency) + ":" + string(key.Tag)
 }
 
-func (c *ReportsRedisCache) AddToCache(ctx context.Context, userID models.UserID, since, till time.Time, report expense.SummaryReport) (err error) {
+func (c *ReportsRedisCache) AddToCache(ctx context.Context, userID models.UserID, key expense.ReportCacheKey, report expense.SummaryReport) (err error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "AddToCache")
 	defer func() {
 		ext.Error.Set(span, err != nil)
@@ -39,17 +39,17 @@ func (c *ReportsRedisCache) AddToCache(ctx context.Context, userID models.UserID
 
 	data, err := cbor.Marshal(report)
 	if err != nil {
-		return errors.Wrapf(err, "failed to marshal report of userID=(%d) since=%q till=%q", userID, since, till)
+		return errors.Wrapf(err, "failed to marshal report of userID=(%d) since=%q till=%q", userID, key.Since, key.Till)
 	}
 	hashSetName := makeUserReportsHashSetName(userID)
-	hashSetReportKey := makeUserReportHashSetKey(since, till)
+	hashSetReportKey := makeUserReportHashSetKey(key)
 	if err := c.redisDB.HSet(ctx, hashSetName, hashSetReportKey, data).Err(); err != nil {
 		return errors.Wrapf(err, "failed to set data to redis hashSet=%q by key=%q", hashSetName, hashSetReportKey)
 	}
 	return nil
 }
 
-func (c *ReportsRedisCache) GetFromCache(ctx context.Context, userID models.UserID, since, till time.Time) (_ expense.SummaryReport, _ bool, err error) {
+func (c *ReportsRedisCache) GetFromCache(ctx context.Context, userID models.UserID, key expense.ReportCacheKey) (_ expense.SummaryReport, _ bool, err error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "GetFromCache")
 	defer func() {
 		ext.Error.Set(span, err != nil)
@@ -57,7 +57,7 @@ func (c *ReportsRedisCache) GetFromCache(ctx context.Context, userID models.User
 	}()
 
 	hashSetName := makeUserReportsHashSetName(userID)
-	hashSetReportKey := makeUserReportHashSetKey(since, till)
+	hashSetReportKey := makeUserReportHashSetKey(key)
 	data, err := c.redisDB.HGet(ctx, hashSetName, hashSetReportKey).Bytes()
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
@@ -67,7 +67,7 @@ func (c *ReportsRedisCache) GetFromCache(ctx context.Context, userID models.User
 	}
 	report := make(expense.SummaryReport)
 	if err := cbor.Unmarshal(data, &report); err != nil {
-		return nil, false, errors.Wrapf(err, "failed to unmarshal report of userID=(%d) since=%q till=%q", userID, since, till)
+		return nil, false, errors.Wrapf(err, "failed to unmarshal report of userID=(%d) since=%q till=%q", userID, key.Since, key.Till)
 	}
 	return report, true, nil
 }
@@ -35,17 +35,18 @@ func TestReportsRedisCache_AddToCache(t *testing.T) {
 		userID = models.UserID(111)
 		since  = time.Now().Truncate(24 * time.Hour).UTC()
 		till   = since.AddDate(0, 0, 1)
+		key    = expense.ReportCacheKey{Since: since, Till: till, Currency: "USD", UserCurrency: "RUB"}
 		report = expense.SummaryReport{"test": decimal.NewFromInt32(222)}
 	)
 	cache, mock := newReportsRedisTestCache(t)
 	var (
 		hashSetName      = makeUserReportsHashSetName(userID)
-		hashSetReportKey = makeUserReportHashSetKey(since, till)
+		hashSetReportKey = makeUserReportHashSetKey(key)
 		data             = marshalCBOR(t, report)
 	)
 	mock.ExpectHSet(hashSetName, hashSetReportKey, data).SetVal(1)
 
-	err := cache.AddToCache(context.Background(), userID, since, till, report)
+	err := cache.AddToCache(context.Background(), userID, key, report)
 	assert.NoError(t, err)
 }
 
@@ -54,16 +55,17 @@ func TestReportsRedisCache_GetFromCache_Hit(t *testing.T) {
 		userID = models.UserID(111)
 		since  = time.Now().Truncate(24 * time.Hour).UTC()
 		till   = since.AddDate(0, 0, 1)
+		key    = expense.ReportCacheKey{Since: since, Till: till, Currency: "USD", UserCurrency: "RUB"}
 		report = expense.SummaryReport{"test": decimal.NewFromInt32(222)}
 	)
 	cache, mock := newReportsRedisTestCache(t)
 	var (
 		hashSetName      = makeUserReportsHashSetName(userID)
-		hashSetReportKey = makeUserReportHashSetKey(since, till)
+		hashSetReportKey = makeUserReportHashSetKey(key)
 		data             = marshalCBOR(t, report)
 	)
 	mock.ExpectHGet(hashSetName, hashSetReportKey).SetVal(string(data))
-	returnedReport, ok, err := cache.GetFromCache(context.Background(), userID, since, till)
+	returnedReport, ok, err := cache.GetFromCache(context.Background(), userID, key)
 	assert.NoError(t, err)
 	assert.True(t, ok)
 	assert.Equal(t, report, returnedReport)
@@ -74,19 +76,30 @@ func TestReportsRedisCache_GetFromCache_Miss(t *testing.T) {
 		userID = models.UserID(111)
 		since  = time.Now().Truncate(24 * time.Hour).UTC()
 		till   = since.AddDate(0, 0, 1)
+		key    = expense.ReportCacheKey{Since: since, Till: till, Currency: "USD", UserCurrency: "RUB"}
 	)
 	cache, mock := newReportsRedisTestCache(t)
 	var (
 		hashSetName      = makeUserReportsHashSetName(userID)
-		hashSetReportKey = makeUserReportHashSetKey(since, till)
+		hashSetReportKey = makeUserReportHashSetKey(key)
 	)
 	mock.ExpectHGet(hashSetName, hashSetReportKey).RedisNil()
-	returnedReport, ok, err := cache.GetFromCache(context.Background(), userID, since, till)
+	returnedReport, ok, err := cache.GetFromCache(context.Background(), userID, key)
 	assert.NoError(t, err)
 	assert.False(t, ok)
 	assert.Nil(t, returnedReport)
 }
 
+func Test_makeUserReportHashSetKey(t *testing.T) {
+	since := time.Now().Truncate(24 * time.Hour).UTC()
+	key := expense.ReportCacheKey{Since: since, Till: since.AddDate(0, 0, 1), Currency: "RUB", UserCurrency: "RUB"}
+	otherCurrency, otherUserCurrency, otherTag := key, key, key
+	otherCurrency.Currency, otherUserCurrency.UserCurrency, otherTag.Tag = "USD", "USD", "trip"
+	assert.NotEqual(t, makeUserReportHashSetKey(key), makeUserReportHashSetKey(otherCurrency))
+	assert.NotEqual(t, makeUserReportHashSetKey(key), makeUserReportHashSetKey(otherUserCurrency))
+	assert.NotEqual(t, makeUserReportHashSetKey(key), makeUserReportHashSetKey(otherTag))
+}
+
 func TestReportsRedisCache_DropCacheForUserID(t *testing.T) {
 	var (
 		userID      = models.UserID(111)
@@ -11,10 +11,12 @@ import (
 )
 
 type EventGenerateSummaryReportByCategories struct {
-	ChatID int64
-	UserID models.UserID
-	Since  time.Time
-	Till   time.Time
+	ChatID   int64
+	UserID   models.UserID
+	Since    time.Time
+	Till     time.Time
+	Currency models.CurrencyCode // the report currency, empty one means the user selected currency
+	Tag      models.ExpenseTag   // only expenses with the tag are reported, if it's not empty
 }
 
 func (e *EventGenerateSummaryReportByCategories) MarshalBinary() (data []byte, err error) {
@@ -22,8 +24,10 @@ func (e *EventGenerateSummaryReportByCategories) MarshalBinary() (data []byte, e
 		ChatId: e.ChatID,
 		UserId: int64(e.UserID),
 		Request: &events.Event_GenerateReport_ByCategories_{ByCategories: &events.Event_GenerateReport_ByCategories{
-			Since: timestamppb.New(e.Since),
-			Till:  timestamppb.New(e.Till),
+			Since:    timestamppb.New(e.Since),
+			Till:     timestamppb.New(e.Till),
+			Currency: string(e.Currency),
+			Tag:      string(e.Tag),
 		}},
 	}}}
 	return proto.Marshal(event)
@@ -44,10 +48,12 @@ func (e *EventGenerateSummaryReportByCategories) UnmarshalBinary(data []byte) er
 		return errors.Errorf("unexpected protobuf generate report by categories request type (%T)", genReportReq)
 	}
 	*e = EventGenerateSummaryReportByCategories{
-		ChatID: genReportEvent.GenerateReport.GetChatId(),
-		UserID: models.UserID(genReportEvent.GenerateReport.GetUserId()),
-		Since:  genByCategoriesRequest.ByCategories.GetSince().AsTime(),
-		Till:   genByCategoriesRequest.ByCategories.GetTill().AsTime(),
+		ChatID:   genReportEvent.GenerateReport.GetChatId(),
+		UserID:   models.UserID(genReportEvent.GenerateReport.GetUserId()),
+		Since:    genByCategoriesRequest.ByCategories.GetSince().AsTime(),
+		Till:     genByCategoriesRequest.ByCategories.GetTill().AsTime(),
+		Currency: models.CurrencyCode(genByCategoriesRequest.ByCategories.GetCurrency()),
+		Tag:      models.ExpenseTag(genByCategoriesRequest.ByCategories.GetTag()),
 	}
 	return nil
 }
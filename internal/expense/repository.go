@@ -4,12 +4,60 @@ import (
 	"context"
 	"time"
 
+	"github.com/pkg/errors"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
 )
 
+var (
+	ErrDoesNotExist         = errors.New("expense does not exist")
+	ErrRefundExceedsExpense = errors.New("refunds exceed the expense amount")
+	ErrMessageLinked        = errors.New("message is already linked to expense")
+
+	ErrCategoryDoesNotExist      = errors.New("category does not exist")
+	ErrCategoryExists            = errors.New("category already exists")
+	ErrCategoryAliasDoesNotExist = errors.New("category alias does not exist")
+	ErrCategoryRuleDoesNotExist  = errors.New("category rule does not exist")
+	ErrCategoryModelDoesNotExist = errors.New("category model does not exist")
+)
+
 type Repository interface {
 	Isolated(ctx context.Context, callback func(ctx context.Context) error) error
 	AddExpense(ctx context.Context, userID models.UserID, expense models.Expense) (models.Expense, error)
+	UpdateExpense(ctx context.Context, userID models.UserID, expense models.Expense) (models.Expense, error)
+	DeleteExpense(ctx context.Context, userID models.UserID, id models.ExpenseID) error
+	GetExpenseByID(ctx context.Context, userID models.UserID, id models.ExpenseID) (models.Expense, error)
+	// AddRefund links the refund to the user expense, ErrDoesNotExist is returned if there is no such expense.
+	AddRefund(ctx context.Context, userID models.UserID, refund models.Refund) (models.Refund, error)
+	// LinkMessageToExpense links the message to the expense, ErrMessageLinked is returned if it is already linked.
+	LinkMessageToExpense(ctx context.Context, userID models.UserID, msg models.MessageRef, id models.ExpenseID) error
+	GetExpenseIDByMessage(ctx context.Context, userID models.UserID, msg models.MessageRef) (models.ExpenseID, error)
 	GetExpensesByDate(ctx context.Context, userID models.UserID, date time.Time) ([]models.Expense, error)
 	GetExpensesAscendSinceTill(ctx context.Context, userID models.UserID, since, till time.Time, iter func(expense *models.Expense) bool) error
+	// CategoryExists reports whether the user has expenses of the category or its children, the case is ignored.
+	CategoryExists(ctx context.Context, userID models.UserID, category models.ExpenseCategory) (bool, error)
+	// RenameCategory moves expenses of the from category and its children to the to category, the case of from
+	// is ignored. Category rules, alias targets and limit notifications follow them, the category model is dropped
+	// to be trained again. It returns the number of moved expenses.
+	RenameCategory(ctx context.Context, userID models.UserID, from, to models.ExpenseCategory) (int64, error)
+	GetCategoryAliases(ctx context.Context, userID models.UserID) (map[models.ExpenseCategory]models.ExpenseCategory, error)
+	SetCategoryAlias(ctx context.Context, userID models.UserID, alias, category models.ExpenseCategory) error
+	DeleteCategoryAlias(ctx context.Context, userID models.UserID, alias models.ExpenseCategory) error
+	// GetCategoryRules returns the user category rules in the order they were added.
+	GetCategoryRules(ctx context.Context, userID models.UserID) ([]models.CategoryRule, error)
+	AddCategoryRule(ctx context.Context, userID models.UserID, rule models.CategoryRule) (models.CategoryRule, error)
+	DeleteCategoryRule(ctx context.Context, userID models.UserID, id models.CategoryRuleID) error
+	// GetCategoryModel returns the user category model, ErrCategoryModelDoesNotExist is returned if it has never been trained.
+	GetCategoryModel(ctx context.Context, userID models.UserID) (*models.CategoryModel, error)
+	CategoryModelExists(ctx context.Context, userID models.UserID) (bool, error)
+	// UpdateCategoryModel adds counts of the delta to the user category model, the model is created if it doesn't exist.
+	UpdateCategoryModel(ctx context.Context, userID models.UserID, delta *models.CategoryModel) error
+	// AddLimitNotification marks the threshold of the limit with the period and the category as notified
+	// in the period starting at periodStart. False is returned if the threshold was already notified in the period.
+	AddLimitNotification(
+		ctx context.Context,
+		userID models.UserID,
+		period models.LimitPeriod, category models.ExpenseCategory,
+		periodStart time.Time,
+		threshold int,
+	) (bool, error)
 }
@@ -2,27 +2,52 @@ package inmemory
 
 import (
 	"context"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/btree"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
 )
 
 type Repository struct {
-	mu           *sync.RWMutex
-	isolatedMu   *sync.Mutex
-	userExpenses map[models.UserID]*userExpenses
+	mu                 *sync.RWMutex
+	isolatedMu         *sync.Mutex
+	lastID             *atomic.Int64
+	userExpenses       map[models.UserID]*userExpenses
+	limitNotifications map[limitNotification]struct{}
+	categoryAliases    map[models.UserID]map[models.ExpenseCategory]models.ExpenseCategory
+	categoryRules      map[models.UserID][]models.CategoryRule
+	categoryModels     map[models.UserID]*models.CategoryModel
 }
+
+type limitNotification struct {
+	userID      models.UserID
+	period      models.LimitPeriod
+	category    models.ExpenseCategory
+	periodStart int64 // unix nanoseconds, because time.Time with different locations can't be compared by ==
+	threshold   int
+}
+
+// expensesAtOneDate holds expenses of one UTC day ordered by time.
 type expensesAtOneDate struct {
 	date     time.Time
 	expenses []*models.Expense
 }
 
 func newExpensesAtOneDate(date time.Time) *expensesAtOneDate {
-	y, m, d := date.Date()
-	midnight := time.Date(y, m, d, 0, 0, 0, 0, date.Location())
-	return &expensesAtOneDate{date: midnight}
+	return &expensesAtOneDate{date: models.StartOfDay(date.UTC())}
+}
+
+func (e *expensesAtOneDate) addExpense(exp *models.Expense) {
+	i := sort.Search(len(e.expenses), func(i int) bool {
+		return e.expenses[i].Date.After(exp.Date)
+	})
+	e.expenses = append(e.expenses, nil)
+	copy(e.expenses[i+1:], e.expenses[i:])
+	e.expenses[i] = exp
 }
 
 func (e *expensesAtOneDate) cloneExpenses() []models.Expense {
@@ -36,10 +61,20 @@ func (e *expensesAtOneDate) cloneExpenses() []models.Expense {
 	return out
 }
 
+func (e *expensesAtOneDate) removeExpense(id models.ExpenseID) {
+	for i, exp := range e.expenses {
+		if exp.ID == id {
+			e.expenses = append(e.expenses[:i], e.expenses[i+1:]...)
+			return
+		}
+	}
+}
+
 type userExpenses struct {
 	*sync.Mutex
-	byDate *btree.BTreeG[*expensesAtOneDate]
-	byID   map[models.ExpenseID]*models.Expense
+	byDate      *btree.BTreeG[*expensesAtOneDate]
+	byID        map[models.ExpenseID]*models.Expense
+	byMessageID map[models.MessageRef]models.ExpenseID
 }
 
 const newUserExpensesByDateBTreeDegree = 3
@@ -52,14 +87,20 @@ func newUserExpensesByDate(btreeDegree int) *userExpenses {
 		&sync.Mutex{},
 		btree.NewG(btreeDegree, less),
 		map[models.ExpenseID]*models.Expense{},
+		map[models.MessageRef]models.ExpenseID{},
 	}
 }
 
 func New() (*Repository, error) {
 	return &Repository{
-		mu:           &sync.RWMutex{},
-		isolatedMu:   &sync.Mutex{},
-		userExpenses: map[models.UserID]*userExpenses{},
+		mu:                 &sync.RWMutex{},
+		isolatedMu:         &sync.Mutex{},
+		lastID:             &atomic.Int64{},
+		userExpenses:       map[models.UserID]*userExpenses{},
+		limitNotifications: map[limitNotification]struct{}{},
+		categoryAliases:    map[models.UserID]map[models.ExpenseCategory]models.ExpenseCategory{},
+		categoryRules:      map[models.UserID][]models.CategoryRule{},
+		categoryModels:     map[models.UserID]*models.CategoryModel{},
 	}, nil
 }
 
@@ -96,32 +137,141 @@ func (r *Repository) Isolated(ctx context.Context, callback func(ctx context.Con
 	return callback(ctx)
 }
 
+func (u *userExpenses) insert(exp *models.Expense) {
+	keyVal := newExpensesAtOneDate(exp.Date)
+	expensesAtOneDay, ok := u.byDate.Get(keyVal)
+	if !ok {
+		expensesAtOneDay = keyVal
+		u.byDate.ReplaceOrInsert(expensesAtOneDay)
+	}
+	expensesAtOneDay.addExpense(exp)
+	u.byID[exp.ID] = exp
+}
+
+func (u *userExpenses) remove(id models.ExpenseID) bool {
+	exp, ok := u.byID[id]
+	if !ok {
+		return false
+	}
+	delete(u.byID, id)
+	expensesAtOneDay, ok := u.byDate.Get(newExpensesAtOneDate(exp.Date))
+	if !ok {
+		return true
+	}
+	expensesAtOneDay.removeExpense(id)
+	if len(expensesAtOneDay.expenses) == 0 {
+		u.byDate.Delete(expensesAtOneDay)
+	}
+	return true
+}
+
 func (r *Repository) AddExpense(ctx context.Context, userID models.UserID, expense models.Expense) (models.Expense, error) {
 	expenses := r.getUserExpenses(userID)
 	expenses.Lock()
 	defer expenses.Unlock()
 
-	keyVal := newExpensesAtOneDate(expense.Date)
-	expensesAtOneDay, ok := expenses.byDate.Get(keyVal)
+	expense.ID = models.ExpenseID(r.lastID.Add(1))
+	expense.Tags = append([]models.ExpenseTag(nil), expense.Tags...)
+	expenses.insert(&expense)
+	return expense, nil
+}
+
+func (r *Repository) UpdateExpense(ctx context.Context, userID models.UserID, exp models.Expense) (models.Expense, error) {
+	expenses := r.getUserExpenses(userID)
+	expenses.Lock()
+	defer expenses.Unlock()
+
+	old, ok := expenses.byID[exp.ID]
 	if !ok {
-		expensesAtOneDay = keyVal
-		expenses.byDate.ReplaceOrInsert(expensesAtOneDay)
+		return models.Expense{}, expense.ErrDoesNotExist
 	}
-	expensesAtOneDay.expenses = append(expensesAtOneDay.expenses, &expense)
-	expenses.byID[expense.ID] = &expense
-	return expense, nil
+	exp.AuthorID, exp.Refunded = old.AuthorID, old.Refunded
+	exp.Tags = append([]models.ExpenseTag(nil), exp.Tags...)
+	expenses.remove(exp.ID)
+	expenses.insert(&exp)
+	return exp, nil
 }
 
-func (r *Repository) GetExpensesByDate(ctx context.Context, userID models.UserID, date time.Time) ([]models.Expense, error) {
+func (r *Repository) DeleteExpense(ctx context.Context, userID models.UserID, id models.ExpenseID) error {
 	expenses := r.getUserExpenses(userID)
 	expenses.Lock()
 	defer expenses.Unlock()
 
-	expensesAtOneDay, ok := expenses.byDate.Get(newExpensesAtOneDate(date))
+	if !expenses.remove(id) {
+		return expense.ErrDoesNotExist
+	}
+	for msg, expenseID := range expenses.byMessageID {
+		if expenseID == id {
+			delete(expenses.byMessageID, msg)
+		}
+	}
+	return nil
+}
+
+func (r *Repository) GetExpenseByID(ctx context.Context, userID models.UserID, id models.ExpenseID) (models.Expense, error) {
+	expenses := r.getUserExpenses(userID)
+	expenses.Lock()
+	defer expenses.Unlock()
+
+	exp, ok := expenses.byID[id]
 	if !ok {
-		return nil, nil
+		return models.Expense{}, expense.ErrDoesNotExist
 	}
-	return expensesAtOneDay.cloneExpenses(), nil
+	return *exp, nil
+}
+
+func (r *Repository) AddRefund(ctx context.Context, userID models.UserID, refund models.Refund) (models.Refund, error) {
+	expenses := r.getUserExpenses(userID)
+	expenses.Lock()
+	defer expenses.Unlock()
+
+	exp, ok := expenses.byID[refund.ExpenseID]
+	if !ok {
+		return models.Refund{}, expense.ErrDoesNotExist
+	}
+	refund.ID = models.RefundID(r.lastID.Add(1))
+	exp.Refunded = exp.Refunded.Add(refund.Amount)
+	return refund, nil
+}
+
+func (r *Repository) LinkMessageToExpense(ctx context.Context, userID models.UserID, msg models.MessageRef, id models.ExpenseID) error {
+	expenses := r.getUserExpenses(userID)
+	expenses.Lock()
+	defer expenses.Unlock()
+
+	if _, ok := expenses.byID[id]; !ok {
+		return expense.ErrDoesNotExist
+	}
+	if _, ok := expenses.byMessageID[msg]; ok {
+		return expense.ErrMessageLinked
+	}
+	expenses.byMessageID[msg] = id
+	return nil
+}
+
+func (r *Repository) GetExpenseIDByMessage(ctx context.Context, userID models.UserID, msg models.MessageRef) (models.ExpenseID, error) {
+	expenses := r.getUserExpenses(userID)
+	expenses.Lock()
+	defer expenses.Unlock()
+
+	id, ok := expenses.byMessageID[msg]
+	if !ok {
+		return 0, expense.ErrDoesNotExist
+	}
+	return id, nil
+}
+
+func (r *Repository) GetExpensesByDate(ctx context.Context, userID models.UserID, date time.Time) ([]models.Expense, error) {
+	var out []models.Expense
+	// the day is taken in the date location
+	err := r.GetExpensesAscendSinceTill(ctx, userID, models.StartOfDay(date), models.EndOfDay(date), func(e *models.Expense) bool {
+		out = append(out, *e)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
 func (r *Repository) GetExpensesAscendSinceTill(
@@ -134,31 +284,198 @@ func (r *Repository) GetExpensesAscendSinceTill(
 	expenses.Lock()
 	defer expenses.Unlock()
 
-	if since.Equal(till) {
-		key := newExpensesAtOneDate(since)
-		if atOneDate, ok := expenses.byDate.Get(key); ok {
-			for _, e := range atOneDate.expenses {
-				if !iter(e) {
-					return nil
-				}
-			}
+	var (
+		greaterOrEqual = newExpensesAtOneDate(since)
+		lessThan       = newExpensesAtOneDate(till)
+	)
+	expenses.byDate.AscendGreaterOrEqual(greaterOrEqual, func(atOneDate *expensesAtOneDate) bool {
+		if atOneDate.date.After(lessThan.date) {
+			return false
 		}
-	} else {
-		var (
-			greaterOrEqual = newExpensesAtOneDate(since)
-			lessThan       = newExpensesAtOneDate(till)
-		)
-		expenses.byDate.AscendGreaterOrEqual(greaterOrEqual, func(atOneDate *expensesAtOneDate) bool {
-			if atOneDate.date.After(lessThan.date) {
-				return false
+		for _, e := range atOneDate.expenses {
+			if e.Date.Before(since) || e.Date.After(till) {
+				continue
 			}
-			for _, e := range atOneDate.expenses {
-				if !iter(e) {
-					return false
-				}
+			if !iter(e) {
+				return false
 			}
-			return true
-		})
+		}
+		return true
+	})
+	return nil
+}
+
+func (r *Repository) CategoryExists(ctx context.Context, userID models.UserID, category models.ExpenseCategory) (bool, error) {
+	expenses := r.getUserExpenses(userID)
+	expenses.Lock()
+	defer expenses.Unlock()
+
+	for _, exp := range expenses.byID {
+		if _, ok := exp.Category.Rebase(category, category); ok {
+			return true, nil
+		}
 	}
+	return false, nil
+}
+
+func (r *Repository) RenameCategory(ctx context.Context, userID models.UserID, from, to models.ExpenseCategory) (int64, error) {
+	expenses := r.getUserExpenses(userID)
+	expenses.Lock()
+	defer expenses.Unlock()
+
+	var renamed int64
+	for _, exp := range expenses.byID {
+		if category, ok := exp.Category.Rebase(from, to); ok {
+			exp.Category = category
+			renamed++
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rules := r.categoryRules[userID]
+	for i := range rules {
+		rules[i].Category, _ = rules[i].Category.Rebase(from, to)
+	}
+	for alias, category := range r.categoryAliases[userID] {
+		r.categoryAliases[userID][alias], _ = category.Rebase(from, to)
+	}
+	var notified []limitNotification
+	for key := range r.limitNotifications {
+		if _, ok := key.category.Rebase(from, to); ok && key.userID == userID {
+			notified = append(notified, key)
+		}
+	}
+	for _, key := range notified {
+		delete(r.limitNotifications, key)
+	}
+	for _, key := range notified {
+		key.category, _ = key.category.Rebase(from, to)
+		r.limitNotifications[key] = struct{}{}
+	}
+	delete(r.categoryModels, userID)
+	return renamed, nil
+}
+
+func (r *Repository) GetCategoryAliases(ctx context.Context, userID models.UserID) (map[models.ExpenseCategory]models.ExpenseCategory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make(map[models.ExpenseCategory]models.ExpenseCategory, len(r.categoryAliases[userID]))
+	for alias, category := range r.categoryAliases[userID] {
+		out[alias] = category
+	}
+	return out, nil
+}
+
+func (r *Repository) SetCategoryAlias(ctx context.Context, userID models.UserID, alias, category models.ExpenseCategory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	aliases, ok := r.categoryAliases[userID]
+	if !ok {
+		aliases = make(map[models.ExpenseCategory]models.ExpenseCategory)
+		r.categoryAliases[userID] = aliases
+	}
+	aliases[alias] = category
+	return nil
+}
+
+func (r *Repository) DeleteCategoryAlias(ctx context.Context, userID models.UserID, alias models.ExpenseCategory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.categoryAliases[userID][alias]; !ok {
+		return expense.ErrCategoryAliasDoesNotExist
+	}
+	delete(r.categoryAliases[userID], alias)
+	return nil
+}
+
+func (r *Repository) GetCategoryRules(ctx context.Context, userID models.UserID) ([]models.CategoryRule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]models.CategoryRule(nil), r.categoryRules[userID]...), nil
+}
+
+func (r *Repository) AddCategoryRule(ctx context.Context, userID models.UserID, rule models.CategoryRule) (models.CategoryRule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rule.ID = models.CategoryRuleID(r.lastID.Add(1))
+	r.categoryRules[userID] = append(r.categoryRules[userID], rule)
+	return rule, nil
+}
+
+func (r *Repository) DeleteCategoryRule(ctx context.Context, userID models.UserID, id models.CategoryRuleID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rules := r.categoryRules[userID]
+	for i := range rules {
+		if rules[i].ID == id {
+			r.categoryRules[userID] = append(rules[:i:i], rules[i+1:]...)
+			return nil
+		}
+	}
+	return expense.ErrCategoryRuleDoesNotExist
+}
+
+func (r *Repository) GetCategoryModel(ctx context.Context, userID models.UserID) (*models.CategoryModel, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	model, ok := r.categoryModels[userID]
+	if !ok {
+		return nil, expense.ErrCategoryModelDoesNotExist
+	}
+	out := models.NewCategoryModel()
+	out.Merge(model)
+	return out, nil
+}
+
+func (r *Repository) CategoryModelExists(ctx context.Context, userID models.UserID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.categoryModels[userID]
+	return ok, nil
+}
+
+func (r *Repository) UpdateCategoryModel(ctx context.Context, userID models.UserID, delta *models.CategoryModel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	model, ok := r.categoryModels[userID]
+	if !ok {
+		model = models.NewCategoryModel()
+		r.categoryModels[userID] = model
+	}
+	model.Merge(delta)
 	return nil
 }
+
+func (r *Repository) AddLimitNotification(
+	ctx context.Context,
+	userID models.UserID,
+	period models.LimitPeriod, category models.ExpenseCategory,
+	periodStart time.Time,
+	threshold int,
+) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := limitNotification{
+		userID:      userID,
+		period:      period,
+		category:    category,
+		periodStart: periodStart.UnixNano(),
+		threshold:   threshold,
+	}
+	if _, ok := r.limitNotifications[key]; ok {
+		return false, nil
+	}
+	r.limitNotifications[key] = struct{}{}
+	return true, nil
+}
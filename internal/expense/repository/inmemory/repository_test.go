@@ -8,6 +8,7 @@ import (
 
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
 )
 
@@ -112,3 +113,123 @@ func TestRepository_ExpensesAscendSinceTill(t *testing.T) {
 	}
 
 }
+
+func TestRepository_UpdateAndDeleteExpense(t *testing.T) {
+	const userID = models.UserID(10)
+	ctx := context.Background()
+	now := time.Now()
+
+	r := newRepo(t)
+	exp := models.Expense{
+		ID:       1,
+		Category: "test",
+		Amount:   decimal.NewFromInt(42),
+		Date:     now,
+		Comment:  "test comment",
+	}
+	_, err := r.AddExpense(ctx, userID, exp)
+	require.NoError(t, err)
+
+	updated := exp
+	updated.Category = "updated"
+	updated.Date = now.AddDate(0, 0, -1)
+	_, err = r.UpdateExpense(ctx, userID, updated)
+	require.NoError(t, err)
+
+	actual, err := r.GetExpenseByID(ctx, userID, exp.ID)
+	require.NoError(t, err)
+	require.Equal(t, updated, actual)
+
+	expensesByDate, err := r.GetExpensesByDate(ctx, userID, exp.Date)
+	require.NoError(t, err)
+	require.Empty(t, expensesByDate)
+	expensesByDate, err = r.GetExpensesByDate(ctx, userID, updated.Date)
+	require.NoError(t, err)
+	require.Equal(t, []models.Expense{updated}, expensesByDate)
+
+	err = r.DeleteExpense(ctx, userID, exp.ID)
+	require.NoError(t, err)
+	_, err = r.GetExpenseByID(ctx, userID, exp.ID)
+	require.ErrorIs(t, err, expense.ErrDoesNotExist)
+	err = r.DeleteExpense(ctx, userID, exp.ID)
+	require.ErrorIs(t, err, expense.ErrDoesNotExist)
+	_, err = r.UpdateExpense(ctx, userID, updated)
+	require.ErrorIs(t, err, expense.ErrDoesNotExist)
+}
+
+func TestRepository_UpdateExpenseKeepsAuthor(t *testing.T) {
+	const userID = models.UserID(-10)
+	ctx := context.Background()
+
+	r := newRepo(t)
+	created, err := r.AddExpense(ctx, userID, models.Expense{
+		Category: "test",
+		Amount:   decimal.NewFromInt(42),
+		Date:     time.Now(),
+		AuthorID: 11,
+	})
+	require.NoError(t, err)
+
+	created.Amount, created.AuthorID = decimal.NewFromInt(24), 0
+	updated, err := r.UpdateExpense(ctx, userID, created)
+	require.NoError(t, err)
+	require.Equal(t, models.UserID(11), updated.AuthorID)
+	stored, err := r.GetExpenseByID(ctx, userID, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, updated, stored)
+}
+
+func TestRepository_LinkMessageToExpense(t *testing.T) {
+	const ledgerID = models.UserID(-100)
+	ctx := context.Background()
+
+	r := newRepo(t)
+	first, err := r.AddExpense(ctx, ledgerID, models.Expense{Category: "food", Amount: decimal.NewFromInt(10), Date: time.Now()})
+	require.NoError(t, err)
+	second, err := r.AddExpense(ctx, ledgerID, models.Expense{Category: "taxi", Amount: decimal.NewFromInt(20), Date: time.Now()})
+	require.NoError(t, err)
+
+	// members of the ledger send messages with the same IDs from their private chats
+	firstMsg := models.MessageRef{ChatID: 1, MessageID: 7}
+	secondMsg := models.MessageRef{ChatID: 2, MessageID: 7}
+	require.NoError(t, r.LinkMessageToExpense(ctx, ledgerID, firstMsg, first.ID))
+	require.NoError(t, r.LinkMessageToExpense(ctx, ledgerID, secondMsg, second.ID))
+	require.ErrorIs(t, r.LinkMessageToExpense(ctx, ledgerID, firstMsg, second.ID), expense.ErrMessageLinked)
+
+	id, err := r.GetExpenseIDByMessage(ctx, ledgerID, firstMsg)
+	require.NoError(t, err)
+	require.Equal(t, first.ID, id)
+	id, err = r.GetExpenseIDByMessage(ctx, ledgerID, secondMsg)
+	require.NoError(t, err)
+	require.Equal(t, second.ID, id)
+}
+
+func TestRepository_MessageLinkOfUpdatedAndDeletedExpense(t *testing.T) {
+	const ledgerID = models.UserID(-100)
+	ctx := context.Background()
+	msg := models.MessageRef{ChatID: 1, MessageID: 7}
+
+	r := newRepo(t)
+	created, err := r.AddExpense(ctx, ledgerID, models.Expense{Category: "food", Amount: decimal.NewFromInt(10), Date: time.Now()})
+	require.NoError(t, err)
+	require.NoError(t, r.LinkMessageToExpense(ctx, ledgerID, msg, created.ID))
+
+	created.Amount = decimal.NewFromInt(15)
+	_, err = r.UpdateExpense(ctx, ledgerID, created)
+	require.NoError(t, err)
+	id, err := r.GetExpenseIDByMessage(ctx, ledgerID, msg)
+	require.NoError(t, err)
+	require.Equal(t, created.ID, id)
+
+	require.NoError(t, r.DeleteExpense(ctx, ledgerID, created.ID))
+	// editing the message of the deleted expense must not find it anymore
+	_, err = r.GetExpenseIDByMessage(ctx, ledgerID, msg)
+	require.ErrorIs(t, err, expense.ErrDoesNotExist)
+
+	recreated, err := r.AddExpense(ctx, ledgerID, models.Expense{Category: "food", Amount: decimal.NewFromInt(15), Date: time.Now()})
+	require.NoError(t, err)
+	require.NoError(t, r.LinkMessageToExpense(ctx, ledgerID, msg, recreated.ID))
+	id, err = r.GetExpenseIDByMessage(ctx, ledgerID, msg)
+	require.NoError(t, err)
+	require.Equal(t, recreated.ID, id)
+}
@@ -2,13 +2,22 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/common/database/postgres"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
 )
 
+const selectExpenses = `SELECT e.id, e.category, e.amount, e.date, e.comment, COALESCE(e.author_id, 0),
+	COALESCE(e.original_amount, 0), COALESCE(e.original_currency, ''), COALESCE(e.rate, 0), COALESCE(r.amount, 0),
+	(SELECT COALESCE(string_agg(t.name, ' ' ORDER BY t.name), '') FROM expense_tags et JOIN tags t ON t.id = et.tag_id
+		WHERE et.expense_id = e.id)
+	FROM expenses e LEFT JOIN LATERAL (SELECT SUM(amount) AS amount FROM refunds WHERE expense_id = e.id) r ON TRUE`
+
 type Repository struct {
 	db postgres.DBDoer
 }
@@ -23,18 +32,168 @@ func (r *Repository) Isolated(ctx context.Context, callback func(ctx context.Con
 
 func (r *Repository) AddExpense(ctx context.Context, userID models.UserID, exp models.Expense) (models.Expense, error) {
 	err := r.db.Do(ctx).QueryRowContext(ctx,
-		"INSERT INTO expenses (user_id, category, amount, date, comment) VALUES ($1, $2, $3, $4, $5) RETURNING id",
-		userID, exp.Category, exp.Amount, exp.Date.UTC(), exp.Comment,
+		`INSERT INTO expenses (user_id, category, amount, date, comment, author_id, original_amount, original_currency, rate)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), NULLIF($7, 0), NULLIF($8, ''), NULLIF($9, 0)) RETURNING id`,
+		userID, exp.Category, exp.Amount, exp.Date.UTC(), exp.Comment, exp.AuthorID,
+		exp.OriginalAmount, exp.OriginalCurrency, exp.Rate,
 	).Scan(&exp.ID)
 	if err != nil {
 		return models.Expense{}, errors.Wrap(err, "failed to add expense to db")
 	}
+	if err := r.setExpenseTags(ctx, userID, exp.ID, exp.Tags); err != nil {
+		return models.Expense{}, err
+	}
 	return exp, nil
 }
 
+func (r *Repository) UpdateExpense(ctx context.Context, userID models.UserID, exp models.Expense) (models.Expense, error) {
+	// the expense author and refunds are kept
+	err := r.db.Do(ctx).QueryRowContext(ctx,
+		`UPDATE expenses SET category = $1, amount = $2, date = $3, comment = $4,
+				original_amount = NULLIF($7, 0), original_currency = NULLIF($8, ''), rate = NULLIF($9, 0)
+			WHERE id = $5 AND user_id = $6
+			RETURNING COALESCE(author_id, 0), (SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE expense_id = $5)`,
+		exp.Category, exp.Amount, exp.Date.UTC(), exp.Comment, exp.ID, userID,
+		exp.OriginalAmount, exp.OriginalCurrency, exp.Rate,
+	).Scan(&exp.AuthorID, &exp.Refunded)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Expense{}, expense.ErrDoesNotExist
+		}
+		return models.Expense{}, errors.Wrapf(err, "failed to update expenseID=%d in db", exp.ID)
+	}
+	if err := r.setExpenseTags(ctx, userID, exp.ID, exp.Tags); err != nil {
+		return models.Expense{}, err
+	}
+	return exp, nil
+}
+
+// setExpenseTags replaces the expense tags, new tags of the user are created. It should be called
+// in the isolated environment, because several queries are made.
+func (r *Repository) setExpenseTags(ctx context.Context, userID models.UserID, id models.ExpenseID, tags []models.ExpenseTag) error {
+	db := r.db.Do(ctx)
+	if _, err := db.ExecContext(ctx, "DELETE FROM expense_tags WHERE expense_id = $1", id); err != nil {
+		return errors.Wrapf(err, "failed to delete tags of expenseID=%d from db", id)
+	}
+	for _, tag := range tags {
+		_, err := db.ExecContext(ctx, `
+				WITH tag AS (
+					INSERT INTO tags (user_id, name) VALUES ($1, $2)
+					ON CONFLICT (user_id, name) DO UPDATE SET name = EXCLUDED.name
+					RETURNING id
+				)
+				INSERT INTO expense_tags (expense_id, tag_id) SELECT $3, id FROM tag ON CONFLICT DO NOTHING`,
+			userID, tag, id,
+		)
+		if err != nil {
+			return errors.Wrapf(err, "failed to add tag %q of expenseID=%d to db", tag, id)
+		}
+	}
+	return nil
+}
+
+// scanTags splits tags aggregated by selectExpenses.
+func scanTags(aggregated string) []models.ExpenseTag {
+	if aggregated == "" {
+		return nil
+	}
+	names := strings.Fields(aggregated)
+	out := make([]models.ExpenseTag, 0, len(names))
+	for _, name := range names {
+		out = append(out, models.ExpenseTag(name))
+	}
+	return out
+}
+
+func (r *Repository) DeleteExpense(ctx context.Context, userID models.UserID, id models.ExpenseID) error {
+	res, err := r.db.Do(ctx).ExecContext(ctx, "DELETE FROM expenses WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete expenseID=%d from db", id)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete expenseID=%d from db", id)
+	}
+	if affected == 0 {
+		return expense.ErrDoesNotExist
+	}
+	return nil
+}
+
+func (r *Repository) GetExpenseByID(ctx context.Context, userID models.UserID, id models.ExpenseID) (models.Expense, error) {
+	var (
+		e    models.Expense
+		tags string
+	)
+	err := r.db.Do(ctx).QueryRowContext(ctx,
+		selectExpenses+" WHERE e.id = $1 AND e.user_id = $2",
+		id, userID,
+	).Scan(&e.ID, &e.Category, &e.Amount, &e.Date, &e.Comment, &e.AuthorID, &e.OriginalAmount, &e.OriginalCurrency, &e.Rate, &e.Refunded, &tags)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Expense{}, expense.ErrDoesNotExist
+		}
+		return models.Expense{}, errors.Wrapf(err, "failed to get expenseID=%d from db", id)
+	}
+	e.Tags = scanTags(tags)
+	return e, nil
+}
+
+func (r *Repository) AddRefund(ctx context.Context, userID models.UserID, refund models.Refund) (models.Refund, error) {
+	err := r.db.Do(ctx).QueryRowContext(ctx,
+		`INSERT INTO refunds (expense_id, amount, date)
+			SELECT id, $3, $4 FROM expenses WHERE id = $1 AND user_id = $2
+			RETURNING id`,
+		refund.ExpenseID, userID, refund.Amount, refund.Date.UTC(),
+	).Scan(&refund.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Refund{}, expense.ErrDoesNotExist
+		}
+		return models.Refund{}, errors.Wrapf(err, "failed to add refund of expenseID=%d to db", refund.ExpenseID)
+	}
+	return refund, nil
+}
+
+func (r *Repository) LinkMessageToExpense(ctx context.Context, userID models.UserID, msg models.MessageRef, id models.ExpenseID) error {
+	res, err := r.db.Do(ctx).ExecContext(ctx, `
+			INSERT INTO expense_messages (user_id, chat_id, message_id, expense_id)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT DO NOTHING`,
+		userID, msg.ChatID, msg.MessageID, id,
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to link messageID=%d of chatID=%d to expenseID=%d in db", msg.MessageID, msg.ChatID, id)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "failed to link messageID=%d of chatID=%d to expenseID=%d in db", msg.MessageID, msg.ChatID, id)
+	}
+	if affected == 0 {
+		return expense.ErrMessageLinked
+	}
+	return nil
+}
+
+func (r *Repository) GetExpenseIDByMessage(ctx context.Context, userID models.UserID, msg models.MessageRef) (models.ExpenseID, error) {
+	var id models.ExpenseID
+	err := r.db.Do(ctx).QueryRowContext(ctx,
+		"SELECT expense_id FROM expense_messages WHERE user_id = $1 AND chat_id = $2 AND message_id = $3",
+		userID, msg.ChatID, msg.MessageID,
+	).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, expense.ErrDoesNotExist
+		}
+		return 0, errors.Wrapf(err, "failed to get expenseID by messageID=%d of chatID=%d from db", msg.MessageID, msg.ChatID)
+	}
+	return id, nil
+}
+
 func (r *Repository) GetExpensesByDate(ctx context.Context, userID models.UserID, date time.Time) ([]models.Expense, error) {
 	var out []models.Expense
-	err := r.GetExpensesAscendSinceTill(ctx, userID, date, date, func(e *models.Expense) bool {
+	// the day is taken in the date location
+	err := r.GetExpensesAscendSinceTill(ctx, userID, models.StartOfDay(date), models.EndOfDay(date), func(e *models.Expense) bool {
 		out = append(out, *e)
 		return true
 	})
@@ -51,7 +210,7 @@ func (r *Repository) GetExpensesAscendSinceTill(
 	iter func(expense *models.Expense) bool,
 ) (err error) {
 	rows, err := r.db.Do(ctx).QueryContext(ctx,
-		"SELECT id, category, amount, date, comment FROM expenses WHERE user_id = $1 AND date BETWEEN $2 AND $3 ORDER BY date",
+		selectExpenses+" WHERE e.user_id = $1 AND e.date BETWEEN $2 AND $3 ORDER BY e.date",
 		userID, since.UTC(), till.UTC(),
 	)
 	if err != nil {
@@ -59,10 +218,17 @@ func (r *Repository) GetExpensesAscendSinceTill(
 	}
 	defer rows.Close()
 	for rows.Next() {
-		var e models.Expense
-		if err := rows.Scan(&e.ID, &e.Category, &e.Amount, &e.Date, &e.Comment); err != nil {
+		var (
+			e    models.Expense
+			tags string
+		)
+		if err := rows.Scan(
+			&e.ID, &e.Category, &e.Amount, &e.Date, &e.Comment, &e.AuthorID,
+			&e.OriginalAmount, &e.OriginalCurrency, &e.Rate, &e.Refunded, &tags,
+		); err != nil {
 			return errors.Wrap(err, "failed to scan expenses since/till")
 		}
+		e.Tags = scanTags(tags)
 		if !iter(&e) {
 			return nil
 		}
@@ -72,3 +238,265 @@ func (r *Repository) GetExpensesAscendSinceTill(
 	}
 	return nil
 }
+
+func (r *Repository) CategoryExists(ctx context.Context, userID models.UserID, category models.ExpenseCategory) (bool, error) {
+	var exists bool
+	err := r.db.Do(ctx).QueryRowContext(ctx, `
+			SELECT EXISTS(SELECT 1 FROM expenses WHERE user_id = $1
+				AND (lower(category) = lower($2) OR left(lower(category), length($2) + 1) = lower($2) || '/'))`,
+		userID, category,
+	).Scan(&exists)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to check category %q existence in db", category)
+	}
+	return exists, nil
+}
+
+// categoryWithinFrom matches the category column within the $2 category, categoryRebasedTo is its path under $3.
+const (
+	categoryWithinFrom = "(lower(category) = lower($2) OR left(lower(category), length($2) + 1) = lower($2) || '/')"
+	categoryRebasedTo  = "$3 || substr(lower(category), length($2) + 1)"
+)
+
+func (r *Repository) RenameCategory(ctx context.Context, userID models.UserID, from, to models.ExpenseCategory) (int64, error) {
+	db := r.db.Do(ctx)
+	// the rest of the nested category path follows the new parent
+	res, err := db.ExecContext(ctx,
+		"UPDATE expenses SET category = "+categoryRebasedTo+" WHERE user_id = $1 AND "+categoryWithinFrom,
+		userID, from, to,
+	)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to rename category %q to %q in db", from, to)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to rename category %q to %q in db", from, to)
+	}
+	for _, table := range []string{"category_rules", "category_aliases"} {
+		_, err := db.ExecContext(ctx,
+			"UPDATE "+table+" SET category = "+categoryRebasedTo+" WHERE user_id = $1 AND "+categoryWithinFrom,
+			userID, from, to,
+		)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to rename category %q to %q in %s in db", from, to, table)
+		}
+	}
+	// notifications of the merged limits are kept as they are, the limits of the target category stay
+	_, err = db.ExecContext(ctx, `
+			UPDATE limit_notifications n SET category = `+categoryRebasedTo+`
+			WHERE user_id = $1 AND `+categoryWithinFrom+` AND NOT EXISTS (
+				SELECT 1 FROM limit_notifications o
+				WHERE o.user_id = n.user_id AND o.period = n.period AND o.period_start = n.period_start
+					AND o.threshold = n.threshold AND o.category = $3 || substr(lower(n.category), length($2) + 1))`,
+		userID, from, to,
+	)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to rename category %q to %q in limit notifications in db", from, to)
+	}
+	// the model is trained again from the renamed expenses when the next expense is added
+	if _, err := db.ExecContext(ctx, "DELETE FROM category_models WHERE user_id = $1", userID); err != nil {
+		return 0, errors.Wrap(err, "failed to delete category model from db")
+	}
+	return affected, nil
+}
+
+func (r *Repository) GetCategoryAliases(ctx context.Context, userID models.UserID) (map[models.ExpenseCategory]models.ExpenseCategory, error) {
+	rows, err := r.db.Do(ctx).QueryContext(ctx, "SELECT alias, category FROM category_aliases WHERE user_id = $1", userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create db query and get category aliases")
+	}
+	defer rows.Close()
+	out := make(map[models.ExpenseCategory]models.ExpenseCategory)
+	for rows.Next() {
+		var alias, category models.ExpenseCategory
+		if err := rows.Scan(&alias, &category); err != nil {
+			return nil, errors.Wrap(err, "failed to scan category aliases")
+		}
+		out[alias] = category
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "error occurred after scanning category aliases")
+	}
+	return out, nil
+}
+
+func (r *Repository) SetCategoryAlias(ctx context.Context, userID models.UserID, alias, category models.ExpenseCategory) error {
+	_, err := r.db.Do(ctx).ExecContext(ctx, `
+			INSERT INTO category_aliases (user_id, alias, category)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, alias) DO UPDATE SET category = $3`,
+		userID, alias, category,
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set category alias %q to db", alias)
+	}
+	return nil
+}
+
+func (r *Repository) DeleteCategoryAlias(ctx context.Context, userID models.UserID, alias models.ExpenseCategory) error {
+	res, err := r.db.Do(ctx).ExecContext(ctx, "DELETE FROM category_aliases WHERE user_id = $1 AND alias = $2", userID, alias)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete category alias %q from db", alias)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete category alias %q from db", alias)
+	}
+	if affected == 0 {
+		return expense.ErrCategoryAliasDoesNotExist
+	}
+	return nil
+}
+
+func (r *Repository) GetCategoryRules(ctx context.Context, userID models.UserID) ([]models.CategoryRule, error) {
+	rows, err := r.db.Do(ctx).QueryContext(ctx, `
+			SELECT id, pattern, is_regex, category FROM category_rules
+			WHERE user_id = $1
+			ORDER BY id`,
+		userID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create db query and get category rules")
+	}
+	defer rows.Close()
+	var out []models.CategoryRule
+	for rows.Next() {
+		var rule models.CategoryRule
+		if err := rows.Scan(&rule.ID, &rule.Pattern, &rule.IsRegex, &rule.Category); err != nil {
+			return nil, errors.Wrap(err, "failed to scan category rules")
+		}
+		out = append(out, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "error occurred after scanning category rules")
+	}
+	return out, nil
+}
+
+func (r *Repository) AddCategoryRule(ctx context.Context, userID models.UserID, rule models.CategoryRule) (models.CategoryRule, error) {
+	err := r.db.Do(ctx).QueryRowContext(ctx, `
+			INSERT INTO category_rules (user_id, pattern, is_regex, category)
+			VALUES ($1, $2, $3, $4) RETURNING id`,
+		userID, rule.Pattern, rule.IsRegex, rule.Category,
+	).Scan(&rule.ID)
+	if err != nil {
+		return models.CategoryRule{}, errors.Wrapf(err, "failed to add category rule %q to db", rule.Pattern)
+	}
+	return rule, nil
+}
+
+func (r *Repository) DeleteCategoryRule(ctx context.Context, userID models.UserID, id models.CategoryRuleID) error {
+	res, err := r.db.Do(ctx).ExecContext(ctx, "DELETE FROM category_rules WHERE user_id = $1 AND id = $2", userID, id)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete category ruleID=%d from db", id)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete category ruleID=%d from db", id)
+	}
+	if affected == 0 {
+		return expense.ErrCategoryRuleDoesNotExist
+	}
+	return nil
+}
+
+func (r *Repository) GetCategoryModel(ctx context.Context, userID models.UserID) (*models.CategoryModel, error) {
+	exists, err := r.CategoryModelExists(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, expense.ErrCategoryModelDoesNotExist
+	}
+	rows, err := r.db.Do(ctx).QueryContext(ctx, "SELECT category, token, count FROM category_model_counts WHERE user_id = $1", userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create db query and get category model")
+	}
+	defer rows.Close()
+	model := models.NewCategoryModel()
+	for rows.Next() {
+		var (
+			category models.ExpenseCategory
+			token    string
+			count    int64
+		)
+		if err := rows.Scan(&category, &token, &count); err != nil {
+			return nil, errors.Wrap(err, "failed to scan category model")
+		}
+		// the empty token counts expenses of the category
+		if token == "" {
+			model.AddExpenses(category, count)
+			continue
+		}
+		model.AddToken(category, token, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "error occurred after scanning category model")
+	}
+	return model, nil
+}
+
+func (r *Repository) CategoryModelExists(ctx context.Context, userID models.UserID) (bool, error) {
+	var exists bool
+	err := r.db.Do(ctx).QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM category_models WHERE user_id = $1)", userID).Scan(&exists)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to check category model existence in db")
+	}
+	return exists, nil
+}
+
+func (r *Repository) UpdateCategoryModel(ctx context.Context, userID models.UserID, delta *models.CategoryModel) error {
+	db := r.db.Do(ctx)
+	_, err := db.ExecContext(ctx, "INSERT INTO category_models (user_id) VALUES ($1) ON CONFLICT DO NOTHING", userID)
+	if err != nil {
+		return errors.Wrap(err, "failed to create category model in db")
+	}
+	var (
+		categories, tokens []string
+		counts             []int64
+	)
+	for category, count := range delta.Expenses {
+		categories, tokens, counts = append(categories, string(category)), append(tokens, ""), append(counts, count)
+	}
+	for category, categoryTokens := range delta.Tokens {
+		for token, count := range categoryTokens {
+			categories, tokens, counts = append(categories, string(category)), append(tokens, token), append(counts, count)
+		}
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+	_, err = db.ExecContext(ctx, `
+			INSERT INTO category_model_counts (user_id, category, token, count)
+			SELECT $1, * FROM unnest($2::text[], $3::text[], $4::bigint[])
+			ON CONFLICT (user_id, category, token) DO UPDATE SET count = category_model_counts.count + EXCLUDED.count`,
+		userID, categories, tokens, counts,
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to update category model counts in db")
+	}
+	return nil
+}
+
+func (r *Repository) AddLimitNotification(
+	ctx context.Context,
+	userID models.UserID,
+	period models.LimitPeriod, category models.ExpenseCategory,
+	periodStart time.Time,
+	threshold int,
+) (bool, error) {
+	res, err := r.db.Do(ctx).ExecContext(ctx, `
+			INSERT INTO limit_notifications (user_id, period, category, period_start, threshold)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT DO NOTHING`,
+		userID, period, category, periodStart.UTC(), threshold,
+	)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to add limit notification threshold=%d to db", threshold)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to add limit notification threshold=%d to db", threshold)
+	}
+	return affected != 0, nil
+}
@@ -12,24 +12,109 @@ import (
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
 )
 
-var ErrExpensesMonthlyLimitExcess = errors.New("expenses monthly limit exceeded")
+var (
+	ErrExpensesLimitExcess = errors.New("expenses limit exceeded")
+)
+
+// LimitExcessError lists the limits which would be exceeded by the expense, it matches ErrExpensesLimitExcess.
+// Spent amounts include the rejected expense.
+type LimitExcessError struct {
+	Exceeded []models.LimitStatus
+}
+
+func (e *LimitExcessError) Error() string {
+	descriptions := make([]string, 0, len(e.Exceeded))
+	for i := range e.Exceeded {
+		status := &e.Exceeded[i]
+		descriptions = append(descriptions, fmt.Sprintf("%s (spent %v of %v)", status.Name(), status.Spent.Round(2), status.Allowance().Round(2)))
+	}
+	return ErrExpensesLimitExcess.Error() + ": " + strings.Join(descriptions, ", ")
+}
+
+func (e *LimitExcessError) Is(target error) bool {
+	return target == ErrExpensesLimitExcess
+}
 
 type SummaryReport map[models.ExpenseCategory]decimal.Decimal
 
+// Text renders the tree of categories, children are indented under their parents,
+// whose amounts are subtotals including the children.
 func (r SummaryReport) Text() (string, error) {
-	if len(r) == 0 {
+	subtotals := r.Subtotals()
+	categories := make([]models.ExpenseCategory, 0, len(subtotals))
+	for category := range subtotals {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		return lessCategoryPath(categories[i], categories[j])
+	})
+
+	sb := new(strings.Builder)
+	for _, category := range categories {
+		indent := strings.Repeat("  ", category.Depth())
+		_, err := fmt.Fprintf(sb, "%s%s=%v\n", indent, category.Name(), subtotals[category])
+		if err != nil {
+			return "", err
+		}
+	}
+	return sb.String(), nil
+}
+
+// Subtotals returns the report with amounts of parent categories including amounts of their children.
+func (r SummaryReport) Subtotals() SummaryReport {
+	out := make(SummaryReport, len(r))
+	for category, amount := range r {
+		for ok := true; ok; category, ok = category.Parent() {
+			out[category] = out[category].Add(amount)
+		}
+	}
+	return out
+}
+
+// Within returns the part of the report with the parent category and its children.
+func (r SummaryReport) Within(parent models.ExpenseCategory) SummaryReport {
+	out := make(SummaryReport)
+	for category, amount := range r {
+		if category.IsWithin(parent) {
+			out[category] = amount
+		}
+	}
+	return out
+}
+
+// lessCategoryPath compares categories part by part, so children follow their parents.
+func lessCategoryPath(a, b models.ExpenseCategory) bool {
+	aParts := strings.Split(string(a), models.CategorySeparator)
+	bParts := strings.Split(string(b), models.CategorySeparator)
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		if aParts[i] != bParts[i] {
+			return aParts[i] < bParts[i]
+		}
+	}
+	return len(aParts) < len(bParts)
+}
+
+// TagsSummaryReport is the summary report grouped by tags, the expense with several tags is summed up in each of them.
+type TagsSummaryReport map[models.ExpenseTag]decimal.Decimal
+
+func (r TagsSummaryReport) Text() (string, error) {
+	return summaryText(r, "#")
+}
+
+// summaryText prints the amounts sorted by keys, one per line.
+func summaryText[K ~string](summary map[K]decimal.Decimal, keyPrefix string) (string, error) {
+	if len(summary) == 0 {
 		return "", nil
 	}
-	sortedKeys := make([]string, 0, len(r))
-	for category := range r {
-		sortedKeys = append(sortedKeys, string(category))
+	sortedKeys := make([]string, 0, len(summary))
+	for key := range summary {
+		sortedKeys = append(sortedKeys, string(key))
 	}
 	sort.Strings(sortedKeys)
 
 	sb := new(strings.Builder)
 	for _, key := range sortedKeys {
-		category := models.ExpenseCategory(key)
-		_, err := fmt.Fprintf(sb, "%s=%v\n", category, r[category])
+		_, err := fmt.Fprintf(sb, "%s%s=%v\n", keyPrefix, key, summary[K(key)])
 		if err != nil {
 			return "", err
 		}
@@ -37,19 +122,86 @@ func (r SummaryReport) Text() (string, error) {
 	return sb.String(), nil
 }
 
+// ReportCacheKey identifies the cached summary report of the user. The user selected currency is a part of the key,
+// because the report currency defaults to it. Empty tag means the report of all expenses.
+type ReportCacheKey struct {
+	Since, Till  time.Time
+	Currency     models.CurrencyCode
+	UserCurrency models.CurrencyCode
+	Tag          models.ExpenseTag
+}
+
+// AuthorsSummaryReport splits the summary report by users who added the expenses, zero user ID means unknown author.
+type AuthorsSummaryReport map[models.UserID]SummaryReport
+
 type UseCase interface {
 	AddExpense(ctx context.Context, userID models.UserID, expense models.Expense) (models.Expense, error)
-	GetExpensesSummaryByCategorySince(ctx context.Context, userID models.UserID, since, till time.Time) (SummaryReport, error)
-	GetExpensesAscendSinceTill(ctx context.Context, userID models.UserID, since, till time.Time, max int) ([]models.Expense, error)
+	// AddExpenseFromMessage adds the expense and links the message to it atomically,
+	// expense.ErrMessageLinked is returned and nothing is added if the message is already linked.
+	AddExpenseFromMessage(ctx context.Context, userID models.UserID, expense models.Expense, msg models.MessageRef) (models.Expense, error)
+	// AddExpenseDeferringNotification adds the expense like AddExpense, but returns the limit notification instead of
+	// sending it, so the caller running its own isolated environment sends it by SendLimitNotification after the commit.
+	AddExpenseDeferringNotification(ctx context.Context, userID models.UserID, expense models.Expense) (models.Expense, string, error)
+	SendLimitNotification(ctx context.Context, userID models.UserID, notification string)
+	UpdateExpense(ctx context.Context, userID models.UserID, expense models.Expense) (models.Expense, error)
+	DeleteExpense(ctx context.Context, userID models.UserID, id models.ExpenseID) error
+	GetExpenseByID(ctx context.Context, userID models.UserID, id models.ExpenseID) (models.Expense, error)
+	// RefundExpense records the refund of the amount in the expense currency, the rest is refunded if it's zero.
+	RefundExpense(ctx context.Context, userID models.UserID, id models.ExpenseID, amount decimal.Decimal) (models.Expense, error)
+	GetExpenseIDByMessage(ctx context.Context, userID models.UserID, msg models.MessageRef) (models.ExpenseID, error)
+	// GetExpensesSummaryByCategorySince, GetExpensesSummaryByAuthorSince, GetExpensesSummaryByTagSince and
+	// GetExpensesAscendSinceTill return amounts in the curr currency, the user selected currency is used if it's empty.
+	// Only expenses with the tag are taken, if it's not empty.
+	GetExpensesSummaryByCategorySince(ctx context.Context, userID models.UserID, since, till time.Time, curr models.CurrencyCode, tag models.ExpenseTag) (SummaryReport, error)
+	GetExpensesSummaryByAuthorSince(ctx context.Context, userID models.UserID, since, till time.Time, curr models.CurrencyCode, tag models.ExpenseTag) (AuthorsSummaryReport, error)
+	GetExpensesSummaryByTagSince(ctx context.Context, userID models.UserID, since, till time.Time, curr models.CurrencyCode) (TagsSummaryReport, error)
+	GetExpensesAscendSinceTill(ctx context.Context, userID models.UserID, since, till time.Time, curr models.CurrencyCode, tag models.ExpenseTag, max int) ([]models.Expense, error)
+	// GetCategories returns categories of all the user expenses with their counts and totals in the user selected currency.
+	GetCategories(ctx context.Context, userID models.UserID) ([]models.CategoryStats, error)
+	// RenameCategory moves expenses of the category and its children to the new category, which must not exist.
+	// The case of from is ignored, ErrCategoryDoesNotExist is returned if there are no such expenses.
+	RenameCategory(ctx context.Context, userID models.UserID, from, to models.ExpenseCategory) error
+	// MergeCategory moves expenses of the category and its children to the other category, which may exist.
+	MergeCategory(ctx context.Context, userID models.UserID, from, to models.ExpenseCategory) error
+	GetCategoryAliases(ctx context.Context, userID models.UserID) (map[models.ExpenseCategory]models.ExpenseCategory, error)
+	// SetCategoryAlias makes the alias replaced with the category in added and updated expenses.
+	SetCategoryAlias(ctx context.Context, userID models.UserID, alias, category models.ExpenseCategory) error
+	DeleteCategoryAlias(ctx context.Context, userID models.UserID, alias models.ExpenseCategory) error
+	// GetCategoryRules returns the user category rules followed by the default ones in the order they're applied.
+	// Default rules have zero IDs.
+	GetCategoryRules(ctx context.Context, userID models.UserID) ([]models.CategoryRule, error)
+	AddCategoryRule(ctx context.Context, userID models.UserID, rule models.CategoryRule) (models.CategoryRule, error)
+	DeleteCategoryRule(ctx context.Context, userID models.UserID, id models.CategoryRuleID) error
+	// MatchCategoryRule returns the first rule matching the text, false is returned if there is no such rule.
+	MatchCategoryRule(ctx context.Context, userID models.UserID, text string) (models.CategoryRule, bool, error)
+	// SuggestCategories returns the categories the expense most likely belongs to according to the user history,
+	// the most likely one goes first. The category of the expense is ignored.
+	SuggestCategories(ctx context.Context, userID models.UserID, exp models.Expense) ([]models.ExpenseCategory, error)
+	// GetLimitsStatus returns the user limits with amounts spent in their current periods in the limits currencies.
+	GetLimitsStatus(ctx context.Context, userID models.UserID) ([]models.LimitStatus, error)
+	// GetLimitHistory returns statuses of the limit periods from the first one with the rollover till the current one,
+	// user.ErrLimitDoesNotExist is returned if there is no such limit.
+	GetLimitHistory(ctx context.Context, userID models.UserID, period models.LimitPeriod, category models.ExpenseCategory) ([]models.LimitStatus, error)
 }
 
 type ExtendedUseCase interface {
 	UseCase
-	SendGetExpensesSummaryByCategorySinceRequest(ctx context.Context, chatID int64, userID models.UserID, since, till time.Time) error
+	SendGetExpensesSummaryByCategorySinceRequest(
+		ctx context.Context,
+		chatID int64,
+		userID models.UserID,
+		since, till time.Time,
+		curr models.CurrencyCode,
+		tag models.ExpenseTag,
+	) error
+}
+
+type MessageSender interface {
+	SendMessage(chatID int64, message string) error
 }
 
 type ReportsCache interface {
-	AddToCache(ctx context.Context, userID models.UserID, since, till time.Time, report SummaryReport) error
-	GetFromCache(ctx context.Context, userID models.UserID, since, till time.Time) (SummaryReport, bool, error)
+	AddToCache(ctx context.Context, userID models.UserID, key ReportCacheKey, report SummaryReport) error
+	GetFromCache(ctx context.Context, userID models.UserID, key ReportCacheKey) (SummaryReport, bool, error)
 	DropCacheForUserID(ctx context.Context, userID models.UserID) error
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/kafka"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
@@ -30,15 +31,157 @@ func (u *ExtendedUseCase) AddExpense(ctx context.Context, userID models.UserID,
 	return u.uc.AddExpense(ctx, userID, expense)
 }
 
-func (u *ExtendedUseCase) GetExpensesSummaryByCategorySince(ctx context.Context, userID models.UserID, since, till time.Time) (expense.SummaryReport, error) {
-	return u.uc.GetExpensesSummaryByCategorySince(ctx, userID, since, till)
+func (u *ExtendedUseCase) AddExpenseFromMessage(
+	ctx context.Context,
+	userID models.UserID,
+	expense models.Expense,
+	msg models.MessageRef,
+) (models.Expense, error) {
+	return u.uc.AddExpenseFromMessage(ctx, userID, expense, msg)
 }
 
-func (u *ExtendedUseCase) GetExpensesAscendSinceTill(ctx context.Context, userID models.UserID, since, till time.Time, max int) ([]models.Expense, error) {
-	return u.uc.GetExpensesAscendSinceTill(ctx, userID, since, till, max)
+func (u *ExtendedUseCase) AddExpenseDeferringNotification(
+	ctx context.Context,
+	userID models.UserID,
+	expense models.Expense,
+) (models.Expense, string, error) {
+	return u.uc.AddExpenseDeferringNotification(ctx, userID, expense)
 }
 
-func (u *ExtendedUseCase) SendGetExpensesSummaryByCategorySinceRequest(ctx context.Context, chatID int64, userID models.UserID, since, till time.Time) (err error) {
+func (u *ExtendedUseCase) SendLimitNotification(ctx context.Context, userID models.UserID, notification string) {
+	u.uc.SendLimitNotification(ctx, userID, notification)
+}
+
+func (u *ExtendedUseCase) UpdateExpense(ctx context.Context, userID models.UserID, expense models.Expense) (models.Expense, error) {
+	return u.uc.UpdateExpense(ctx, userID, expense)
+}
+
+func (u *ExtendedUseCase) DeleteExpense(ctx context.Context, userID models.UserID, id models.ExpenseID) error {
+	return u.uc.DeleteExpense(ctx, userID, id)
+}
+
+func (u *ExtendedUseCase) GetExpenseByID(ctx context.Context, userID models.UserID, id models.ExpenseID) (models.Expense, error) {
+	return u.uc.GetExpenseByID(ctx, userID, id)
+}
+
+func (u *ExtendedUseCase) RefundExpense(
+	ctx context.Context,
+	userID models.UserID,
+	id models.ExpenseID,
+	amount decimal.Decimal,
+) (models.Expense, error) {
+	return u.uc.RefundExpense(ctx, userID, id, amount)
+}
+
+func (u *ExtendedUseCase) GetExpenseIDByMessage(ctx context.Context, userID models.UserID, msg models.MessageRef) (models.ExpenseID, error) {
+	return u.uc.GetExpenseIDByMessage(ctx, userID, msg)
+}
+
+func (u *ExtendedUseCase) GetExpensesSummaryByCategorySince(
+	ctx context.Context,
+	userID models.UserID,
+	since, till time.Time,
+	curr models.CurrencyCode,
+	tag models.ExpenseTag,
+) (expense.SummaryReport, error) {
+	return u.uc.GetExpensesSummaryByCategorySince(ctx, userID, since, till, curr, tag)
+}
+
+func (u *ExtendedUseCase) GetExpensesSummaryByAuthorSince(
+	ctx context.Context,
+	userID models.UserID,
+	since, till time.Time,
+	curr models.CurrencyCode,
+	tag models.ExpenseTag,
+) (expense.AuthorsSummaryReport, error) {
+	return u.uc.GetExpensesSummaryByAuthorSince(ctx, userID, since, till, curr, tag)
+}
+
+func (u *ExtendedUseCase) GetExpensesSummaryByTagSince(
+	ctx context.Context,
+	userID models.UserID,
+	since, till time.Time,
+	curr models.CurrencyCode,
+) (expense.TagsSummaryReport, error) {
+	return u.uc.GetExpensesSummaryByTagSince(ctx, userID, since, till, curr)
+}
+
+func (u *ExtendedUseCase) GetExpensesAscendSinceTill(
+	ctx context.Context,
+	userID models.UserID,
+	since, till time.Time,
+	curr models.CurrencyCode,
+	tag models.ExpenseTag,
+	max int,
+) ([]models.Expense, error) {
+	return u.uc.GetExpensesAscendSinceTill(ctx, userID, since, till, curr, tag, max)
+}
+
+func (u *ExtendedUseCase) GetCategories(ctx context.Context, userID models.UserID) ([]models.CategoryStats, error) {
+	return u.uc.GetCategories(ctx, userID)
+}
+
+func (u *ExtendedUseCase) RenameCategory(ctx context.Context, userID models.UserID, from, to models.ExpenseCategory) error {
+	return u.uc.RenameCategory(ctx, userID, from, to)
+}
+
+func (u *ExtendedUseCase) MergeCategory(ctx context.Context, userID models.UserID, from, to models.ExpenseCategory) error {
+	return u.uc.MergeCategory(ctx, userID, from, to)
+}
+
+func (u *ExtendedUseCase) GetCategoryAliases(ctx context.Context, userID models.UserID) (map[models.ExpenseCategory]models.ExpenseCategory, error) {
+	return u.uc.GetCategoryAliases(ctx, userID)
+}
+
+func (u *ExtendedUseCase) SetCategoryAlias(ctx context.Context, userID models.UserID, alias, category models.ExpenseCategory) error {
+	return u.uc.SetCategoryAlias(ctx, userID, alias, category)
+}
+
+func (u *ExtendedUseCase) DeleteCategoryAlias(ctx context.Context, userID models.UserID, alias models.ExpenseCategory) error {
+	return u.uc.DeleteCategoryAlias(ctx, userID, alias)
+}
+
+func (u *ExtendedUseCase) GetCategoryRules(ctx context.Context, userID models.UserID) ([]models.CategoryRule, error) {
+	return u.uc.GetCategoryRules(ctx, userID)
+}
+
+func (u *ExtendedUseCase) AddCategoryRule(ctx context.Context, userID models.UserID, rule models.CategoryRule) (models.CategoryRule, error) {
+	return u.uc.AddCategoryRule(ctx, userID, rule)
+}
+
+func (u *ExtendedUseCase) DeleteCategoryRule(ctx context.Context, userID models.UserID, id models.CategoryRuleID) error {
+	return u.uc.DeleteCategoryRule(ctx, userID, id)
+}
+
+func (u *ExtendedUseCase) MatchCategoryRule(ctx context.Context, userID models.UserID, text string) (models.CategoryRule, bool, error) {
+	return u.uc.MatchCategoryRule(ctx, userID, text)
+}
+
+func (u *ExtendedUseCase) SuggestCategories(ctx context.Context, userID models.UserID, exp models.Expense) ([]models.ExpenseCategory, error) {
+	return u.uc.SuggestCategories(ctx, userID, exp)
+}
+
+func (u *ExtendedUseCase) GetLimitHistory(
+	ctx context.Context,
+	userID models.UserID,
+	period models.LimitPeriod,
+	category models.ExpenseCategory,
+) ([]models.LimitStatus, error) {
+	return u.uc.GetLimitHistory(ctx, userID, period, category)
+}
+
+func (u *ExtendedUseCase) GetLimitsStatus(ctx context.Context, userID models.UserID) ([]models.LimitStatus, error) {
+	return u.uc.GetLimitsStatus(ctx, userID)
+}
+
+func (u *ExtendedUseCase) SendGetExpensesSummaryByCategorySinceRequest(
+	ctx context.Context,
+	chatID int64,
+	userID models.UserID,
+	since, till time.Time,
+	curr models.CurrencyCode,
+	tag models.ExpenseTag,
+) (err error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "SendGetExpensesSummaryByCategorySinceRequest")
 	defer func() {
 		ext.Error.Set(span, err != nil)
@@ -48,12 +191,16 @@ func (u *ExtendedUseCase) SendGetExpensesSummaryByCategorySinceRequest(ctx conte
 	span.SetTag(chatIDSpanTagKey, chatID)
 	span.SetTag(sinceUnixMillisSpanTagKey, since.UnixMilli())
 	span.SetTag(tillUnixMillisSpanTagKey, till.UnixMilli())
+	span.SetTag(currencyCodeSpanTagKey, curr)
+	span.SetTag(expenseTagSpanTagKey, tag)
 
 	event := expense.EventGenerateSummaryReportByCategories{
-		ChatID: chatID,
-		UserID: userID,
-		Since:  since,
-		Till:   till,
+		ChatID:   chatID,
+		UserID:   userID,
+		Since:    since,
+		Till:     till,
+		Currency: curr,
+		Tag:      tag,
 	}
 	data, err := event.MarshalBinary()
 	if err != nil {
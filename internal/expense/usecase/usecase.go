@@ -2,6 +2,9 @@ package usecase
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
@@ -21,23 +24,35 @@ const (
 	dateUnixMillisSpanTagKey    = "date_unix_ms"
 	handlerCallsCountSpanTagKey = "handler_call_count"
 	currencyCodeSpanTagKey      = "currency_code"
+	expenseIDSpanTagKey         = "expense_id"
+	messageIDSpanTagKey         = "message_id"
+	limitPeriodSpanTagKey       = "limit_period"
+	categorySpanTagKey          = "category"
+	expenseTagSpanTagKey        = "expense_tag"
 )
 
+var defaultLimitThresholds = []int{50, 80, 100}
+
+const maxLimitThreshold = 1000
+
 type UseCase struct {
-	baseCurrency models.CurrencyCode
-	expRepo      expense.Repository
-	userRepo     user.Repository
-	exrateRepo   exrate.Repository
-	reportsCache expense.ReportsCache
+	baseCurrency    models.CurrencyCode
+	expRepo         expense.Repository
+	userRepo        user.Settings
+	exrateRepo      exrate.Repository
+	reportsCache    expense.ReportsCache
+	limitSender     expense.MessageSender
+	limitThresholds []int
+	defaultRules    []models.CategoryRule
 }
 
-func New(baseCurrency models.CurrencyCode, expRepo expense.Repository, userRepo user.Repository, exrateRepo exrate.Repository) (*UseCase, error) {
+func New(baseCurrency models.CurrencyCode, expRepo expense.Repository, userRepo user.Settings, exrateRepo exrate.Repository) (*UseCase, error) {
 	return NewWithCache(baseCurrency, expRepo, userRepo, exrateRepo, nil)
 }
 
 func NewWithCache(
 	baseCurrency models.CurrencyCode,
-	expRepo expense.Repository, userRepo user.Repository, exrateRepo exrate.Repository,
+	expRepo expense.Repository, userRepo user.Settings, exrateRepo exrate.Repository,
 	reportsCache expense.ReportsCache,
 ) (*UseCase, error) {
 	if reportsCache == nil {
@@ -52,6 +67,41 @@ func NewWithCache(
 	}, nil
 }
 
+// EnableLimitNotifications turns on notifications about reached thresholds of the limits and
+// about limits exceeded in the soft mode. Thresholds are in percents of the limit, each of them is notified
+// at most once per limit period. If thresholds are empty, defaultLimitThresholds are used.
+func (u *UseCase) EnableLimitNotifications(sender expense.MessageSender, thresholds []int) error {
+	if len(thresholds) == 0 {
+		thresholds = defaultLimitThresholds
+	}
+	sorted := make([]int, len(thresholds))
+	copy(sorted, thresholds)
+	sort.Ints(sorted)
+	for _, threshold := range sorted {
+		if threshold <= 0 || threshold > maxLimitThreshold {
+			return errors.Errorf("limit threshold %d%% is out of range", threshold)
+		}
+	}
+	u.limitSender = sender
+	u.limitThresholds = sorted
+	return nil
+}
+
+// SetDefaultCategoryRules sets the rules applied to expenses of all users after their own rules.
+func (u *UseCase) SetDefaultCategoryRules(rules []models.CategoryRule) error {
+	defaults := make([]models.CategoryRule, len(rules))
+	for i, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return errors.Wrapf(err, "default category rule %s is invalid", rule)
+		}
+		// default rules have no IDs to tell them from the user ones
+		rule.ID, rule.Category = 0, rule.Category.Normalize()
+		defaults[i] = rule
+	}
+	u.defaultRules = defaults
+	return nil
+}
+
 func (u *UseCase) AddExpense(ctx context.Context, userID models.UserID, exp models.Expense) (_ models.Expense, err error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "AddExpense")
 	defer func() {
@@ -59,6 +109,64 @@ func (u *UseCase) AddExpense(ctx context.Context, userID models.UserID, exp mode
 		span.Finish()
 	}()
 	span.SetTag(userIDSpanTagKey, userID)
+
+	out, notification, err := u.addExpense(ctx, userID, exp, nil)
+	if err != nil {
+		return models.Expense{}, err
+	}
+	u.notifyUser(span, userID, notification)
+	return out, nil
+}
+
+func (u *UseCase) AddExpenseDeferringNotification(
+	ctx context.Context,
+	userID models.UserID,
+	exp models.Expense,
+) (_ models.Expense, _ string, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "AddExpenseDeferringNotification")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+
+	return u.addExpense(ctx, userID, exp, nil)
+}
+
+func (u *UseCase) SendLimitNotification(ctx context.Context, userID models.UserID, notification string) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "SendLimitNotification")
+	defer span.Finish()
+	span.SetTag(userIDSpanTagKey, userID)
+
+	u.notifyUser(span, userID, notification)
+}
+
+func (u *UseCase) AddExpenseFromMessage(ctx context.Context, userID models.UserID, exp models.Expense, msg models.MessageRef) (_ models.Expense, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "AddExpenseFromMessage")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+	span.SetTag(chatIDSpanTagKey, msg.ChatID)
+	span.SetTag(messageIDSpanTagKey, msg.MessageID)
+
+	out, notification, err := u.addExpense(ctx, userID, exp, &msg)
+	if err != nil {
+		return models.Expense{}, err
+	}
+	u.notifyUser(span, userID, notification)
+	return out, nil
+}
+
+// addExpense adds the expense and links the message to it in the same isolated environment, if the message is not nil.
+// It returns the limit notification to be sent to the user.
+func (u *UseCase) addExpense(
+	ctx context.Context,
+	userID models.UserID,
+	exp models.Expense,
+	msg *models.MessageRef,
+) (_ models.Expense, _ string, err error) {
 	defer func() {
 		if err != nil {
 			return
@@ -66,28 +174,182 @@ func (u *UseCase) AddExpense(ctx context.Context, userID models.UserID, exp mode
 		err = u.reportsCache.DropCacheForUserID(ctx, userID)
 	}()
 
-	if err := exp.Validate(); err != nil {
-		return models.Expense{}, errors.Wrap(err, "expense validation failed")
+	exp, err = u.prepareExpense(ctx, userID, exp)
+	if err != nil {
+		return models.Expense{}, "", err
+	}
+	period, err := u.getUserPeriod(ctx, userID)
+	if err != nil {
+		return models.Expense{}, "", err
+	}
+	var (
+		out          models.Expense
+		notification string
+	)
+	err = u.expRepo.Isolated(ctx, func(ctx context.Context) (err error) {
+		span, ctx := opentracing.StartSpanFromContext(ctx, "expRepo.Isolated")
+		defer func() {
+			ext.Error.Set(span, err != nil)
+			span.Finish()
+		}()
+		span.SetTag(userIDSpanTagKey, userID)
+
+		check, err := u.checkLimits(ctx, userID, exp, period, nil)
+		if err != nil {
+			return err
+		}
+		out, err = u.expRepo.AddExpense(ctx, userID, exp)
+		if err != nil {
+			return errors.Wrap(err, "failed to add expense to expenses repository")
+		}
+		if msg != nil {
+			if err := u.expRepo.LinkMessageToExpense(ctx, userID, *msg, out.ID); err != nil {
+				return errors.Wrapf(err, "failed to link messageID=%d to expenseID=%d", msg.MessageID, out.ID)
+			}
+		}
+		if err := u.learnCategory(ctx, userID, &out); err != nil {
+			return err
+		}
+		notification, err = u.makeLimitNotification(ctx, userID, check)
+		return err
+	})
+	if err != nil {
+		return models.Expense{}, "", errors.Wrapf(err, "error occured in expenses repo isolated environment")
+	}
+	return out, notification, nil
+}
+
+func (u *UseCase) UpdateExpense(ctx context.Context, userID models.UserID, exp models.Expense) (_ models.Expense, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UpdateExpense")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+	span.SetTag(expenseIDSpanTagKey, exp.ID)
+	defer func() {
+		if err != nil {
+			return
+		}
+		err = u.reportsCache.DropCacheForUserID(ctx, userID)
+	}()
+
+	exp, err = u.prepareExpense(ctx, userID, exp)
+	if err != nil {
+		return models.Expense{}, err
+	}
+	period, err := u.getUserPeriod(ctx, userID)
+	if err != nil {
+		return models.Expense{}, err
+	}
+	var (
+		out          models.Expense
+		notification string
+	)
+	err = u.expRepo.Isolated(ctx, func(ctx context.Context) (err error) {
+		span, ctx := opentracing.StartSpanFromContext(ctx, "expRepo.Isolated")
+		defer func() {
+			ext.Error.Set(span, err != nil)
+			span.Finish()
+		}()
+		span.SetTag(userIDSpanTagKey, userID)
+
+		old, err := u.expRepo.GetExpenseByID(ctx, userID, exp.ID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get expenseID=%d from expenses repository", exp.ID)
+		}
+		if exp.Amount.LessThan(old.Refunded) {
+			return expense.ErrRefundExceedsExpense
+		}
+		exp.Refunded = old.Refunded
+		check, err := u.checkLimits(ctx, userID, exp, period, &exp.ID)
+		if err != nil {
+			return err
+		}
+		out, err = u.expRepo.UpdateExpense(ctx, userID, exp)
+		if err != nil {
+			return errors.Wrapf(err, "failed to update expenseID=%d in expenses repository", exp.ID)
+		}
+		notification, err = u.makeLimitNotification(ctx, userID, check)
+		return err
+	})
+	if err != nil {
+		return models.Expense{}, errors.Wrapf(err, "error occured in expenses repo isolated environment")
+	}
+	u.notifyUser(span, userID, notification)
+	return out, nil
+}
+
+func (u *UseCase) DeleteExpense(ctx context.Context, userID models.UserID, id models.ExpenseID) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "DeleteExpense")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+	span.SetTag(expenseIDSpanTagKey, id)
+
+	if err := u.expRepo.DeleteExpense(ctx, userID, id); err != nil {
+		return errors.Wrapf(err, "failed to delete expenseID=%d from expenses repository", id)
+	}
+	return u.reportsCache.DropCacheForUserID(ctx, userID)
+}
+
+// GetExpenseByID returns the expense with amount converted to the user selected currency and date in the user time zone.
+func (u *UseCase) GetExpenseByID(ctx context.Context, userID models.UserID, id models.ExpenseID) (_ models.Expense, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "GetExpenseByID")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+	span.SetTag(expenseIDSpanTagKey, id)
+
+	exp, err := u.expRepo.GetExpenseByID(ctx, userID, id)
+	if err != nil {
+		return models.Expense{}, errors.Wrapf(err, "failed to get expenseID=%d from expenses repository", id)
 	}
 	curr, err := u.userRepo.GetUserCurrency(ctx, userID)
 	if err != nil {
 		return models.Expense{}, errors.Wrapf(err, "failed to get selected user currency by userID=%d", userID)
 	}
-	if curr != u.baseCurrency {
+	switch {
+	case exp.OriginalCurrency == curr:
+		exp.Amount, exp.Refunded = exp.OriginalAmount, exp.OriginalRefunded()
+	case curr != u.baseCurrency:
 		rate, err := u.exrateRepo.GetRate(ctx, curr, exp.Date)
 		if err != nil {
 			return models.Expense{}, errors.Wrapf(err, "failed to get exchange rate for currency=%q at time=%v", curr, exp.Date)
 		}
-		exp.Amount = rate.ConvertToBase(exp.Amount)
+		exp.Amount, exp.Refunded = rate.ConvertFromBase(exp.Amount), rate.ConvertFromBase(exp.Refunded)
+	}
+	loc, err := u.getUserTimeZone(ctx, userID)
+	if err != nil {
+		return models.Expense{}, err
 	}
-	nowYear, nowMonth, _ := time.Now().UTC().Date()
-	expenseYear, expenseMonth, _ := exp.Date.UTC().Date()
-	// we don't check limit if expense month is not current month
-	if expenseMonth != nowMonth || expenseYear != nowYear {
-		return u.expRepo.AddExpense(ctx, userID, exp)
+	exp.Date = exp.Date.In(loc)
+	return exp, nil
+}
+
+// RefundExpense records the refund of the expense and returns the expense as GetExpenseByID does. The amount is
+// in the currency the expense was added in, the rest of the expense is refunded if the amount is zero.
+func (u *UseCase) RefundExpense(
+	ctx context.Context,
+	userID models.UserID,
+	id models.ExpenseID,
+	amount decimal.Decimal,
+) (_ models.Expense, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "RefundExpense")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+	span.SetTag(expenseIDSpanTagKey, id)
+
+	if amount.IsNegative() {
+		return models.Expense{}, models.ErrRefundAmountIsNotPositive
 	}
-	// expense happened in the current month
-	var out models.Expense
 	err = u.expRepo.Isolated(ctx, func(ctx context.Context) (err error) {
 		span, ctx := opentracing.StartSpanFromContext(ctx, "expRepo.Isolated")
 		defer func() {
@@ -96,107 +358,968 @@ func (u *UseCase) AddExpense(ctx context.Context, userID models.UserID, exp mode
 		}()
 		span.SetTag(userIDSpanTagKey, userID)
 
-		limit, err := u.userRepo.GetUserMonthlyLimit(ctx, userID)
+		exp, err := u.expRepo.GetExpenseByID(ctx, userID, id)
 		if err != nil {
-			return errors.Wrapf(err, "failed to get user montly limit by userID=%q", userID)
+			return errors.Wrapf(err, "failed to get expenseID=%d from expenses repository", id)
 		}
-		if limit != nil {
-			spentByMonth, err := u.getUserExpensesSumByMonth(ctx, userID, expenseYear, expenseMonth)
-			if err != nil {
-				return errors.Wrap(err, "failed to get user expenses sum by month")
-			}
-			newSum := spentByMonth.Add(exp.Amount)
-			if newSum.GreaterThan(*limit) {
-				return expense.ErrExpensesMonthlyLimitExcess
+		left := exp.NetAmount()
+		refund := models.Refund{ExpenseID: id, Amount: amount, Date: time.Now()}
+		switch {
+		case amount.IsZero():
+			refund.Amount = left
+		case !exp.Rate.IsZero():
+			// the expense rate is applied to refund exactly the typed part of the expense
+			rate := models.ExchangeRate{Rate: exp.Rate}
+			refund.Amount = rate.ConvertToBase(amount)
+		}
+		switch {
+		case !left.IsPositive():
+			return expense.ErrRefundExceedsExpense
+		case refund.Amount.GreaterThan(left):
+			// conversions may leave a tiny remainder, which isn't worth a separate refund
+			if refund.Amount.Round(2).GreaterThan(left.Round(2)) {
+				return expense.ErrRefundExceedsExpense
 			}
+			refund.Amount = left
 		}
-		out, err = u.expRepo.AddExpense(ctx, userID, exp)
-		if err != nil {
-			return errors.Wrap(err, "failed to add expense to expenses repository")
+		if _, err := u.expRepo.AddRefund(ctx, userID, refund); err != nil {
+			return errors.Wrapf(err, "failed to add refund of expenseID=%d to expenses repository", id)
 		}
 		return nil
 	})
 	if err != nil {
 		return models.Expense{}, errors.Wrapf(err, "error occured in expenses repo isolated environment")
 	}
-	return out, nil
+	if err := u.reportsCache.DropCacheForUserID(ctx, userID); err != nil {
+		return models.Expense{}, err
+	}
+	return u.GetExpenseByID(ctx, userID, id)
+}
+
+func (u *UseCase) GetExpenseIDByMessage(ctx context.Context, userID models.UserID, msg models.MessageRef) (_ models.ExpenseID, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "GetExpenseIDByMessage")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+	span.SetTag(chatIDSpanTagKey, msg.ChatID)
+	span.SetTag(messageIDSpanTagKey, msg.MessageID)
+
+	return u.expRepo.GetExpenseIDByMessage(ctx, userID, msg)
 }
 
-func (u *UseCase) GetExpensesSummaryByCategorySince(ctx context.Context, userID models.UserID, since, till time.Time) (expense.SummaryReport, error) {
-	cached, ok, err := u.reportsCache.GetFromCache(ctx, userID, since, till)
+// prepareExpense validates the expense and converts its amount to the base currency at the rate of the expense day.
+// The original amount is converted if the original currency is set, otherwise the amount in the user selected currency.
+// The typed amount, its currency and the applied rate are kept in the expense. Tags are taken from the comment.
+// The category is normalized and replaced according to the user aliases.
+func (u *UseCase) prepareExpense(ctx context.Context, userID models.UserID, exp models.Expense) (models.Expense, error) {
+	if err := exp.Validate(); err != nil {
+		return models.Expense{}, errors.Wrap(err, "expense validation failed")
+	}
+	exp.Tags = models.ParseTags(exp.Comment)
+	category, err := u.resolveCategory(ctx, userID, exp.Category)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get report from cache")
+		return models.Expense{}, err
 	}
-	if ok {
-		return cached, nil
+	exp.Category = category
+	return u.convertToBase(ctx, userID, exp)
+}
+
+// convertToBase converts the amount of the expense to the base currency keeping the typed amount and currency.
+func (u *UseCase) convertToBase(ctx context.Context, userID models.UserID, exp models.Expense) (models.Expense, error) {
+	if !exp.HasOriginal() {
+		curr, err := u.userRepo.GetUserCurrency(ctx, userID)
+		if err != nil {
+			return models.Expense{}, errors.Wrapf(err, "failed to get selected user currency by userID=%d", userID)
+		}
+		exp.OriginalAmount, exp.OriginalCurrency = exp.Amount, curr
 	}
-	out := make(expense.SummaryReport)
-	err = u.handleExpensesAscendSinceTill(ctx, userID, since, till, func(expense *models.Expense) bool {
-		categoryAmount := out[expense.Category]
-		out[expense.Category] = categoryAmount.Add(expense.Amount)
-		return true
-	})
+	curr := exp.OriginalCurrency
+	exp.Amount, exp.Rate = exp.OriginalAmount, decimal.NewFromInt(1)
+	if curr != u.baseCurrency {
+		rate, err := u.exrateRepo.GetRate(ctx, curr, exp.Date)
+		if err != nil {
+			return models.Expense{}, errors.Wrapf(err, "failed to get exchange rate for currency=%q at time=%v", curr, exp.Date)
+		}
+		exp.Amount, exp.Rate = rate.ConvertToBase(exp.Amount), rate.Rate
+	}
+	return exp, nil
+}
+
+// resolveCategory normalizes the category and applies the alias of the category or of its closest parent.
+func (u *UseCase) resolveCategory(ctx context.Context, userID models.UserID, category models.ExpenseCategory) (models.ExpenseCategory, error) {
+	category = category.Normalize()
+	aliases, err := u.expRepo.GetCategoryAliases(ctx, userID)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to iterate through expenses of userID=%d and split by categories", userID)
+		return "", errors.Wrapf(err, "failed to get category aliases of userID=%d", userID)
 	}
-	if err := u.reportsCache.AddToCache(ctx, userID, since, till, out); err != nil {
-		return nil, errors.Wrap(err, "failed to set report to cache")
+	for parent, ok := category, true; ok; parent, ok = parent.Parent() {
+		if target, isAlias := aliases[parent]; isAlias {
+			category, _ = category.Rebase(parent, target)
+			break
+		}
 	}
-	return out, nil
+	return category, nil
 }
 
-func (u *UseCase) GetExpensesAscendSinceTill(ctx context.Context, userID models.UserID, since, till time.Time, max int) ([]models.Expense, error) {
-	var out []models.Expense
-	err := u.handleExpensesAscendSinceTill(ctx, userID, since, till, func(expense *models.Expense) bool {
-		out = append(out, *expense)
-		return len(out) < max
-	})
+func (u *UseCase) getUserTimeZone(ctx context.Context, userID models.UserID) (*time.Location, error) {
+	loc, err := u.userRepo.GetUserTimeZone(ctx, userID)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to iterate through expenses of userID=%d", userID)
+		return nil, errors.Wrapf(err, "failed to get user time zone by userID=%d", userID)
 	}
-	return out, nil
+	return loc, nil
+}
+
+// userPeriod describes the user time zone and budget period start day, which define the limit periods.
+type userPeriod struct {
+	loc      *time.Location
+	startDay int
+}
+
+func (u *UseCase) getUserPeriod(ctx context.Context, userID models.UserID) (userPeriod, error) {
+	loc, err := u.getUserTimeZone(ctx, userID)
+	if err != nil {
+		return userPeriod{}, err
+	}
+	startDay, err := u.userRepo.GetUserPeriodStartDay(ctx, userID)
+	if err != nil {
+		return userPeriod{}, errors.Wrapf(err, "failed to get user period start day by userID=%d", userID)
+	}
+	return userPeriod{loc: loc, startDay: startDay}, nil
+}
+
+// bounds returns the first and the last instants of the limit period containing t.
+func (p userPeriod) bounds(period models.LimitPeriod, t time.Time) (since, till time.Time) {
+	return period.Bounds(t.In(p.loc), p.startDay)
+}
+
+// limitUsage describes the limit and the amount spent in its period in the limit currency.
+type limitUsage struct {
+	status   models.LimitStatus
+	currency models.CurrencyCode // the limit currency with the base one used by default
+}
+
+func (l *limitUsage) String() string {
+	return fmt.Sprintf("spent %v of %v %s", l.status.Spent.Round(2), l.status.Allowance().Round(2), l.currency)
+}
+
+func (l *limitUsage) isExceeded() bool {
+	return l.status.Spent.GreaterThan(l.status.Allowance())
+}
+
+// isReached reports whether the threshold in percents of the limit allowance is reached.
+func (l *limitUsage) isReached(threshold int) bool {
+	allowance := l.status.Allowance()
+	return l.status.Spent.Mul(decimal.NewFromInt(100)).GreaterThanOrEqual(allowance.Mul(decimal.NewFromInt(int64(threshold))))
+}
+
+// limitsCheck is the result of the expense check against the user limits.
+type limitsCheck struct {
+	usages   []limitUsage // limits applied to the expense, spent amounts include it
+	warnings []string     // limits exceeded in the soft mode
 }
 
-func (u *UseCase) getUserExpensesSumByMonth(ctx context.Context, userID models.UserID, year int, month time.Month) (decimal.Decimal, error) {
+// checkLimits checks all the user limits applied to the expense, it must be called inside expRepo.Isolated.
+// The limit is checked only if the expense belongs to its current period. The expense with replacedID is excluded
+// from the sums. Exceeded limits are returned as LimitExcessError in the hard mode and as warnings in the soft mode.
+func (u *UseCase) checkLimits(
+	ctx context.Context,
+	userID models.UserID,
+	exp models.Expense,
+	period userPeriod,
+	replacedID *models.ExpenseID,
+) (limitsCheck, error) {
+	limits, err := u.userRepo.GetUserLimits(ctx, userID)
+	if err != nil {
+		return limitsCheck{}, errors.Wrapf(err, "failed to get user limits by userID=%d", userID)
+	}
 	var (
-		since = time.Date(year, month, 0, 0, 0, 0, 0, time.UTC)
-		till  = since.AddDate(0, 1, 0).Add(-1 * time.Nanosecond)
-		sum   decimal.Decimal
+		check    limitsCheck
+		exceeded []limitUsage
+		now      = time.Now()
 	)
-	err := u.handleExpensesAscendSinceTill(ctx, userID, since, till, func(expense *models.Expense) bool {
-		sum = sum.Add(expense.Amount)
-		return true
-	})
+	for i := range limits {
+		limit := limits[i]
+		if !limit.AppliesTo(&exp) {
+			continue
+		}
+		if since, till := period.bounds(limit.Period, now); exp.Date.Before(since) || exp.Date.After(till) {
+			continue
+		}
+		usage, err := u.getLimitUsage(ctx, userID, limit, period, exp.Date, exp.NetAmount(), replacedID)
+		if err != nil {
+			return limitsCheck{}, err
+		}
+		check.usages = append(check.usages, usage)
+		if usage.isExceeded() {
+			exceeded = append(exceeded, usage)
+		}
+	}
+	if len(exceeded) == 0 {
+		return check, nil
+	}
+	mode, err := u.userRepo.GetUserLimitMode(ctx, userID)
 	if err != nil {
-		return decimal.Decimal{}, errors.Wrapf(err, "failed to get userID=%d expenses sum by %s", userID, since.Format("2006/January"))
+		return limitsCheck{}, errors.Wrapf(err, "failed to get user limit mode by userID=%d", userID)
 	}
-	return sum, nil
+	if mode != models.LimitModeSoft {
+		excessErr := &expense.LimitExcessError{Exceeded: make([]models.LimitStatus, 0, len(exceeded))}
+		for _, usage := range exceeded {
+			excessErr.Exceeded = append(excessErr.Exceeded, usage.status)
+		}
+		return limitsCheck{}, excessErr
+	}
+	for i := range exceeded {
+		check.warnings = append(check.warnings, fmt.Sprintf("Exceeded %s: %s.", exceeded[i].status.Name(), &exceeded[i]))
+	}
+	return check, nil
 }
 
-func (u *UseCase) handleExpensesAscendSinceTill(ctx context.Context, userID models.UserID, since, till time.Time, handler func(expense *models.Expense) bool) (err error) {
-	var handlerCalls int
-	span, ctx := opentracing.StartSpanFromContext(ctx, "handleExpensesAscendSinceTill")
+// getLimitUsage returns the usage of the limit in its period containing t. The added amount in the base currency
+// is included in the sum and the expense with replacedID is excluded from it.
+func (u *UseCase) getLimitUsage(
+	ctx context.Context,
+	userID models.UserID,
+	limit models.Limit,
+	period userPeriod,
+	t time.Time,
+	added decimal.Decimal,
+	replacedID *models.ExpenseID,
+) (limitUsage, error) {
+	history, currency, err := u.getLimitHistory(ctx, userID, limit, period, t, added, replacedID)
+	if err != nil {
+		return limitUsage{}, err
+	}
+	return limitUsage{status: history[len(history)-1], currency: currency}, nil
+}
+
+// getLimitHistory returns statuses of the limit periods from the first rollover period till the one containing t,
+// only the last period is returned if the limit has no rollover. The added amount in the base currency is included
+// in the last period sum and the expense with replacedID is excluded from all the sums. Statuses are returned
+// in the limit currency, which is returned as well.
+func (u *UseCase) getLimitHistory(
+	ctx context.Context,
+	userID models.UserID,
+	limit models.Limit,
+	period userPeriod,
+	t time.Time,
+	added decimal.Decimal,
+	replacedID *models.ExpenseID,
+) ([]models.LimitStatus, models.CurrencyCode, error) {
+	currency := limit.Currency
+	if currency == "" {
+		currency = u.baseCurrency
+	}
+	convert := func(amount decimal.Decimal) decimal.Decimal { return amount }
+	if currency != u.baseCurrency {
+		// spent amounts are converted instead of the limit to keep the limit as the user set it
+		rate, err := u.exrateRepo.GetRate(ctx, currency, time.Now())
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "failed to get exchange rate for currency=%q", currency)
+		}
+		convert = rate.ConvertFromBase
+	}
+	last, _ := period.bounds(limit.Period, t)
+	start := last
+	if limit.Rollover != nil && limit.Rollover.Since.Before(last) {
+		start, _ = period.bounds(limit.Period, limit.Rollover.Since)
+	}
+	var out []models.LimitStatus
+	for !start.After(last) {
+		_, end := period.bounds(limit.Period, start)
+		out = append(out, models.LimitStatus{Limit: limit, Start: start, End: end})
+		start = end.Add(time.Nanosecond)
+	}
+	if err := u.sumUserExpensesByPeriods(ctx, userID, out, limit.Category, replacedID); err != nil {
+		return nil, "", errors.Wrapf(err, "failed to get user expenses sum by %s", limit.Name())
+	}
+	out[len(out)-1].Spent = out[len(out)-1].Spent.Add(added)
+	var carried decimal.Decimal
+	for i := range out {
+		out[i].Carried, out[i].Spent = carried, convert(out[i].Spent)
+		if limit.Rollover != nil {
+			carried = limit.Rollover.Carry(out[i].Remaining())
+		}
+	}
+	return out, currency, nil
+}
+
+// makeLimitNotification must be called inside expRepo.Isolated after the expense is saved.
+// It marks reached thresholds of the limits as notified and returns the text to send to the user.
+func (u *UseCase) makeLimitNotification(ctx context.Context, userID models.UserID, check limitsCheck) (string, error) {
+	if u.limitSender == nil {
+		return "", nil
+	}
+	var lines []string
+	for i := range check.usages {
+		usage := &check.usages[i]
+		var reached int
+		for _, threshold := range u.limitThresholds {
+			if !usage.isReached(threshold) {
+				break
+			}
+			added, err := u.expRepo.AddLimitNotification(
+				ctx, userID, usage.status.Period, usage.status.Category, usage.status.Start, threshold,
+			)
+			if err != nil {
+				return "", errors.Wrapf(err, "failed to add limit notification for userID=%d", userID)
+			}
+			if added {
+				reached = threshold
+			}
+		}
+		// only the highest of thresholds reached at once is notified
+		if reached != 0 {
+			lines = append(lines, fmt.Sprintf("You have reached %d%% of your %s: %s.", reached, usage.status.Name(), usage))
+		}
+	}
+	lines = append(lines, check.warnings...)
+	return strings.Join(lines, "\n"), nil
+}
+
+// notifyUser sends the notification to the user private chat. The expense is already saved,
+// so the failure is only logged to the span.
+func (u *UseCase) notifyUser(span opentracing.Span, userID models.UserID, notification string) {
+	if notification == "" {
+		return
+	}
+	chatID := int64(userID) // private chat ID equals to the user ID
+	if err := u.limitSender.SendMessage(chatID, notification); err != nil {
+		ext.LogError(span, errors.Wrapf(err, "failed to send limit notification to userID=%d", userID))
+	}
+}
+
+func (u *UseCase) GetLimitsStatus(ctx context.Context, userID models.UserID) (_ []models.LimitStatus, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "GetLimitsStatus")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+
+	limits, err := u.userRepo.GetUserLimits(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get user limits by userID=%d", userID)
+	}
+	if len(limits) == 0 {
+		return nil, nil
+	}
+	period, err := u.getUserPeriod(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	out := make([]models.LimitStatus, 0, len(limits))
+	for _, limit := range limits {
+		usage, err := u.getLimitUsage(ctx, userID, limit, period, now, decimal.Zero, nil)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, usage.status)
+	}
+	return out, nil
+}
+
+// GetExpensesSummaryByCategorySince builds the report for days from since till till inclusive, days are taken in the user time zone.
+func (u *UseCase) GetExpensesSummaryByCategorySince(
+	ctx context.Context,
+	userID models.UserID,
+	since, till time.Time,
+	curr models.CurrencyCode,
+	tag models.ExpenseTag,
+) (expense.SummaryReport, error) {
+	loc, err := u.getUserTimeZone(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	since, till = models.StartOfDay(since.In(loc)), models.EndOfDay(till.In(loc))
+	userCurr, err := u.userRepo.GetUserCurrency(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get selected user currency by userID=%d", userID)
+	}
+	if curr == "" {
+		curr = userCurr
+	}
+	key := expense.ReportCacheKey{Since: since, Till: till, Currency: curr, UserCurrency: userCurr, Tag: tag}
+	cached, ok, err := u.reportsCache.GetFromCache(ctx, userID, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get report from cache")
+	}
+	if ok {
+		return cached, nil
+	}
+	out := make(expense.SummaryReport)
+	err = u.handleExpensesAscendSinceTill(ctx, userID, since, till, loc, curr, func(expense *models.Expense) bool {
+		if !expense.HasTag(tag) {
+			return true
+		}
+		categoryAmount := out[expense.Category]
+		out[expense.Category] = categoryAmount.Add(expense.NetAmount())
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to iterate through expenses of userID=%d and split by categories", userID)
+	}
+	if err := u.reportsCache.AddToCache(ctx, userID, key, out); err != nil {
+		return nil, errors.Wrap(err, "failed to set report to cache")
+	}
+	return out, nil
+}
+
+// GetExpensesSummaryByAuthorSince builds the report split by authors for days from since till till inclusive,
+// days are taken in the user time zone.
+func (u *UseCase) GetExpensesSummaryByAuthorSince(
+	ctx context.Context,
+	userID models.UserID,
+	since, till time.Time,
+	curr models.CurrencyCode,
+	tag models.ExpenseTag,
+) (expense.AuthorsSummaryReport, error) {
+	loc, err := u.getUserTimeZone(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if curr, err = u.getReportCurrency(ctx, userID, curr); err != nil {
+		return nil, err
+	}
+	since, till = models.StartOfDay(since.In(loc)), models.EndOfDay(till.In(loc))
+	out := make(expense.AuthorsSummaryReport)
+	err = u.handleExpensesAscendSinceTill(ctx, userID, since, till, loc, curr, func(exp *models.Expense) bool {
+		if !exp.HasTag(tag) {
+			return true
+		}
+		report, ok := out[exp.AuthorID]
+		if !ok {
+			report = make(expense.SummaryReport)
+			out[exp.AuthorID] = report
+		}
+		report[exp.Category] = report[exp.Category].Add(exp.NetAmount())
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to iterate through expenses of userID=%d and split by authors", userID)
+	}
+	return out, nil
+}
+
+// GetExpensesSummaryByTagSince builds the report split by tags for days from since till till inclusive,
+// days are taken in the user time zone. Expenses without tags are skipped.
+func (u *UseCase) GetExpensesSummaryByTagSince(
+	ctx context.Context,
+	userID models.UserID,
+	since, till time.Time,
+	curr models.CurrencyCode,
+) (expense.TagsSummaryReport, error) {
+	loc, err := u.getUserTimeZone(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if curr, err = u.getReportCurrency(ctx, userID, curr); err != nil {
+		return nil, err
+	}
+	since, till = models.StartOfDay(since.In(loc)), models.EndOfDay(till.In(loc))
+	out := make(expense.TagsSummaryReport)
+	err = u.handleExpensesAscendSinceTill(ctx, userID, since, till, loc, curr, func(exp *models.Expense) bool {
+		for _, tag := range exp.Tags {
+			out[tag] = out[tag].Add(exp.NetAmount())
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to iterate through expenses of userID=%d and split by tags", userID)
+	}
+	return out, nil
+}
+
+// GetExpensesAscendSinceTill returns expenses for days from since till till inclusive, days are taken in the user time zone.
+func (u *UseCase) GetExpensesAscendSinceTill(
+	ctx context.Context,
+	userID models.UserID,
+	since, till time.Time,
+	curr models.CurrencyCode,
+	tag models.ExpenseTag,
+	max int,
+) ([]models.Expense, error) {
+	loc, err := u.getUserTimeZone(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if curr, err = u.getReportCurrency(ctx, userID, curr); err != nil {
+		return nil, err
+	}
+	since, till = models.StartOfDay(since.In(loc)), models.EndOfDay(till.In(loc))
+	var out []models.Expense
+	err = u.handleExpensesAscendSinceTill(ctx, userID, since, till, loc, curr, func(expense *models.Expense) bool {
+		if !expense.HasTag(tag) {
+			return true
+		}
+		out = append(out, *expense)
+		return len(out) < max
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to iterate through expenses of userID=%d", userID)
+	}
+	return out, nil
+}
+
+func (u *UseCase) GetLimitHistory(
+	ctx context.Context,
+	userID models.UserID,
+	limitPeriod models.LimitPeriod,
+	category models.ExpenseCategory,
+) (_ []models.LimitStatus, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "GetLimitHistory")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+	span.SetTag(limitPeriodSpanTagKey, limitPeriod)
+	span.SetTag(categorySpanTagKey, category)
+
+	limits, err := u.userRepo.GetUserLimits(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get user limits by userID=%d", userID)
+	}
+	for _, limit := range limits {
+		if limit.Period != limitPeriod || limit.Category != category {
+			continue
+		}
+		period, err := u.getUserPeriod(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		history, _, err := u.getLimitHistory(ctx, userID, limit, period, time.Now(), decimal.Zero, nil)
+		return history, err
+	}
+	return nil, user.ErrLimitDoesNotExist
+}
+
+func (u *UseCase) GetCategoryRules(ctx context.Context, userID models.UserID) (_ []models.CategoryRule, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "GetCategoryRules")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+
+	rules, err := u.expRepo.GetCategoryRules(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get category rules of userID=%d", userID)
+	}
+	return append(rules, u.defaultRules...), nil
+}
+
+func (u *UseCase) AddCategoryRule(ctx context.Context, userID models.UserID, rule models.CategoryRule) (_ models.CategoryRule, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "AddCategoryRule")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+	span.SetTag(categorySpanTagKey, rule.Category)
+
+	rule.Category = rule.Category.Normalize()
+	if err := rule.Validate(); err != nil {
+		return models.CategoryRule{}, err
+	}
+	return u.expRepo.AddCategoryRule(ctx, userID, rule)
+}
+
+func (u *UseCase) DeleteCategoryRule(ctx context.Context, userID models.UserID, id models.CategoryRuleID) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "DeleteCategoryRule")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+
+	return u.expRepo.DeleteCategoryRule(ctx, userID, id)
+}
+
+func (u *UseCase) MatchCategoryRule(ctx context.Context, userID models.UserID, text string) (_ models.CategoryRule, _ bool, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "MatchCategoryRule")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+
+	rules, err := u.GetCategoryRules(ctx, userID)
+	if err != nil {
+		return models.CategoryRule{}, false, err
+	}
+	for _, rule := range rules {
+		if rule.Matches(text) {
+			return rule, true, nil
+		}
+	}
+	return models.CategoryRule{}, false, nil
+}
+
+// maxCategorySuggestions limits the number of categories suggested for the expense.
+const maxCategorySuggestions = 3
+
+func (u *UseCase) SuggestCategories(ctx context.Context, userID models.UserID, exp models.Expense) (_ []models.ExpenseCategory, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SuggestCategories")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+
+	// the model is learned from amounts in the base currency
+	exp, err = u.convertToBase(ctx, userID, exp)
+	if err != nil {
+		return nil, err
+	}
+	var out []models.ExpenseCategory
+	err = u.expRepo.Isolated(ctx, func(ctx context.Context) (err error) {
+		span, ctx := opentracing.StartSpanFromContext(ctx, "expRepo.Isolated")
+		defer func() {
+			ext.Error.Set(span, err != nil)
+			span.Finish()
+		}()
+		span.SetTag(userIDSpanTagKey, userID)
+
+		model, err := u.expRepo.GetCategoryModel(ctx, userID)
+		if errors.Is(err, expense.ErrCategoryModelDoesNotExist) {
+			model, err = u.trainCategoryModel(ctx, userID)
+			if err != nil {
+				return err
+			}
+			err = u.expRepo.UpdateCategoryModel(ctx, userID, model)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to get category model of userID=%d", userID)
+		}
+		// categories may be renamed or merged after they were learned
+		for _, category := range model.Suggest(&exp) {
+			exists, err := u.expRepo.CategoryExists(ctx, userID, category)
+			if err != nil {
+				return errors.Wrapf(err, "failed to check category %q existence in expenses repository", category)
+			}
+			if !exists {
+				continue
+			}
+			if out = append(out, category); len(out) == maxCategorySuggestions {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error occured in expenses repo isolated environment")
+	}
+	return out, nil
+}
+
+// learnCategory adds the expense to the user category model, the model is trained on all the expenses if it doesn't exist.
+func (u *UseCase) learnCategory(ctx context.Context, userID models.UserID, exp *models.Expense) error {
+	exists, err := u.expRepo.CategoryModelExists(ctx, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check category model existence of userID=%d", userID)
+	}
+	delta := models.NewCategoryModel()
+	if exists {
+		delta.Learn(exp)
+	} else if delta, err = u.trainCategoryModel(ctx, userID); err != nil {
+		return err
+	}
+	if err := u.expRepo.UpdateCategoryModel(ctx, userID, delta); err != nil {
+		return errors.Wrapf(err, "failed to update category model of userID=%d", userID)
+	}
+	return nil
+}
+
+// trainCategoryModel learns the category model from all the user expenses.
+func (u *UseCase) trainCategoryModel(ctx context.Context, userID models.UserID) (*models.CategoryModel, error) {
+	model := models.NewCategoryModel()
+	err := u.expRepo.GetExpensesAscendSinceTill(ctx, userID, allTimeSince, allTimeTill, func(exp *models.Expense) bool {
+		model.Learn(exp)
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to train category model on expenses of userID=%d", userID)
+	}
+	return model, nil
+}
+
+// allTimeSince and allTimeTill bound dates of all the expenses.
+var (
+	allTimeSince = time.Time{}
+	allTimeTill  = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+)
+
+func (u *UseCase) GetCategories(ctx context.Context, userID models.UserID) (_ []models.CategoryStats, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "GetCategories")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+
+	loc, err := u.getUserTimeZone(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	curr, err := u.getReportCurrency(ctx, userID, "")
+	if err != nil {
+		return nil, err
+	}
+	stats := make(map[models.ExpenseCategory]*models.CategoryStats)
+	err = u.handleExpensesAscendSinceTill(ctx, userID, allTimeSince, allTimeTill, loc, curr, func(exp *models.Expense) bool {
+		s, ok := stats[exp.Category]
+		if !ok {
+			s = &models.CategoryStats{Category: exp.Category}
+			stats[exp.Category] = s
+		}
+		s.Count++
+		s.Amount = s.Amount.Add(exp.NetAmount())
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to iterate through expenses of userID=%d and split by categories", userID)
+	}
+	out := make([]models.CategoryStats, 0, len(stats))
+	for _, s := range stats {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Category < out[j].Category
+	})
+	return out, nil
+}
+
+func (u *UseCase) RenameCategory(ctx context.Context, userID models.UserID, from, to models.ExpenseCategory) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "RenameCategory")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+	span.SetTag(categorySpanTagKey, from)
+
+	return u.moveCategory(ctx, userID, from, to, false)
+}
+
+func (u *UseCase) MergeCategory(ctx context.Context, userID models.UserID, from, to models.ExpenseCategory) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "MergeCategory")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+	span.SetTag(categorySpanTagKey, from)
+
+	return u.moveCategory(ctx, userID, from, to, true)
+}
+
+// moveCategory rewrites categories of the historical expenses and of limits in one transaction and drops the cached reports.
+// The existing target category is accepted only if merge is true or it's within from, e.g. to change the case.
+func (u *UseCase) moveCategory(ctx context.Context, userID models.UserID, from, to models.ExpenseCategory, merge bool) error {
+	to = to.Normalize()
+	if err := to.Validate(); err != nil {
+		return err
+	}
+	err := u.expRepo.Isolated(ctx, func(ctx context.Context) (err error) {
+		span, ctx := opentracing.StartSpanFromContext(ctx, "expRepo.Isolated")
+		defer func() {
+			ext.Error.Set(span, err != nil)
+			span.Finish()
+		}()
+		span.SetTag(userIDSpanTagKey, userID)
+
+		if _, isWithin := to.Rebase(from, from); !merge && !isWithin {
+			exists, err := u.expRepo.CategoryExists(ctx, userID, to)
+			if err != nil {
+				return errors.Wrapf(err, "failed to check category %q existence in expenses repository", to)
+			}
+			if exists {
+				return expense.ErrCategoryExists
+			}
+		}
+		moved, err := u.expRepo.RenameCategory(ctx, userID, from, to)
+		if err != nil {
+			return errors.Wrapf(err, "failed to rename category %q in expenses repository", from)
+		}
+		if moved == 0 {
+			return expense.ErrCategoryDoesNotExist
+		}
+		return u.moveLimits(ctx, userID, from, to)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error occured in expenses repo isolated environment")
+	}
+	return u.reportsCache.DropCacheForUserID(ctx, userID)
+}
+
+// moveLimits moves limits of the from category and its children to the to category.
+// The limit of the target category is kept, if it already exists.
+func (u *UseCase) moveLimits(ctx context.Context, userID models.UserID, from, to models.ExpenseCategory) error {
+	limits, err := u.userRepo.GetUserLimits(ctx, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get limits of userID=%d", userID)
+	}
+	type limitKey struct {
+		period   models.LimitPeriod
+		category models.ExpenseCategory
+	}
+	var (
+		kept  = make(map[limitKey]struct{}, len(limits))
+		moved []models.Limit
+	)
+	for _, limit := range limits {
+		if _, ok := limit.Category.Rebase(from, to); !ok || limit.Category == "" {
+			kept[limitKey{period: limit.Period, category: limit.Category}] = struct{}{}
+			continue
+		}
+		moved = append(moved, limit)
+	}
+	var replaced []models.Limit
+	for _, limit := range moved {
+		if err := u.userRepo.DeleteUserLimit(ctx, userID, limit.Period, limit.Category); err != nil {
+			return errors.Wrapf(err, "failed to delete %s", limit.Name())
+		}
+		limit.Category, _ = limit.Category.Rebase(from, to)
+		if _, ok := kept[limitKey{period: limit.Period, category: limit.Category}]; !ok {
+			replaced = append(replaced, limit)
+		}
+	}
+	if err := u.userRepo.SetUserLimits(ctx, userID, replaced); err != nil {
+		return errors.Wrapf(err, "failed to set limits of the category %q", to)
+	}
+	return nil
+}
+
+func (u *UseCase) GetCategoryAliases(ctx context.Context, userID models.UserID) (_ map[models.ExpenseCategory]models.ExpenseCategory, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "GetCategoryAliases")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+
+	return u.expRepo.GetCategoryAliases(ctx, userID)
+}
+
+func (u *UseCase) SetCategoryAlias(ctx context.Context, userID models.UserID, alias, category models.ExpenseCategory) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SetCategoryAlias")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+	span.SetTag(categorySpanTagKey, category)
+
+	alias, category = alias.Normalize(), category.Normalize()
+	if alias == category {
+		return models.ErrCategoryAliasToItself
+	}
+	if err := alias.Validate(); err != nil {
+		return err
+	}
+	if err := category.Validate(); err != nil {
+		return err
+	}
+	return u.expRepo.SetCategoryAlias(ctx, userID, alias, category)
+}
+
+func (u *UseCase) DeleteCategoryAlias(ctx context.Context, userID models.UserID, alias models.ExpenseCategory) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "DeleteCategoryAlias")
 	defer func() {
-		span.SetTag(handlerCallsCountSpanTagKey, handlerCalls)
 		ext.Error.Set(span, err != nil)
 		span.Finish()
 	}()
 	span.SetTag(userIDSpanTagKey, userID)
-	span.SetTag(sinceUnixMillisSpanTagKey, since.UnixMilli())
-	span.SetTag(tillUnixMillisSpanTagKey, till.UnixMilli())
 
+	return u.expRepo.DeleteCategoryAlias(ctx, userID, alias.Normalize())
+}
+
+// getReportCurrency returns curr or the user selected currency if curr is empty.
+func (u *UseCase) getReportCurrency(ctx context.Context, userID models.UserID, curr models.CurrencyCode) (models.CurrencyCode, error) {
+	if curr != "" {
+		return curr, nil
+	}
 	curr, err := u.userRepo.GetUserCurrency(ctx, userID)
 	if err != nil {
-		return errors.Wrapf(err, "failed to get selected user currency by userID=%d", userID)
+		return "", errors.Wrapf(err, "failed to get selected user currency by userID=%d", userID)
+	}
+	return curr, nil
+}
+
+// sumUserExpensesByPeriods sets Spent of the consecutive ascending periods to the sum of their expenses less refunds
+// in the base currency, all the periods are summed up in one pass over expenses.
+// Only expenses of the category and its children are summed up, if it's not empty.
+func (u *UseCase) sumUserExpensesByPeriods(
+	ctx context.Context,
+	userID models.UserID,
+	periods []models.LimitStatus,
+	category models.ExpenseCategory,
+	exceptID *models.ExpenseID,
+) error {
+	if len(periods) == 0 {
+		return nil
+	}
+	since, till := periods[0].Start, periods[len(periods)-1].End
+	var i int
+	err := u.expRepo.GetExpensesAscendSinceTill(ctx, userID, since, till, func(expense *models.Expense) bool {
+		if exceptID != nil && expense.ID == *exceptID {
+			return true
+		}
+		if category != "" && !expense.Category.IsWithin(category) {
+			return true
+		}
+		for i < len(periods)-1 && expense.Date.After(periods[i].End) {
+			i++
+		}
+		periods[i].Spent = periods[i].Spent.Add(expense.NetAmount())
+		return true
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get userID=%d expenses sum since %v till %v", userID, since, till)
 	}
+	return nil
+}
+
+// handleExpensesAscendSinceTill passes to the handler copies of expenses with date in loc and amount in curr.
+// The typed amount is passed as is for expenses added in curr.
+func (u *UseCase) handleExpensesAscendSinceTill(
+	ctx context.Context,
+	userID models.UserID,
+	since, till time.Time,
+	loc *time.Location,
+	curr models.CurrencyCode,
+	handler func(expense *models.Expense) bool,
+) (err error) {
+	var handlerCalls int
+	span, ctx := opentracing.StartSpanFromContext(ctx, "handleExpensesAscendSinceTill")
+	defer func() {
+		span.SetTag(handlerCallsCountSpanTagKey, handlerCalls)
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+	span.SetTag(sinceUnixMillisSpanTagKey, since.UnixMilli())
+	span.SetTag(tillUnixMillisSpanTagKey, till.UnixMilli())
+	span.SetTag(currencyCodeSpanTagKey, curr)
+
 	var iterErr error
 	iter := func(expense *models.Expense) bool {
 		handlerCalls++
-		return handler(expense)
+		exp := *expense
+		exp.Date = exp.Date.In(loc)
+		return handler(&exp)
 	}
 	if curr != u.baseCurrency {
 		inner := iter
 		iter = func(expense *models.Expense) bool {
+			if expense.OriginalCurrency == curr {
+				exp := *expense
+				exp.Amount, exp.Refunded = exp.OriginalAmount, exp.OriginalRefunded()
+				return inner(&exp)
+			}
 			span, ctx := opentracing.StartSpanFromContext(ctx, "exrateRepo.GetRate")
 			defer func() {
 				ext.Error.Set(span, iterErr != nil)
@@ -211,7 +1334,7 @@ func (u *UseCase) handleExpensesAscendSinceTill(ctx context.Context, userID mode
 				return false
 			}
 			exp := *expense
-			exp.Amount = rate.ConvertFromBase(exp.Amount)
+			exp.Amount, exp.Refunded = rate.ConvertFromBase(exp.Amount), rate.ConvertFromBase(exp.Refunded)
 			return inner(&exp)
 		}
 	}
@@ -227,11 +1350,11 @@ func (u *UseCase) handleExpensesAscendSinceTill(ctx context.Context, userID mode
 
 type noopCache struct{}
 
-func (n noopCache) AddToCache(_ context.Context, _ models.UserID, _, _ time.Time, _ expense.SummaryReport) error {
+func (n noopCache) AddToCache(_ context.Context, _ models.UserID, _ expense.ReportCacheKey, _ expense.SummaryReport) error {
 	return nil
 }
 
-func (n noopCache) GetFromCache(_ context.Context, _ models.UserID, _, _ time.Time) (expense.SummaryReport, bool, error) {
+func (n noopCache) GetFromCache(_ context.Context, _ models.UserID, _ expense.ReportCacheKey) (expense.SummaryReport, bool, error) {
 	return nil, false, nil
 }
 
@@ -13,6 +13,7 @@ import (
 	expenseInMemRepo "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense/repository/inmemory"
 	exrateInMemRepo "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/exrate/repository/inmemory"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/user"
 	userInMemRepo "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/user/repository/inmemory"
 )
 
@@ -37,6 +38,11 @@ func newUC(t *testing.T, baseCurrency models.CurrencyCode, u models.User, rates
 	return uc
 }
 
+func setLimits(t *testing.T, uc *UseCase, userID models.UserID, limits ...models.Limit) {
+	err := uc.userRepo.SetUserLimits(context.TODO(), userID, limits)
+	require.NoError(t, err)
+}
+
 func TestUseCase_ExpensesSummaryByCategorySince(t *testing.T) {
 	const (
 		userID       = models.UserID(10)
@@ -123,7 +129,7 @@ func TestUseCase_ExpensesSummaryByCategorySince(t *testing.T) {
 			}
 			err := uc.userRepo.ChangeUserCurrency(ctx, userID, testCase.selectedCurr)
 			require.NoError(t, err)
-			summary, err := uc.GetExpensesSummaryByCategorySince(ctx, userID, testCase.since, testCase.till)
+			summary, err := uc.GetExpensesSummaryByCategorySince(ctx, userID, testCase.since, testCase.till, "", "")
 			require.NoError(t, err)
 			for category, d := range summary {
 				expected := testCase.summaryByCategories[category]
@@ -133,3 +139,756 @@ func TestUseCase_ExpensesSummaryByCategorySince(t *testing.T) {
 		})
 	}
 }
+
+func TestUseCase_UpdateExpenseMonthlyLimit(t *testing.T) {
+	const (
+		userID   = models.UserID(10)
+		baseCurr = models.CurrencyCode("RUB")
+	)
+	ctx := context.Background()
+	limit := decimal.NewFromInt(1000)
+	u := models.NewUser(userID, baseCurr)
+	uc := newUC(t, baseCurr, u)
+	setLimits(t, uc, userID, models.Limit{Period: models.LimitPeriodMonth, Amount: limit, Currency: baseCurr})
+
+	exp := models.Expense{
+		ID:       1,
+		Category: "cat1",
+		Amount:   decimal.NewFromInt(600),
+		Date:     time.Now(),
+		Comment:  "comment",
+	}
+	_, err := uc.AddExpense(ctx, userID, exp)
+	require.NoError(t, err)
+
+	// the updated expense replaces the old one, so it is not counted twice
+	exp.Amount = decimal.NewFromInt(900)
+	_, err = uc.UpdateExpense(ctx, userID, exp)
+	require.NoError(t, err)
+
+	exp.Amount = decimal.NewFromInt(1001)
+	_, err = uc.UpdateExpense(ctx, userID, exp)
+	require.ErrorIs(t, err, expense.ErrExpensesLimitExcess)
+
+	exp.ID = 2
+	_, err = uc.UpdateExpense(ctx, userID, exp)
+	require.ErrorIs(t, err, expense.ErrDoesNotExist)
+
+	err = uc.DeleteExpense(ctx, userID, 1)
+	require.NoError(t, err)
+	err = uc.DeleteExpense(ctx, userID, 1)
+	require.ErrorIs(t, err, expense.ErrDoesNotExist)
+}
+
+func TestUseCase_AddExpenseFromMessage(t *testing.T) {
+	const (
+		userID   = models.UserID(10)
+		baseCurr = models.CurrencyCode("RUB")
+	)
+	ctx := context.Background()
+	uc := newUC(t, baseCurr, models.NewUser(userID, baseCurr))
+	msg := models.MessageRef{ChatID: int64(userID), MessageID: 5}
+	exp := models.Expense{Category: "cat1", Amount: decimal.NewFromInt(100), Date: time.Now()}
+
+	created, err := uc.AddExpenseFromMessage(ctx, userID, exp, msg)
+	require.NoError(t, err)
+	id, err := uc.GetExpenseIDByMessage(ctx, userID, msg)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, id)
+
+	_, err = uc.AddExpenseFromMessage(ctx, userID, exp, msg)
+	require.ErrorIs(t, err, expense.ErrMessageLinked)
+}
+
+func TestUseCase_AddExpenseMonthlyLimitInUserTimeZone(t *testing.T) {
+	const (
+		userID   = models.UserID(10)
+		baseCurr = models.CurrencyCode("RUB")
+	)
+	ctx := context.Background()
+	loc, err := models.ParseTimeZone("UTC+10")
+	require.NoError(t, err)
+	limit := decimal.NewFromInt(1000)
+	u := models.NewUser(userID, baseCurr)
+	u.TimeZone = loc
+	uc := newUC(t, baseCurr, u)
+	setLimits(t, uc, userID, models.Limit{Period: models.LimitPeriodMonth, Amount: limit, Currency: baseCurr})
+
+	now := time.Now().In(loc)
+	// it's the previous month in UTC, but the current month for the user
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+	exp := models.Expense{
+		Category: "cat1",
+		Amount:   decimal.NewFromInt(600),
+		Date:     monthStart.Add(5 * time.Hour),
+		Comment:  "comment",
+	}
+	_, err = uc.AddExpense(ctx, userID, exp)
+	require.NoError(t, err)
+
+	exp.Date = now
+	_, err = uc.AddExpense(ctx, userID, exp)
+	require.ErrorIs(t, err, expense.ErrExpensesLimitExcess)
+
+	expenses, err := uc.GetExpensesAscendSinceTill(ctx, userID, monthStart, monthStart, "", "", 10)
+	require.NoError(t, err)
+	require.Len(t, expenses, 1)
+	assert.Equal(t, loc, expenses[0].Date.Location())
+}
+
+func TestUseCase_AddExpenseMonthlyLimitByBudgetPeriod(t *testing.T) {
+	const (
+		userID   = models.UserID(10)
+		baseCurr = models.CurrencyCode("RUB")
+	)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	limit := decimal.NewFromInt(1000)
+	u := models.NewUser(userID, baseCurr)
+	u.PeriodStartDay = now.Day() // the budget period starts today
+	uc := newUC(t, baseCurr, u)
+	setLimits(t, uc, userID, models.Limit{Period: models.LimitPeriodMonth, Amount: limit, Currency: baseCurr})
+
+	exp := models.Expense{
+		Category: "cat1",
+		Amount:   decimal.NewFromInt(600),
+		Date:     now.AddDate(0, 0, -1),
+		Comment:  "comment",
+	}
+	_, err := uc.AddExpense(ctx, userID, exp)
+	require.NoError(t, err)
+
+	// yesterday's expense belongs to the previous period
+	exp.Date = now
+	_, err = uc.AddExpense(ctx, userID, exp)
+	require.NoError(t, err)
+
+	_, err = uc.AddExpense(ctx, userID, exp)
+	require.ErrorIs(t, err, expense.ErrExpensesLimitExcess)
+}
+
+func TestUseCase_AddExpenseCategoryLimit(t *testing.T) {
+	const (
+		userID   = models.UserID(10)
+		baseCurr = models.CurrencyCode("RUB")
+	)
+	ctx := context.Background()
+	uc := newUC(t, baseCurr, models.NewUser(userID, baseCurr))
+	setLimits(t, uc, userID, models.Limit{
+		Period:   models.LimitPeriodMonth,
+		Category: "groceries",
+		Amount:   decimal.NewFromInt(1000),
+	})
+
+	exp := models.Expense{
+		Category: "groceries",
+		Amount:   decimal.NewFromInt(600),
+		Date:     time.Now(),
+	}
+	_, err := uc.AddExpense(ctx, userID, exp)
+	require.NoError(t, err)
+
+	_, err = uc.AddExpense(ctx, userID, exp)
+	require.ErrorIs(t, err, expense.ErrExpensesLimitExcess)
+
+	// other categories are not limited by the groceries limit
+	exp.Category = "travel"
+	_, err = uc.AddExpense(ctx, userID, exp)
+	require.NoError(t, err)
+
+	statuses, err := uc.GetLimitsStatus(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, models.ExpenseCategory("groceries"), statuses[0].Category)
+	assert.True(t, decimal.NewFromInt(600).Equal(statuses[0].Spent))
+	assert.True(t, decimal.NewFromInt(400).Equal(statuses[0].Remaining()))
+	assert.True(t, decimal.NewFromInt(60).Equal(statuses[0].SpentPercent()))
+}
+
+func TestUseCase_AddExpenseSeveralLimits(t *testing.T) {
+	const (
+		userID   = models.UserID(10)
+		baseCurr = models.CurrencyCode("RUB")
+	)
+	ctx := context.Background()
+	uc := newUC(t, baseCurr, models.NewUser(userID, baseCurr))
+	setLimits(t, uc, userID,
+		models.Limit{Period: models.LimitPeriodWeek, Amount: decimal.NewFromInt(500)},
+		models.Limit{Period: models.LimitPeriodMonth, Category: "food", Amount: decimal.NewFromInt(1000)},
+		models.Limit{Period: models.LimitPeriodYear, Amount: decimal.NewFromInt(100000)},
+	)
+	now := time.Now()
+
+	tests := []struct {
+		exp      models.Expense
+		exceeded []models.LimitPeriod
+	}{
+		{exp: models.Expense{Category: "food", Amount: decimal.NewFromInt(400), Date: now}},
+		{exp: models.Expense{Category: "travel", Amount: decimal.NewFromInt(100), Date: now}},
+		{
+			exp:      models.Expense{Category: "food", Amount: decimal.NewFromInt(700), Date: now},
+			exceeded: []models.LimitPeriod{models.LimitPeriodWeek, models.LimitPeriodMonth},
+		},
+		{
+			exp:      models.Expense{Category: "travel", Amount: decimal.NewFromInt(1), Date: now},
+			exceeded: []models.LimitPeriod{models.LimitPeriodWeek},
+		},
+		// limits are checked only in their current periods
+		{exp: models.Expense{Category: "food", Amount: decimal.NewFromInt(200000), Date: now.AddDate(-1, 0, 0)}},
+	}
+	for i, test := range tests {
+		_, err := uc.AddExpense(ctx, userID, test.exp)
+		if len(test.exceeded) == 0 {
+			require.NoError(t, err, "expense #%d", i+1)
+			continue
+		}
+		require.ErrorIs(t, err, expense.ErrExpensesLimitExcess, "expense #%d", i+1)
+		var excessErr *expense.LimitExcessError
+		require.ErrorAs(t, err, &excessErr)
+		periods := make([]models.LimitPeriod, 0, len(excessErr.Exceeded))
+		for _, status := range excessErr.Exceeded {
+			periods = append(periods, status.Period)
+		}
+		assert.Equal(t, test.exceeded, periods, "expense #%d", i+1)
+	}
+}
+
+type messagesRecorder struct {
+	messages []string
+}
+
+func (r *messagesRecorder) SendMessage(_ int64, message string) error {
+	r.messages = append(r.messages, message)
+	return nil
+}
+
+func TestUseCase_AddExpenseSoftLimitNotifications(t *testing.T) {
+	const (
+		userID   = models.UserID(10)
+		baseCurr = models.CurrencyCode("RUB")
+	)
+	ctx := context.Background()
+	limit := decimal.NewFromInt(1000)
+	u := models.NewUser(userID, baseCurr)
+	u.LimitMode = models.LimitModeSoft
+	uc := newUC(t, baseCurr, u)
+	setLimits(t, uc, userID, models.Limit{Period: models.LimitPeriodMonth, Amount: limit, Currency: baseCurr})
+	recorder := &messagesRecorder{}
+	require.NoError(t, uc.EnableLimitNotifications(recorder, nil))
+
+	tests := []struct {
+		amount   int64
+		expected []string
+	}{
+		{amount: 600, expected: []string{"You have reached 50% of your monthly limit: spent 600 of 1000 RUB."}},
+		{amount: 300, expected: []string{"You have reached 80% of your monthly limit: spent 900 of 1000 RUB."}},
+		{amount: 50},
+		{amount: 200, expected: []string{
+			"You have reached 100% of your monthly limit: spent 1150 of 1000 RUB.\n" +
+				"Exceeded monthly limit: spent 1150 of 1000 RUB.",
+		}},
+		{amount: 10, expected: []string{"Exceeded monthly limit: spent 1160 of 1000 RUB."}},
+	}
+	for _, test := range tests {
+		recorder.messages = nil
+		_, err := uc.AddExpense(ctx, userID, models.Expense{
+			Category: "cat1",
+			Amount:   decimal.NewFromInt(test.amount),
+			Date:     time.Now(),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, recorder.messages)
+	}
+
+	err := uc.userRepo.SetUserLimitMode(ctx, userID, models.LimitModeHard)
+	require.NoError(t, err)
+	_, err = uc.AddExpense(ctx, userID, models.Expense{
+		Category: "cat1",
+		Amount:   decimal.NewFromInt(10),
+		Date:     time.Now(),
+	})
+	require.ErrorIs(t, err, expense.ErrExpensesLimitExcess)
+}
+
+func TestUseCase_AddExpenseMonthlyLimitInOwnCurrency(t *testing.T) {
+	const (
+		userID   = models.UserID(10)
+		baseCurr = models.CurrencyCode("RUB")
+		userCurr = models.CurrencyCode("USD")
+	)
+	ctx := context.Background()
+	today := models.StartOfDay(time.Now().UTC())
+	u := models.NewUser(userID, userCurr)
+	uc := newUC(t, baseCurr, u, models.NewExchangeRate(userCurr, decimal.RequireFromString("0.01"), today))
+	setLimits(t, uc, userID, models.Limit{Period: models.LimitPeriodMonth, Amount: decimal.NewFromInt(100), Currency: userCurr})
+
+	exp := models.Expense{
+		Category: "cat1",
+		Amount:   decimal.NewFromInt(60), // in the user currency
+		Date:     time.Now(),
+	}
+	_, err := uc.AddExpense(ctx, userID, exp)
+	require.NoError(t, err)
+
+	_, err = uc.AddExpense(ctx, userID, exp)
+	require.ErrorIs(t, err, expense.ErrExpensesLimitExcess)
+
+	exp.Amount = decimal.NewFromInt(40)
+	_, err = uc.AddExpense(ctx, userID, exp)
+	require.NoError(t, err)
+}
+
+func TestUseCase_LimitRollover(t *testing.T) {
+	const (
+		userID   = models.UserID(10)
+		baseCurr = models.CurrencyCode("RUB")
+	)
+	ctx := context.Background()
+	uc := newUC(t, baseCurr, models.NewUser(userID, baseCurr))
+	currentStart, _ := models.LimitPeriodMonth.Bounds(time.Now().UTC(), models.DefaultUserPeriodStartDay)
+	previousStart, _ := models.LimitPeriodMonth.Bounds(currentStart.Add(-1*time.Nanosecond), models.DefaultUserPeriodStartDay)
+	firstStart, _ := models.LimitPeriodMonth.Bounds(previousStart.Add(-1*time.Nanosecond), models.DefaultUserPeriodStartDay)
+	rolloverCap := decimal.NewFromInt(300)
+	setLimits(t, uc, userID, models.Limit{
+		Period:   models.LimitPeriodMonth,
+		Amount:   decimal.NewFromInt(1000),
+		Rollover: &models.Rollover{Since: firstStart.Add(time.Hour), Cap: &rolloverCap},
+	})
+
+	// past periods are not limited, but they define the carried amounts
+	for _, exp := range []models.Expense{
+		{Category: "cat1", Amount: decimal.NewFromInt(600), Date: firstStart.AddDate(0, 0, 1)},
+		{Category: "cat1", Amount: decimal.NewFromInt(1500), Date: previousStart.AddDate(0, 0, 1)},
+		{Category: "cat1", Amount: decimal.NewFromInt(700), Date: time.Now()},
+	} {
+		_, err := uc.AddExpense(ctx, userID, exp)
+		require.NoError(t, err)
+	}
+	// 400 unspent in the first period is capped to 300, 200 overspent in the previous one is carried as is
+	_, err := uc.AddExpense(ctx, userID, models.Expense{Category: "cat1", Amount: decimal.NewFromInt(200), Date: time.Now()})
+	require.ErrorIs(t, err, expense.ErrExpensesLimitExcess)
+
+	history, err := uc.GetLimitHistory(ctx, userID, models.LimitPeriodMonth, "")
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	expected := []struct {
+		start   time.Time
+		carried int64
+		spent   int64
+	}{
+		{start: firstStart, carried: 0, spent: 600},
+		{start: previousStart, carried: 300, spent: 1500},
+		{start: currentStart, carried: -200, spent: 700},
+	}
+	for i, status := range history {
+		assert.Equal(t, expected[i].start, status.Start)
+		assert.Truef(t, decimal.NewFromInt(expected[i].carried).Equal(status.Carried), "period #%d carried %v", i+1, status.Carried)
+		assert.Truef(t, decimal.NewFromInt(expected[i].spent).Equal(status.Spent), "period #%d spent %v", i+1, status.Spent)
+	}
+	assert.True(t, decimal.NewFromInt(100).Equal(history[2].Remaining()))
+
+	_, err = uc.GetLimitHistory(ctx, userID, models.LimitPeriodWeek, "")
+	require.ErrorIs(t, err, user.ErrLimitDoesNotExist)
+}
+
+func TestUseCase_SharedLedgerExpenses(t *testing.T) {
+	const (
+		ledgerID = models.UserID(-100)
+		alice    = models.UserID(1)
+		bob      = models.UserID(2)
+		baseCurr = models.CurrencyCode("RUB")
+	)
+	ctx := context.Background()
+	uc := newUC(t, baseCurr, models.NewUser(ledgerID, baseCurr))
+	setLimits(t, uc, ledgerID, models.Limit{Period: models.LimitPeriodMonth, Amount: decimal.NewFromInt(1000)})
+	now := time.Now()
+
+	for _, exp := range []models.Expense{
+		{Category: "food", Amount: decimal.NewFromInt(300), Date: now, AuthorID: alice},
+		{Category: "taxi", Amount: decimal.NewFromInt(200), Date: now, AuthorID: alice},
+		{Category: "food", Amount: decimal.NewFromInt(400), Date: now, AuthorID: bob},
+	} {
+		_, err := uc.AddExpense(ctx, ledgerID, exp)
+		require.NoError(t, err)
+	}
+	// the limit applies to expenses of all the members
+	_, err := uc.AddExpense(ctx, ledgerID, models.Expense{Category: "food", Amount: decimal.NewFromInt(200), Date: now, AuthorID: bob})
+	require.ErrorIs(t, err, expense.ErrExpensesLimitExcess)
+
+	report, err := uc.GetExpensesSummaryByAuthorSince(ctx, ledgerID, now, now, "", "")
+	require.NoError(t, err)
+	require.Len(t, report, 2)
+	assert.True(t, decimal.NewFromInt(300).Equal(report[alice]["food"]))
+	assert.True(t, decimal.NewFromInt(200).Equal(report[alice]["taxi"]))
+	assert.True(t, decimal.NewFromInt(400).Equal(report[bob]["food"]))
+	assert.Len(t, report[bob], 1)
+}
+
+func TestUseCase_KeepsOriginalAmount(t *testing.T) {
+	const (
+		userID   = models.UserID(10)
+		baseCurr = models.CurrencyCode("RUB")
+		userCurr = models.CurrencyCode("USD")
+	)
+	ctx := context.Background()
+	today := models.StartOfDay(time.Now().UTC())
+	u := models.NewUser(userID, userCurr)
+	uc := newUC(t, baseCurr, u, models.NewExchangeRate(userCurr, decimal.RequireFromString("0.03"), today))
+
+	added, err := uc.AddExpense(ctx, userID, models.Expense{Category: "cat1", Amount: decimal.NewFromInt(100), Date: today})
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(100).Equal(added.OriginalAmount))
+	assert.Equal(t, userCurr, added.OriginalCurrency)
+	assert.True(t, decimal.RequireFromString("0.03").Equal(added.Rate))
+	assert.False(t, decimal.NewFromInt(100).Equal(added.Amount))
+
+	// the rate update doesn't change the typed amount
+	err = uc.exrateRepo.AddOrUpdateRates(ctx, models.NewExchangeRate(userCurr, decimal.RequireFromString("0.05"), today))
+	require.NoError(t, err)
+	expenses, err := uc.GetExpensesAscendSinceTill(ctx, userID, today, today, "", "", 10)
+	require.NoError(t, err)
+	require.Len(t, expenses, 1)
+	assert.True(t, decimal.NewFromInt(100).Equal(expenses[0].Amount), expenses[0].Amount)
+
+	exp, err := uc.GetExpenseByID(ctx, userID, added.ID)
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(100).Equal(exp.Amount), exp.Amount)
+}
+
+func TestUseCase_AddExpenseInOtherCurrency(t *testing.T) {
+	const (
+		userID    = models.UserID(10)
+		baseCurr  = models.CurrencyCode("RUB")
+		userCurr  = models.CurrencyCode("USD")
+		otherCurr = models.CurrencyCode("EUR")
+	)
+	ctx := context.Background()
+	today := models.StartOfDay(time.Now().UTC())
+	u := models.NewUser(userID, userCurr)
+	uc := newUC(t, baseCurr, u,
+		models.NewExchangeRate(userCurr, decimal.RequireFromString("0.01"), today),
+		models.NewExchangeRate(otherCurr, decimal.RequireFromString("0.02"), today),
+	)
+
+	exp := models.Expense{
+		Category:         "cat1",
+		Amount:           decimal.NewFromInt(10),
+		Date:             today,
+		OriginalAmount:   decimal.NewFromInt(10),
+		OriginalCurrency: otherCurr,
+	}
+	added, err := uc.AddExpense(ctx, userID, exp)
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(500).Equal(added.Amount), added.Amount)
+	assert.Equal(t, otherCurr, added.OriginalCurrency)
+
+	// the amount is reported in the user currency, which isn't changed
+	curr, err := uc.userRepo.GetUserCurrency(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, userCurr, curr)
+	got, err := uc.GetExpenseByID(ctx, userID, added.ID)
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(5).Equal(got.Amount), got.Amount)
+}
+
+func TestUseCase_ExpensesSummaryInReportCurrency(t *testing.T) {
+	const (
+		userID     = models.UserID(10)
+		baseCurr   = models.CurrencyCode("RUB")
+		userCurr   = models.CurrencyCode("USD")
+		reportCurr = models.CurrencyCode("EUR")
+	)
+	ctx := context.Background()
+	today := models.StartOfDay(time.Now().UTC())
+	u := models.NewUser(userID, userCurr)
+	uc := newUC(t, baseCurr, u,
+		models.NewExchangeRate(userCurr, decimal.RequireFromString("0.01"), today),
+		models.NewExchangeRate(reportCurr, decimal.RequireFromString("0.02"), today),
+	)
+	_, err := uc.AddExpense(ctx, userID, models.Expense{Category: "cat1", Amount: decimal.NewFromInt(10), Date: today})
+	require.NoError(t, err)
+
+	report, err := uc.GetExpensesSummaryByCategorySince(ctx, userID, today, today, reportCurr, "")
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(20).Equal(report["cat1"]), report["cat1"])
+
+	report, err = uc.GetExpensesSummaryByCategorySince(ctx, userID, today, today, "", "")
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(10).Equal(report["cat1"]), report["cat1"])
+}
+
+func TestUseCase_RefundExpense(t *testing.T) {
+	const (
+		userID   = models.UserID(10)
+		baseCurr = models.CurrencyCode("RUB")
+		userCurr = models.CurrencyCode("USD")
+	)
+	ctx := context.Background()
+	today := models.StartOfDay(time.Now().UTC())
+	u := models.NewUser(userID, userCurr)
+	uc := newUC(t, baseCurr, u, models.NewExchangeRate(userCurr, decimal.RequireFromString("0.03"), today))
+	setLimits(t, uc, userID, models.Limit{Period: models.LimitPeriodMonth, Amount: decimal.NewFromInt(1200), Currency: baseCurr})
+
+	added, err := uc.AddExpense(ctx, userID, models.Expense{Category: "cat1", Amount: decimal.NewFromInt(30), Date: today})
+	require.NoError(t, err)
+
+	refunded, err := uc.RefundExpense(ctx, userID, added.ID, decimal.NewFromInt(10))
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(10).Equal(refunded.Refunded.Round(2)), refunded.Refunded)
+	report, err := uc.GetExpensesSummaryByCategorySince(ctx, userID, today, today, "", "")
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(20).Equal(report["cat1"].Round(2)), report["cat1"])
+
+	// the refund is subtracted from the limit sum
+	_, err = uc.AddExpense(ctx, userID, models.Expense{Category: "cat1", Amount: decimal.NewFromInt(15), Date: today})
+	require.NoError(t, err)
+
+	_, err = uc.RefundExpense(ctx, userID, added.ID, decimal.NewFromInt(25))
+	require.ErrorIs(t, err, expense.ErrRefundExceedsExpense)
+
+	refunded, err = uc.RefundExpense(ctx, userID, added.ID, decimal.Zero)
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(30).Equal(refunded.Refunded.Round(2)), refunded.Refunded)
+	assert.True(t, refunded.NetAmount().Round(2).IsZero(), refunded.NetAmount())
+
+	_, err = uc.RefundExpense(ctx, userID, added.ID, decimal.Zero)
+	require.ErrorIs(t, err, expense.ErrRefundExceedsExpense)
+	_, err = uc.RefundExpense(ctx, userID, added.ID+100, decimal.Zero)
+	require.ErrorIs(t, err, expense.ErrDoesNotExist)
+}
+
+func TestUseCase_ExpenseTags(t *testing.T) {
+	const (
+		userID   = models.UserID(10)
+		baseCurr = models.CurrencyCode("RUB")
+	)
+	ctx := context.Background()
+	today := models.StartOfDay(time.Now().UTC())
+	uc := newUC(t, baseCurr, models.NewUser(userID, baseCurr))
+
+	for _, exp := range []models.Expense{
+		{Category: "food", Amount: decimal.NewFromInt(100), Date: today, Comment: "pizza #trip-italy"},
+		{Category: "hotel", Amount: decimal.NewFromInt(300), Date: today, Comment: "#Trip-Italy #work"},
+		{Category: "food", Amount: decimal.NewFromInt(50), Date: today, Comment: "lunch"},
+	} {
+		_, err := uc.AddExpense(ctx, userID, exp)
+		require.NoError(t, err)
+	}
+
+	report, err := uc.GetExpensesSummaryByCategorySince(ctx, userID, today, today, "", "trip-italy")
+	require.NoError(t, err)
+	assert.Equal(t, expense.SummaryReport{"food": decimal.NewFromInt(100), "hotel": decimal.NewFromInt(300)}, report)
+
+	expenses, err := uc.GetExpensesAscendSinceTill(ctx, userID, today, today, "", "work", 10)
+	require.NoError(t, err)
+	require.Len(t, expenses, 1)
+	assert.Equal(t, []models.ExpenseTag{"trip-italy", "work"}, expenses[0].Tags)
+
+	tagsReport, err := uc.GetExpensesSummaryByTagSince(ctx, userID, today, today, "")
+	require.NoError(t, err)
+	text, err := tagsReport.Text()
+	require.NoError(t, err)
+	assert.Equal(t, "#trip-italy=400\n#work=300\n", text)
+}
+
+func TestUseCase_NestedCategories(t *testing.T) {
+	const (
+		userID   = models.UserID(10)
+		baseCurr = models.CurrencyCode("RUB")
+	)
+	ctx := context.Background()
+	today := models.StartOfDay(time.Now().UTC())
+	uc := newUC(t, baseCurr, models.NewUser(userID, baseCurr))
+	setLimits(t, uc, userID, models.Limit{Period: models.LimitPeriodMonth, Category: "food", Amount: decimal.NewFromInt(1000)})
+
+	for _, exp := range []models.Expense{
+		{Category: "food/restaurants", Amount: decimal.NewFromInt(300), Date: today},
+		{Category: "food/groceries", Amount: decimal.NewFromInt(200), Date: today},
+		{Category: "food", Amount: decimal.NewFromInt(50), Date: today},
+		{Category: "food-delivery", Amount: decimal.NewFromInt(70), Date: today},
+		{Category: "taxi", Amount: decimal.NewFromInt(100), Date: today},
+	} {
+		_, err := uc.AddExpense(ctx, userID, exp)
+		require.NoError(t, err)
+	}
+
+	// the budget of the parent covers its children
+	_, err := uc.AddExpense(ctx, userID, models.Expense{Category: "food/groceries", Amount: decimal.NewFromInt(500), Date: today})
+	require.ErrorIs(t, err, expense.ErrExpensesLimitExcess)
+
+	report, err := uc.GetExpensesSummaryByCategorySince(ctx, userID, today, today, "", "")
+	require.NoError(t, err)
+	text, err := report.Text()
+	require.NoError(t, err)
+	assert.Equal(t, "food=550\n  groceries=200\n  restaurants=300\nfood-delivery=70\ntaxi=100\n", text)
+
+	text, err = report.Within("food").Text()
+	require.NoError(t, err)
+	assert.Equal(t, "food=550\n  groceries=200\n  restaurants=300\n", text)
+}
+
+func TestUseCase_ManageCategories(t *testing.T) {
+	const (
+		userID   = models.UserID(10)
+		baseCurr = models.CurrencyCode("RUB")
+	)
+	ctx := context.Background()
+	today := models.StartOfDay(time.Now().UTC())
+	uc := newUC(t, baseCurr, models.NewUser(userID, baseCurr))
+
+	require.NoError(t, uc.SetCategoryAlias(ctx, userID, "EDA", "food"))
+	require.ErrorIs(t, uc.SetCategoryAlias(ctx, userID, "food", "Food"), models.ErrCategoryAliasToItself)
+	for _, exp := range []models.Expense{
+		{Category: "food", Amount: decimal.NewFromInt(100), Date: today},
+		{Category: "Food", Amount: decimal.NewFromInt(50), Date: today},
+		{Category: "eda/Pizza", Amount: decimal.NewFromInt(30), Date: today},
+		{Category: "cafe", Amount: decimal.NewFromInt(20), Date: today},
+	} {
+		_, err := uc.AddExpense(ctx, userID, exp)
+		require.NoError(t, err)
+	}
+
+	categories, err := uc.GetCategories(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.CategoryStats{
+		{Category: "cafe", Count: 1, Amount: decimal.NewFromInt(20)},
+		{Category: "food", Count: 2, Amount: decimal.NewFromInt(150)},
+		{Category: "food/pizza", Count: 1, Amount: decimal.NewFromInt(30)},
+	}, categories)
+
+	require.ErrorIs(t, uc.RenameCategory(ctx, userID, "cafe", "food"), expense.ErrCategoryExists)
+	require.ErrorIs(t, uc.RenameCategory(ctx, userID, "taxi", "transport"), expense.ErrCategoryDoesNotExist)
+	require.NoError(t, uc.RenameCategory(ctx, userID, "Food", "meal"))
+	require.NoError(t, uc.MergeCategory(ctx, userID, "cafe", "meal/cafe"))
+
+	categories, err = uc.GetCategories(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.CategoryStats{
+		{Category: "meal", Count: 2, Amount: decimal.NewFromInt(150)},
+		{Category: "meal/cafe", Count: 1, Amount: decimal.NewFromInt(20)},
+		{Category: "meal/pizza", Count: 1, Amount: decimal.NewFromInt(30)},
+	}, categories)
+
+	require.NoError(t, uc.DeleteCategoryAlias(ctx, userID, "Eda"))
+	require.ErrorIs(t, uc.DeleteCategoryAlias(ctx, userID, "eda"), expense.ErrCategoryAliasDoesNotExist)
+}
+
+func TestUseCase_RenameCategoryWithReferences(t *testing.T) {
+	const (
+		userID   = models.UserID(10)
+		baseCurr = models.CurrencyCode("RUB")
+	)
+	ctx := context.Background()
+	today := models.StartOfDay(time.Now().UTC())
+	uc := newUC(t, baseCurr, models.NewUser(userID, baseCurr))
+	budget := func(category models.ExpenseCategory, amount int64) models.Limit {
+		return models.Limit{Period: models.LimitPeriodMonth, Category: category, Amount: decimal.NewFromInt(amount), Currency: baseCurr}
+	}
+	setLimits(t, uc, userID, budget("food", 100), budget("food/pizza", 50), budget("cafe", 70), budget("groceries", 300))
+	require.NoError(t, uc.SetCategoryAlias(ctx, userID, "eda", "food"))
+	_, err := uc.AddCategoryRule(ctx, userID, models.CategoryRule{Pattern: "pizza", Category: "food/pizza"})
+	require.NoError(t, err)
+	for _, exp := range []models.Expense{
+		{Category: "food", Amount: decimal.NewFromInt(60), Date: today},
+		{Category: "cafe", Amount: decimal.NewFromInt(20), Date: today},
+	} {
+		_, err := uc.AddExpense(ctx, userID, exp)
+		require.NoError(t, err)
+	}
+
+	// the budget of the target category is kept on merge
+	require.NoError(t, uc.MergeCategory(ctx, userID, "cafe", "groceries"))
+	require.NoError(t, uc.RenameCategory(ctx, userID, "food", "meal"))
+
+	limits, err := uc.userRepo.GetUserLimits(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Limit{budget("groceries", 300), budget("meal", 100), budget("meal/pizza", 50)}, limits)
+	aliases, err := uc.GetCategoryAliases(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, map[models.ExpenseCategory]models.ExpenseCategory{"eda": "meal"}, aliases)
+	rule, ok, err := uc.MatchCategoryRule(ctx, userID, "pizza")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, models.ExpenseCategory("meal/pizza"), rule.Category)
+
+	// the renamed budget still limits expenses of the renamed category
+	_, err = uc.AddExpense(ctx, userID, models.Expense{Category: "eda", Amount: decimal.NewFromInt(50), Date: today})
+	require.ErrorIs(t, err, expense.ErrExpensesLimitExcess)
+}
+
+func TestUseCase_CategoryRules(t *testing.T) {
+	const (
+		userID   = models.UserID(10)
+		baseCurr = models.CurrencyCode("RUB")
+	)
+	ctx := context.Background()
+	uc := newUC(t, baseCurr, models.NewUser(userID, baseCurr))
+	require.NoError(t, uc.SetDefaultCategoryRules([]models.CategoryRule{
+		{Pattern: "starbucks", Category: "Coffee"},
+		{Pattern: "^uber", IsRegex: true, Category: "taxi"},
+	}))
+	require.Error(t, uc.SetDefaultCategoryRules([]models.CategoryRule{{Pattern: "(", IsRegex: true, Category: "taxi"}}))
+
+	rule, ok, err := uc.MatchCategoryRule(ctx, userID, "Starbucks latte")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, models.ExpenseCategory("coffee"), rule.Category)
+
+	// the user rules are applied before the default ones
+	added, err := uc.AddCategoryRule(ctx, userID, models.CategoryRule{Pattern: "latte", Category: "food/drinks"})
+	require.NoError(t, err)
+	rule, ok, err = uc.MatchCategoryRule(ctx, userID, "Starbucks latte")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, added, rule)
+
+	_, ok, err = uc.MatchCategoryRule(ctx, userID, "airport uber")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	rules, err := uc.GetCategoryRules(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, rules, 3)
+
+	_, err = uc.AddCategoryRule(ctx, userID, models.CategoryRule{Pattern: " ", Category: "food"})
+	require.ErrorIs(t, err, models.ErrCategoryRulePatternIsEmpty)
+	require.NoError(t, uc.DeleteCategoryRule(ctx, userID, added.ID))
+	require.ErrorIs(t, uc.DeleteCategoryRule(ctx, userID, added.ID), expense.ErrCategoryRuleDoesNotExist)
+}
+
+func TestUseCase_SuggestCategories(t *testing.T) {
+	const (
+		userID   = models.UserID(10)
+		baseCurr = models.CurrencyCode("RUB")
+	)
+	ctx := context.Background()
+	today := models.StartOfDay(time.Now().UTC())
+	uc := newUC(t, baseCurr, models.NewUser(userID, baseCurr))
+
+	suggested, err := uc.SuggestCategories(ctx, userID, models.Expense{Amount: decimal.NewFromInt(250), Comment: "latte", Date: today})
+	require.NoError(t, err)
+	assert.Empty(t, suggested)
+
+	for _, exp := range []models.Expense{
+		{Category: "coffee", Amount: decimal.NewFromInt(250), Comment: "latte", Date: today},
+		{Category: "coffee", Amount: decimal.NewFromInt(300), Comment: "cappuccino", Date: today},
+		{Category: "food", Amount: decimal.NewFromInt(2500), Comment: "groceries", Date: today},
+		{Category: "food", Amount: decimal.NewFromInt(1800), Comment: "bakery", Date: today},
+		{Category: "taxi", Amount: decimal.NewFromInt(430), Comment: "airport", Date: today},
+		{Category: "books", Amount: decimal.NewFromInt(900), Date: today},
+	} {
+		_, err := uc.AddExpense(ctx, userID, exp)
+		require.NoError(t, err)
+	}
+
+	suggested, err = uc.SuggestCategories(ctx, userID, models.Expense{Amount: decimal.NewFromInt(270), Comment: "Latte", Date: today})
+	require.NoError(t, err)
+	require.Len(t, suggested, 3)
+	assert.Equal(t, models.ExpenseCategory("coffee"), suggested[0])
+
+	// renamed categories are not suggested
+	require.NoError(t, uc.RenameCategory(ctx, userID, "coffee", "drinks"))
+	suggested, err = uc.SuggestCategories(ctx, userID, models.Expense{Amount: decimal.NewFromInt(270), Comment: "Latte", Date: today})
+	require.NoError(t, err)
+	assert.NotContains(t, suggested, models.ExpenseCategory("coffee"))
+}
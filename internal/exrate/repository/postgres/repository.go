@@ -23,7 +23,7 @@ func (r *Repository) GetRate(ctx context.Context, curr models.CurrencyCode, date
 	var rate models.ExchangeRate
 	err := r.db.Do(ctx).QueryRowContext(ctx,
 		"SELECT currency, date, rate FROM exchange_rates WHERE currency = $1 AND date = $2",
-		curr, date.UTC(),
+		curr, models.StartOfDay(date.UTC()),
 	).Scan(&rate.Code, &rate.Date, &rate.Rate)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -48,6 +48,39 @@ func (mr *MocktelebotReducedContextMockRecorder) Args() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Args", reflect.TypeOf((*MocktelebotReducedContext)(nil).Args))
 }
 
+// Callback mocks base method.
+func (m *MocktelebotReducedContext) Callback() *telebot.Callback {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Callback")
+	ret0, _ := ret[0].(*telebot.Callback)
+	return ret0
+}
+
+// Callback indicates an expected call of Callback.
+func (mr *MocktelebotReducedContextMockRecorder) Callback() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Callback", reflect.TypeOf((*MocktelebotReducedContext)(nil).Callback))
+}
+
+// Edit mocks base method.
+func (m *MocktelebotReducedContext) Edit(what interface{}, opts ...interface{}) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{what}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Edit", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Edit indicates an expected call of Edit.
+func (mr *MocktelebotReducedContextMockRecorder) Edit(what interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{what}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Edit", reflect.TypeOf((*MocktelebotReducedContext)(nil).Edit), varargs...)
+}
+
 // Message mocks base method.
 func (m *MocktelebotReducedContext) Message() *telebot.Message {
 	m.ctrl.T.Helper()
@@ -62,6 +95,24 @@ func (mr *MocktelebotReducedContextMockRecorder) Message() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Message", reflect.TypeOf((*MocktelebotReducedContext)(nil).Message))
 }
 
+// Respond mocks base method.
+func (m *MocktelebotReducedContext) Respond(resp ...*telebot.CallbackResponse) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range resp {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Respond", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Respond indicates an expected call of Respond.
+func (mr *MocktelebotReducedContextMockRecorder) Respond(resp ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Respond", reflect.TypeOf((*MocktelebotReducedContext)(nil).Respond), resp...)
+}
+
 // Send mocks base method.
 func (m *MocktelebotReducedContext) Send(what interface{}, opts ...interface{}) error {
 	m.ctrl.T.Helper()
@@ -10,6 +10,7 @@ import (
 	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	decimal "github.com/shopspring/decimal"
 	expense "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense"
 	models "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
 )
@@ -37,6 +38,21 @@ func (m *MockUseCase) EXPECT() *MockUseCaseMockRecorder {
 	return m.recorder
 }
 
+// AddCategoryRule mocks base method.
+func (m *MockUseCase) AddCategoryRule(ctx context.Context, userID models.UserID, rule models.CategoryRule) (models.CategoryRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCategoryRule", ctx, userID, rule)
+	ret0, _ := ret[0].(models.CategoryRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddCategoryRule indicates an expected call of AddCategoryRule.
+func (mr *MockUseCaseMockRecorder) AddCategoryRule(ctx, userID, rule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCategoryRule", reflect.TypeOf((*MockUseCase)(nil).AddCategoryRule), ctx, userID, rule)
+}
+
 // AddExpense mocks base method.
 func (m *MockUseCase) AddExpense(ctx context.Context, userID models.UserID, expense models.Expense) (models.Expense, error) {
 	m.ctrl.T.Helper()
@@ -52,34 +68,357 @@ func (mr *MockUseCaseMockRecorder) AddExpense(ctx, userID, expense interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddExpense", reflect.TypeOf((*MockUseCase)(nil).AddExpense), ctx, userID, expense)
 }
 
+// AddExpenseDeferringNotification mocks base method.
+func (m *MockUseCase) AddExpenseDeferringNotification(ctx context.Context, userID models.UserID, expense models.Expense) (models.Expense, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddExpenseDeferringNotification", ctx, userID, expense)
+	ret0, _ := ret[0].(models.Expense)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AddExpenseDeferringNotification indicates an expected call of AddExpenseDeferringNotification.
+func (mr *MockUseCaseMockRecorder) AddExpenseDeferringNotification(ctx, userID, expense interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddExpenseDeferringNotification", reflect.TypeOf((*MockUseCase)(nil).AddExpenseDeferringNotification), ctx, userID, expense)
+}
+
+// AddExpenseFromMessage mocks base method.
+func (m *MockUseCase) AddExpenseFromMessage(ctx context.Context, userID models.UserID, expense models.Expense, msg models.MessageRef) (models.Expense, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddExpenseFromMessage", ctx, userID, expense, msg)
+	ret0, _ := ret[0].(models.Expense)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddExpenseFromMessage indicates an expected call of AddExpenseFromMessage.
+func (mr *MockUseCaseMockRecorder) AddExpenseFromMessage(ctx, userID, expense, msg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddExpenseFromMessage", reflect.TypeOf((*MockUseCase)(nil).AddExpenseFromMessage), ctx, userID, expense, msg)
+}
+
+// DeleteCategoryAlias mocks base method.
+func (m *MockUseCase) DeleteCategoryAlias(ctx context.Context, userID models.UserID, alias models.ExpenseCategory) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCategoryAlias", ctx, userID, alias)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCategoryAlias indicates an expected call of DeleteCategoryAlias.
+func (mr *MockUseCaseMockRecorder) DeleteCategoryAlias(ctx, userID, alias interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCategoryAlias", reflect.TypeOf((*MockUseCase)(nil).DeleteCategoryAlias), ctx, userID, alias)
+}
+
+// DeleteCategoryRule mocks base method.
+func (m *MockUseCase) DeleteCategoryRule(ctx context.Context, userID models.UserID, id models.CategoryRuleID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCategoryRule", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCategoryRule indicates an expected call of DeleteCategoryRule.
+func (mr *MockUseCaseMockRecorder) DeleteCategoryRule(ctx, userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCategoryRule", reflect.TypeOf((*MockUseCase)(nil).DeleteCategoryRule), ctx, userID, id)
+}
+
+// DeleteExpense mocks base method.
+func (m *MockUseCase) DeleteExpense(ctx context.Context, userID models.UserID, id models.ExpenseID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpense", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpense indicates an expected call of DeleteExpense.
+func (mr *MockUseCaseMockRecorder) DeleteExpense(ctx, userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpense", reflect.TypeOf((*MockUseCase)(nil).DeleteExpense), ctx, userID, id)
+}
+
+// GetCategories mocks base method.
+func (m *MockUseCase) GetCategories(ctx context.Context, userID models.UserID) ([]models.CategoryStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategories", ctx, userID)
+	ret0, _ := ret[0].([]models.CategoryStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategories indicates an expected call of GetCategories.
+func (mr *MockUseCaseMockRecorder) GetCategories(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategories", reflect.TypeOf((*MockUseCase)(nil).GetCategories), ctx, userID)
+}
+
+// GetCategoryAliases mocks base method.
+func (m *MockUseCase) GetCategoryAliases(ctx context.Context, userID models.UserID) (map[models.ExpenseCategory]models.ExpenseCategory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategoryAliases", ctx, userID)
+	ret0, _ := ret[0].(map[models.ExpenseCategory]models.ExpenseCategory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategoryAliases indicates an expected call of GetCategoryAliases.
+func (mr *MockUseCaseMockRecorder) GetCategoryAliases(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategoryAliases", reflect.TypeOf((*MockUseCase)(nil).GetCategoryAliases), ctx, userID)
+}
+
+// GetCategoryRules mocks base method.
+func (m *MockUseCase) GetCategoryRules(ctx context.Context, userID models.UserID) ([]models.CategoryRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategoryRules", ctx, userID)
+	ret0, _ := ret[0].([]models.CategoryRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategoryRules indicates an expected call of GetCategoryRules.
+func (mr *MockUseCaseMockRecorder) GetCategoryRules(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategoryRules", reflect.TypeOf((*MockUseCase)(nil).GetCategoryRules), ctx, userID)
+}
+
+// GetExpenseByID mocks base method.
+func (m *MockUseCase) GetExpenseByID(ctx context.Context, userID models.UserID, id models.ExpenseID) (models.Expense, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpenseByID", ctx, userID, id)
+	ret0, _ := ret[0].(models.Expense)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpenseByID indicates an expected call of GetExpenseByID.
+func (mr *MockUseCaseMockRecorder) GetExpenseByID(ctx, userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpenseByID", reflect.TypeOf((*MockUseCase)(nil).GetExpenseByID), ctx, userID, id)
+}
+
+// GetExpenseIDByMessage mocks base method.
+func (m *MockUseCase) GetExpenseIDByMessage(ctx context.Context, userID models.UserID, msg models.MessageRef) (models.ExpenseID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpenseIDByMessage", ctx, userID, msg)
+	ret0, _ := ret[0].(models.ExpenseID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpenseIDByMessage indicates an expected call of GetExpenseIDByMessage.
+func (mr *MockUseCaseMockRecorder) GetExpenseIDByMessage(ctx, userID, msg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpenseIDByMessage", reflect.TypeOf((*MockUseCase)(nil).GetExpenseIDByMessage), ctx, userID, msg)
+}
+
 // GetExpensesAscendSinceTill mocks base method.
-func (m *MockUseCase) GetExpensesAscendSinceTill(ctx context.Context, userID models.UserID, since, till time.Time, max int) ([]models.Expense, error) {
+func (m *MockUseCase) GetExpensesAscendSinceTill(ctx context.Context, userID models.UserID, since, till time.Time, curr models.CurrencyCode, tag models.ExpenseTag, max int) ([]models.Expense, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetExpensesAscendSinceTill", ctx, userID, since, till, max)
+	ret := m.ctrl.Call(m, "GetExpensesAscendSinceTill", ctx, userID, since, till, curr, tag, max)
 	ret0, _ := ret[0].([]models.Expense)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetExpensesAscendSinceTill indicates an expected call of GetExpensesAscendSinceTill.
-func (mr *MockUseCaseMockRecorder) GetExpensesAscendSinceTill(ctx, userID, since, till, max interface{}) *gomock.Call {
+func (mr *MockUseCaseMockRecorder) GetExpensesAscendSinceTill(ctx, userID, since, till, curr, tag, max interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpensesAscendSinceTill", reflect.TypeOf((*MockUseCase)(nil).GetExpensesAscendSinceTill), ctx, userID, since, till, curr, tag, max)
+}
+
+// GetExpensesSummaryByAuthorSince mocks base method.
+func (m *MockUseCase) GetExpensesSummaryByAuthorSince(ctx context.Context, userID models.UserID, since, till time.Time, curr models.CurrencyCode, tag models.ExpenseTag) (expense.AuthorsSummaryReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpensesSummaryByAuthorSince", ctx, userID, since, till, curr, tag)
+	ret0, _ := ret[0].(expense.AuthorsSummaryReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpensesSummaryByAuthorSince indicates an expected call of GetExpensesSummaryByAuthorSince.
+func (mr *MockUseCaseMockRecorder) GetExpensesSummaryByAuthorSince(ctx, userID, since, till, curr, tag interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpensesAscendSinceTill", reflect.TypeOf((*MockUseCase)(nil).GetExpensesAscendSinceTill), ctx, userID, since, till, max)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpensesSummaryByAuthorSince", reflect.TypeOf((*MockUseCase)(nil).GetExpensesSummaryByAuthorSince), ctx, userID, since, till, curr, tag)
 }
 
 // GetExpensesSummaryByCategorySince mocks base method.
-func (m *MockUseCase) GetExpensesSummaryByCategorySince(ctx context.Context, userID models.UserID, since, till time.Time) (expense.SummaryReport, error) {
+func (m *MockUseCase) GetExpensesSummaryByCategorySince(ctx context.Context, userID models.UserID, since, till time.Time, curr models.CurrencyCode, tag models.ExpenseTag) (expense.SummaryReport, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetExpensesSummaryByCategorySince", ctx, userID, since, till)
+	ret := m.ctrl.Call(m, "GetExpensesSummaryByCategorySince", ctx, userID, since, till, curr, tag)
 	ret0, _ := ret[0].(expense.SummaryReport)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetExpensesSummaryByCategorySince indicates an expected call of GetExpensesSummaryByCategorySince.
-func (mr *MockUseCaseMockRecorder) GetExpensesSummaryByCategorySince(ctx, userID, since, till interface{}) *gomock.Call {
+func (mr *MockUseCaseMockRecorder) GetExpensesSummaryByCategorySince(ctx, userID, since, till, curr, tag interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpensesSummaryByCategorySince", reflect.TypeOf((*MockUseCase)(nil).GetExpensesSummaryByCategorySince), ctx, userID, since, till)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpensesSummaryByCategorySince", reflect.TypeOf((*MockUseCase)(nil).GetExpensesSummaryByCategorySince), ctx, userID, since, till, curr, tag)
+}
+
+// GetExpensesSummaryByTagSince mocks base method.
+func (m *MockUseCase) GetExpensesSummaryByTagSince(ctx context.Context, userID models.UserID, since, till time.Time, curr models.CurrencyCode) (expense.TagsSummaryReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpensesSummaryByTagSince", ctx, userID, since, till, curr)
+	ret0, _ := ret[0].(expense.TagsSummaryReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpensesSummaryByTagSince indicates an expected call of GetExpensesSummaryByTagSince.
+func (mr *MockUseCaseMockRecorder) GetExpensesSummaryByTagSince(ctx, userID, since, till, curr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpensesSummaryByTagSince", reflect.TypeOf((*MockUseCase)(nil).GetExpensesSummaryByTagSince), ctx, userID, since, till, curr)
+}
+
+// GetLimitHistory mocks base method.
+func (m *MockUseCase) GetLimitHistory(ctx context.Context, userID models.UserID, period models.LimitPeriod, category models.ExpenseCategory) ([]models.LimitStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLimitHistory", ctx, userID, period, category)
+	ret0, _ := ret[0].([]models.LimitStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLimitHistory indicates an expected call of GetLimitHistory.
+func (mr *MockUseCaseMockRecorder) GetLimitHistory(ctx, userID, period, category interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLimitHistory", reflect.TypeOf((*MockUseCase)(nil).GetLimitHistory), ctx, userID, period, category)
+}
+
+// GetLimitsStatus mocks base method.
+func (m *MockUseCase) GetLimitsStatus(ctx context.Context, userID models.UserID) ([]models.LimitStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLimitsStatus", ctx, userID)
+	ret0, _ := ret[0].([]models.LimitStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLimitsStatus indicates an expected call of GetLimitsStatus.
+func (mr *MockUseCaseMockRecorder) GetLimitsStatus(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLimitsStatus", reflect.TypeOf((*MockUseCase)(nil).GetLimitsStatus), ctx, userID)
+}
+
+// MatchCategoryRule mocks base method.
+func (m *MockUseCase) MatchCategoryRule(ctx context.Context, userID models.UserID, text string) (models.CategoryRule, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MatchCategoryRule", ctx, userID, text)
+	ret0, _ := ret[0].(models.CategoryRule)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// MatchCategoryRule indicates an expected call of MatchCategoryRule.
+func (mr *MockUseCaseMockRecorder) MatchCategoryRule(ctx, userID, text interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MatchCategoryRule", reflect.TypeOf((*MockUseCase)(nil).MatchCategoryRule), ctx, userID, text)
+}
+
+// MergeCategory mocks base method.
+func (m *MockUseCase) MergeCategory(ctx context.Context, userID models.UserID, from, to models.ExpenseCategory) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeCategory", ctx, userID, from, to)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MergeCategory indicates an expected call of MergeCategory.
+func (mr *MockUseCaseMockRecorder) MergeCategory(ctx, userID, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeCategory", reflect.TypeOf((*MockUseCase)(nil).MergeCategory), ctx, userID, from, to)
+}
+
+// RefundExpense mocks base method.
+func (m *MockUseCase) RefundExpense(ctx context.Context, userID models.UserID, id models.ExpenseID, amount decimal.Decimal) (models.Expense, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefundExpense", ctx, userID, id, amount)
+	ret0, _ := ret[0].(models.Expense)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefundExpense indicates an expected call of RefundExpense.
+func (mr *MockUseCaseMockRecorder) RefundExpense(ctx, userID, id, amount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefundExpense", reflect.TypeOf((*MockUseCase)(nil).RefundExpense), ctx, userID, id, amount)
+}
+
+// RenameCategory mocks base method.
+func (m *MockUseCase) RenameCategory(ctx context.Context, userID models.UserID, from, to models.ExpenseCategory) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameCategory", ctx, userID, from, to)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenameCategory indicates an expected call of RenameCategory.
+func (mr *MockUseCaseMockRecorder) RenameCategory(ctx, userID, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameCategory", reflect.TypeOf((*MockUseCase)(nil).RenameCategory), ctx, userID, from, to)
+}
+
+// SendLimitNotification mocks base method.
+func (m *MockUseCase) SendLimitNotification(ctx context.Context, userID models.UserID, notification string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SendLimitNotification", ctx, userID, notification)
+}
+
+// SendLimitNotification indicates an expected call of SendLimitNotification.
+func (mr *MockUseCaseMockRecorder) SendLimitNotification(ctx, userID, notification interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendLimitNotification", reflect.TypeOf((*MockUseCase)(nil).SendLimitNotification), ctx, userID, notification)
+}
+
+// SetCategoryAlias mocks base method.
+func (m *MockUseCase) SetCategoryAlias(ctx context.Context, userID models.UserID, alias, category models.ExpenseCategory) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCategoryAlias", ctx, userID, alias, category)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCategoryAlias indicates an expected call of SetCategoryAlias.
+func (mr *MockUseCaseMockRecorder) SetCategoryAlias(ctx, userID, alias, category interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCategoryAlias", reflect.TypeOf((*MockUseCase)(nil).SetCategoryAlias), ctx, userID, alias, category)
+}
+
+// SuggestCategories mocks base method.
+func (m *MockUseCase) SuggestCategories(ctx context.Context, userID models.UserID, exp models.Expense) ([]models.ExpenseCategory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SuggestCategories", ctx, userID, exp)
+	ret0, _ := ret[0].([]models.ExpenseCategory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SuggestCategories indicates an expected call of SuggestCategories.
+func (mr *MockUseCaseMockRecorder) SuggestCategories(ctx, userID, exp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuggestCategories", reflect.TypeOf((*MockUseCase)(nil).SuggestCategories), ctx, userID, exp)
+}
+
+// UpdateExpense mocks base method.
+func (m *MockUseCase) UpdateExpense(ctx context.Context, userID models.UserID, expense models.Expense) (models.Expense, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateExpense", ctx, userID, expense)
+	ret0, _ := ret[0].(models.Expense)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateExpense indicates an expected call of UpdateExpense.
+func (mr *MockUseCaseMockRecorder) UpdateExpense(ctx, userID, expense interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateExpense", reflect.TypeOf((*MockUseCase)(nil).UpdateExpense), ctx, userID, expense)
 }
 
 // MockExtendedUseCase is a mock of ExtendedUseCase interface.
@@ -105,6 +444,21 @@ func (m *MockExtendedUseCase) EXPECT() *MockExtendedUseCaseMockRecorder {
 	return m.recorder
 }
 
+// AddCategoryRule mocks base method.
+func (m *MockExtendedUseCase) AddCategoryRule(ctx context.Context, userID models.UserID, rule models.CategoryRule) (models.CategoryRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCategoryRule", ctx, userID, rule)
+	ret0, _ := ret[0].(models.CategoryRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddCategoryRule indicates an expected call of AddCategoryRule.
+func (mr *MockExtendedUseCaseMockRecorder) AddCategoryRule(ctx, userID, rule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCategoryRule", reflect.TypeOf((*MockExtendedUseCase)(nil).AddCategoryRule), ctx, userID, rule)
+}
+
 // AddExpense mocks base method.
 func (m *MockExtendedUseCase) AddExpense(ctx context.Context, userID models.UserID, expense models.Expense) (models.Expense, error) {
 	m.ctrl.T.Helper()
@@ -120,48 +474,408 @@ func (mr *MockExtendedUseCaseMockRecorder) AddExpense(ctx, userID, expense inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddExpense", reflect.TypeOf((*MockExtendedUseCase)(nil).AddExpense), ctx, userID, expense)
 }
 
+// AddExpenseDeferringNotification mocks base method.
+func (m *MockExtendedUseCase) AddExpenseDeferringNotification(ctx context.Context, userID models.UserID, expense models.Expense) (models.Expense, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddExpenseDeferringNotification", ctx, userID, expense)
+	ret0, _ := ret[0].(models.Expense)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AddExpenseDeferringNotification indicates an expected call of AddExpenseDeferringNotification.
+func (mr *MockExtendedUseCaseMockRecorder) AddExpenseDeferringNotification(ctx, userID, expense interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddExpenseDeferringNotification", reflect.TypeOf((*MockExtendedUseCase)(nil).AddExpenseDeferringNotification), ctx, userID, expense)
+}
+
+// AddExpenseFromMessage mocks base method.
+func (m *MockExtendedUseCase) AddExpenseFromMessage(ctx context.Context, userID models.UserID, expense models.Expense, msg models.MessageRef) (models.Expense, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddExpenseFromMessage", ctx, userID, expense, msg)
+	ret0, _ := ret[0].(models.Expense)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddExpenseFromMessage indicates an expected call of AddExpenseFromMessage.
+func (mr *MockExtendedUseCaseMockRecorder) AddExpenseFromMessage(ctx, userID, expense, msg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddExpenseFromMessage", reflect.TypeOf((*MockExtendedUseCase)(nil).AddExpenseFromMessage), ctx, userID, expense, msg)
+}
+
+// DeleteCategoryAlias mocks base method.
+func (m *MockExtendedUseCase) DeleteCategoryAlias(ctx context.Context, userID models.UserID, alias models.ExpenseCategory) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCategoryAlias", ctx, userID, alias)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCategoryAlias indicates an expected call of DeleteCategoryAlias.
+func (mr *MockExtendedUseCaseMockRecorder) DeleteCategoryAlias(ctx, userID, alias interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCategoryAlias", reflect.TypeOf((*MockExtendedUseCase)(nil).DeleteCategoryAlias), ctx, userID, alias)
+}
+
+// DeleteCategoryRule mocks base method.
+func (m *MockExtendedUseCase) DeleteCategoryRule(ctx context.Context, userID models.UserID, id models.CategoryRuleID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCategoryRule", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCategoryRule indicates an expected call of DeleteCategoryRule.
+func (mr *MockExtendedUseCaseMockRecorder) DeleteCategoryRule(ctx, userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCategoryRule", reflect.TypeOf((*MockExtendedUseCase)(nil).DeleteCategoryRule), ctx, userID, id)
+}
+
+// DeleteExpense mocks base method.
+func (m *MockExtendedUseCase) DeleteExpense(ctx context.Context, userID models.UserID, id models.ExpenseID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpense", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpense indicates an expected call of DeleteExpense.
+func (mr *MockExtendedUseCaseMockRecorder) DeleteExpense(ctx, userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpense", reflect.TypeOf((*MockExtendedUseCase)(nil).DeleteExpense), ctx, userID, id)
+}
+
+// GetCategories mocks base method.
+func (m *MockExtendedUseCase) GetCategories(ctx context.Context, userID models.UserID) ([]models.CategoryStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategories", ctx, userID)
+	ret0, _ := ret[0].([]models.CategoryStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategories indicates an expected call of GetCategories.
+func (mr *MockExtendedUseCaseMockRecorder) GetCategories(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategories", reflect.TypeOf((*MockExtendedUseCase)(nil).GetCategories), ctx, userID)
+}
+
+// GetCategoryAliases mocks base method.
+func (m *MockExtendedUseCase) GetCategoryAliases(ctx context.Context, userID models.UserID) (map[models.ExpenseCategory]models.ExpenseCategory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategoryAliases", ctx, userID)
+	ret0, _ := ret[0].(map[models.ExpenseCategory]models.ExpenseCategory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategoryAliases indicates an expected call of GetCategoryAliases.
+func (mr *MockExtendedUseCaseMockRecorder) GetCategoryAliases(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategoryAliases", reflect.TypeOf((*MockExtendedUseCase)(nil).GetCategoryAliases), ctx, userID)
+}
+
+// GetCategoryRules mocks base method.
+func (m *MockExtendedUseCase) GetCategoryRules(ctx context.Context, userID models.UserID) ([]models.CategoryRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategoryRules", ctx, userID)
+	ret0, _ := ret[0].([]models.CategoryRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategoryRules indicates an expected call of GetCategoryRules.
+func (mr *MockExtendedUseCaseMockRecorder) GetCategoryRules(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategoryRules", reflect.TypeOf((*MockExtendedUseCase)(nil).GetCategoryRules), ctx, userID)
+}
+
+// GetExpenseByID mocks base method.
+func (m *MockExtendedUseCase) GetExpenseByID(ctx context.Context, userID models.UserID, id models.ExpenseID) (models.Expense, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpenseByID", ctx, userID, id)
+	ret0, _ := ret[0].(models.Expense)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpenseByID indicates an expected call of GetExpenseByID.
+func (mr *MockExtendedUseCaseMockRecorder) GetExpenseByID(ctx, userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpenseByID", reflect.TypeOf((*MockExtendedUseCase)(nil).GetExpenseByID), ctx, userID, id)
+}
+
+// GetExpenseIDByMessage mocks base method.
+func (m *MockExtendedUseCase) GetExpenseIDByMessage(ctx context.Context, userID models.UserID, msg models.MessageRef) (models.ExpenseID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpenseIDByMessage", ctx, userID, msg)
+	ret0, _ := ret[0].(models.ExpenseID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpenseIDByMessage indicates an expected call of GetExpenseIDByMessage.
+func (mr *MockExtendedUseCaseMockRecorder) GetExpenseIDByMessage(ctx, userID, msg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpenseIDByMessage", reflect.TypeOf((*MockExtendedUseCase)(nil).GetExpenseIDByMessage), ctx, userID, msg)
+}
+
 // GetExpensesAscendSinceTill mocks base method.
-func (m *MockExtendedUseCase) GetExpensesAscendSinceTill(ctx context.Context, userID models.UserID, since, till time.Time, max int) ([]models.Expense, error) {
+func (m *MockExtendedUseCase) GetExpensesAscendSinceTill(ctx context.Context, userID models.UserID, since, till time.Time, curr models.CurrencyCode, tag models.ExpenseTag, max int) ([]models.Expense, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetExpensesAscendSinceTill", ctx, userID, since, till, max)
+	ret := m.ctrl.Call(m, "GetExpensesAscendSinceTill", ctx, userID, since, till, curr, tag, max)
 	ret0, _ := ret[0].([]models.Expense)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetExpensesAscendSinceTill indicates an expected call of GetExpensesAscendSinceTill.
-func (mr *MockExtendedUseCaseMockRecorder) GetExpensesAscendSinceTill(ctx, userID, since, till, max interface{}) *gomock.Call {
+func (mr *MockExtendedUseCaseMockRecorder) GetExpensesAscendSinceTill(ctx, userID, since, till, curr, tag, max interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpensesAscendSinceTill", reflect.TypeOf((*MockExtendedUseCase)(nil).GetExpensesAscendSinceTill), ctx, userID, since, till, curr, tag, max)
+}
+
+// GetExpensesSummaryByAuthorSince mocks base method.
+func (m *MockExtendedUseCase) GetExpensesSummaryByAuthorSince(ctx context.Context, userID models.UserID, since, till time.Time, curr models.CurrencyCode, tag models.ExpenseTag) (expense.AuthorsSummaryReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpensesSummaryByAuthorSince", ctx, userID, since, till, curr, tag)
+	ret0, _ := ret[0].(expense.AuthorsSummaryReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpensesSummaryByAuthorSince indicates an expected call of GetExpensesSummaryByAuthorSince.
+func (mr *MockExtendedUseCaseMockRecorder) GetExpensesSummaryByAuthorSince(ctx, userID, since, till, curr, tag interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpensesAscendSinceTill", reflect.TypeOf((*MockExtendedUseCase)(nil).GetExpensesAscendSinceTill), ctx, userID, since, till, max)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpensesSummaryByAuthorSince", reflect.TypeOf((*MockExtendedUseCase)(nil).GetExpensesSummaryByAuthorSince), ctx, userID, since, till, curr, tag)
 }
 
 // GetExpensesSummaryByCategorySince mocks base method.
-func (m *MockExtendedUseCase) GetExpensesSummaryByCategorySince(ctx context.Context, userID models.UserID, since, till time.Time) (expense.SummaryReport, error) {
+func (m *MockExtendedUseCase) GetExpensesSummaryByCategorySince(ctx context.Context, userID models.UserID, since, till time.Time, curr models.CurrencyCode, tag models.ExpenseTag) (expense.SummaryReport, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetExpensesSummaryByCategorySince", ctx, userID, since, till)
+	ret := m.ctrl.Call(m, "GetExpensesSummaryByCategorySince", ctx, userID, since, till, curr, tag)
 	ret0, _ := ret[0].(expense.SummaryReport)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetExpensesSummaryByCategorySince indicates an expected call of GetExpensesSummaryByCategorySince.
-func (mr *MockExtendedUseCaseMockRecorder) GetExpensesSummaryByCategorySince(ctx, userID, since, till interface{}) *gomock.Call {
+func (mr *MockExtendedUseCaseMockRecorder) GetExpensesSummaryByCategorySince(ctx, userID, since, till, curr, tag interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpensesSummaryByCategorySince", reflect.TypeOf((*MockExtendedUseCase)(nil).GetExpensesSummaryByCategorySince), ctx, userID, since, till, curr, tag)
+}
+
+// GetExpensesSummaryByTagSince mocks base method.
+func (m *MockExtendedUseCase) GetExpensesSummaryByTagSince(ctx context.Context, userID models.UserID, since, till time.Time, curr models.CurrencyCode) (expense.TagsSummaryReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpensesSummaryByTagSince", ctx, userID, since, till, curr)
+	ret0, _ := ret[0].(expense.TagsSummaryReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpensesSummaryByTagSince indicates an expected call of GetExpensesSummaryByTagSince.
+func (mr *MockExtendedUseCaseMockRecorder) GetExpensesSummaryByTagSince(ctx, userID, since, till, curr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpensesSummaryByTagSince", reflect.TypeOf((*MockExtendedUseCase)(nil).GetExpensesSummaryByTagSince), ctx, userID, since, till, curr)
+}
+
+// GetLimitHistory mocks base method.
+func (m *MockExtendedUseCase) GetLimitHistory(ctx context.Context, userID models.UserID, period models.LimitPeriod, category models.ExpenseCategory) ([]models.LimitStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLimitHistory", ctx, userID, period, category)
+	ret0, _ := ret[0].([]models.LimitStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLimitHistory indicates an expected call of GetLimitHistory.
+func (mr *MockExtendedUseCaseMockRecorder) GetLimitHistory(ctx, userID, period, category interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLimitHistory", reflect.TypeOf((*MockExtendedUseCase)(nil).GetLimitHistory), ctx, userID, period, category)
+}
+
+// GetLimitsStatus mocks base method.
+func (m *MockExtendedUseCase) GetLimitsStatus(ctx context.Context, userID models.UserID) ([]models.LimitStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLimitsStatus", ctx, userID)
+	ret0, _ := ret[0].([]models.LimitStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLimitsStatus indicates an expected call of GetLimitsStatus.
+func (mr *MockExtendedUseCaseMockRecorder) GetLimitsStatus(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLimitsStatus", reflect.TypeOf((*MockExtendedUseCase)(nil).GetLimitsStatus), ctx, userID)
+}
+
+// MatchCategoryRule mocks base method.
+func (m *MockExtendedUseCase) MatchCategoryRule(ctx context.Context, userID models.UserID, text string) (models.CategoryRule, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MatchCategoryRule", ctx, userID, text)
+	ret0, _ := ret[0].(models.CategoryRule)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// MatchCategoryRule indicates an expected call of MatchCategoryRule.
+func (mr *MockExtendedUseCaseMockRecorder) MatchCategoryRule(ctx, userID, text interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MatchCategoryRule", reflect.TypeOf((*MockExtendedUseCase)(nil).MatchCategoryRule), ctx, userID, text)
+}
+
+// MergeCategory mocks base method.
+func (m *MockExtendedUseCase) MergeCategory(ctx context.Context, userID models.UserID, from, to models.ExpenseCategory) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeCategory", ctx, userID, from, to)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MergeCategory indicates an expected call of MergeCategory.
+func (mr *MockExtendedUseCaseMockRecorder) MergeCategory(ctx, userID, from, to interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpensesSummaryByCategorySince", reflect.TypeOf((*MockExtendedUseCase)(nil).GetExpensesSummaryByCategorySince), ctx, userID, since, till)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeCategory", reflect.TypeOf((*MockExtendedUseCase)(nil).MergeCategory), ctx, userID, from, to)
+}
+
+// RefundExpense mocks base method.
+func (m *MockExtendedUseCase) RefundExpense(ctx context.Context, userID models.UserID, id models.ExpenseID, amount decimal.Decimal) (models.Expense, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefundExpense", ctx, userID, id, amount)
+	ret0, _ := ret[0].(models.Expense)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefundExpense indicates an expected call of RefundExpense.
+func (mr *MockExtendedUseCaseMockRecorder) RefundExpense(ctx, userID, id, amount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefundExpense", reflect.TypeOf((*MockExtendedUseCase)(nil).RefundExpense), ctx, userID, id, amount)
+}
+
+// RenameCategory mocks base method.
+func (m *MockExtendedUseCase) RenameCategory(ctx context.Context, userID models.UserID, from, to models.ExpenseCategory) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameCategory", ctx, userID, from, to)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenameCategory indicates an expected call of RenameCategory.
+func (mr *MockExtendedUseCaseMockRecorder) RenameCategory(ctx, userID, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameCategory", reflect.TypeOf((*MockExtendedUseCase)(nil).RenameCategory), ctx, userID, from, to)
 }
 
 // SendGetExpensesSummaryByCategorySinceRequest mocks base method.
-func (m *MockExtendedUseCase) SendGetExpensesSummaryByCategorySinceRequest(ctx context.Context, chatID int64, userID models.UserID, since, till time.Time) error {
+func (m *MockExtendedUseCase) SendGetExpensesSummaryByCategorySinceRequest(ctx context.Context, chatID int64, userID models.UserID, since, till time.Time, curr models.CurrencyCode, tag models.ExpenseTag) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SendGetExpensesSummaryByCategorySinceRequest", ctx, chatID, userID, since, till)
+	ret := m.ctrl.Call(m, "SendGetExpensesSummaryByCategorySinceRequest", ctx, chatID, userID, since, till, curr, tag)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SendGetExpensesSummaryByCategorySinceRequest indicates an expected call of SendGetExpensesSummaryByCategorySinceRequest.
-func (mr *MockExtendedUseCaseMockRecorder) SendGetExpensesSummaryByCategorySinceRequest(ctx, chatID, userID, since, till interface{}) *gomock.Call {
+func (mr *MockExtendedUseCaseMockRecorder) SendGetExpensesSummaryByCategorySinceRequest(ctx, chatID, userID, since, till, curr, tag interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendGetExpensesSummaryByCategorySinceRequest", reflect.TypeOf((*MockExtendedUseCase)(nil).SendGetExpensesSummaryByCategorySinceRequest), ctx, chatID, userID, since, till, curr, tag)
+}
+
+// SendLimitNotification mocks base method.
+func (m *MockExtendedUseCase) SendLimitNotification(ctx context.Context, userID models.UserID, notification string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SendLimitNotification", ctx, userID, notification)
+}
+
+// SendLimitNotification indicates an expected call of SendLimitNotification.
+func (mr *MockExtendedUseCaseMockRecorder) SendLimitNotification(ctx, userID, notification interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendLimitNotification", reflect.TypeOf((*MockExtendedUseCase)(nil).SendLimitNotification), ctx, userID, notification)
+}
+
+// SetCategoryAlias mocks base method.
+func (m *MockExtendedUseCase) SetCategoryAlias(ctx context.Context, userID models.UserID, alias, category models.ExpenseCategory) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCategoryAlias", ctx, userID, alias, category)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetCategoryAlias indicates an expected call of SetCategoryAlias.
+func (mr *MockExtendedUseCaseMockRecorder) SetCategoryAlias(ctx, userID, alias, category interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCategoryAlias", reflect.TypeOf((*MockExtendedUseCase)(nil).SetCategoryAlias), ctx, userID, alias, category)
+}
+
+// SuggestCategories mocks base method.
+func (m *MockExtendedUseCase) SuggestCategories(ctx context.Context, userID models.UserID, exp models.Expense) ([]models.ExpenseCategory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SuggestCategories", ctx, userID, exp)
+	ret0, _ := ret[0].([]models.ExpenseCategory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SuggestCategories indicates an expected call of SuggestCategories.
+func (mr *MockExtendedUseCaseMockRecorder) SuggestCategories(ctx, userID, exp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuggestCategories", reflect.TypeOf((*MockExtendedUseCase)(nil).SuggestCategories), ctx, userID, exp)
+}
+
+// UpdateExpense mocks base method.
+func (m *MockExtendedUseCase) UpdateExpense(ctx context.Context, userID models.UserID, expense models.Expense) (models.Expense, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateExpense", ctx, userID, expense)
+	ret0, _ := ret[0].(models.Expense)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateExpense indicates an expected call of UpdateExpense.
+func (mr *MockExtendedUseCaseMockRecorder) UpdateExpense(ctx, userID, expense interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateExpense", reflect.TypeOf((*MockExtendedUseCase)(nil).UpdateExpense), ctx, userID, expense)
+}
+
+// MockMessageSender is a mock of MessageSender interface.
+type MockMessageSender struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessageSenderMockRecorder
+}
+
+// MockMessageSenderMockRecorder is the mock recorder for MockMessageSender.
+type MockMessageSenderMockRecorder struct {
+	mock *MockMessageSender
+}
+
+// NewMockMessageSender creates a new mock instance.
+func NewMockMessageSender(ctrl *gomock.Controller) *MockMessageSender {
+	mock := &MockMessageSender{ctrl: ctrl}
+	mock.recorder = &MockMessageSenderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMessageSender) EXPECT() *MockMessageSenderMockRecorder {
+	return m.recorder
+}
+
+// SendMessage mocks base method.
+func (m *MockMessageSender) SendMessage(chatID int64, message string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendMessage", chatID, message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendMessage indicates an expected call of SendMessage.
+func (mr *MockMessageSenderMockRecorder) SendMessage(chatID, message interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendGetExpensesSummaryByCategorySinceRequest", reflect.TypeOf((*MockExtendedUseCase)(nil).SendGetExpensesSummaryByCategorySinceRequest), ctx, chatID, userID, since, till)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMessage", reflect.TypeOf((*MockMessageSender)(nil).SendMessage), chatID, message)
 }
 
 // MockReportsCache is a mock of ReportsCache interface.
@@ -188,17 +902,17 @@ func (m *MockReportsCache) EXPECT() *MockReportsCacheMockRecorder {
 }
 
 // AddToCache mocks base method.
-func (m *MockReportsCache) AddToCache(ctx context.Context, userID models.UserID, since, till time.Time, report expense.SummaryReport) error {
+func (m *MockReportsCache) AddToCache(ctx context.Context, userID models.UserID, key expense.ReportCacheKey, report expense.SummaryReport) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddToCache", ctx, userID, since, till, report)
+	ret := m.ctrl.Call(m, "AddToCache", ctx, userID, key, report)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // AddToCache indicates an expected call of AddToCache.
-func (mr *MockReportsCacheMockRecorder) AddToCache(ctx, userID, since, till, report interface{}) *gomock.Call {
+func (mr *MockReportsCacheMockRecorder) AddToCache(ctx, userID, key, report interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddToCache", reflect.TypeOf((*MockReportsCache)(nil).AddToCache), ctx, userID, since, till, report)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddToCache", reflect.TypeOf((*MockReportsCache)(nil).AddToCache), ctx, userID, key, report)
 }
 
 // DropCacheForUserID mocks base method.
@@ -216,9 +930,9 @@ func (mr *MockReportsCacheMockRecorder) DropCacheForUserID(ctx, userID interface
 }
 
 // GetFromCache mocks base method.
-func (m *MockReportsCache) GetFromCache(ctx context.Context, userID models.UserID, since, till time.Time) (expense.SummaryReport, bool, error) {
+func (m *MockReportsCache) GetFromCache(ctx context.Context, userID models.UserID, key expense.ReportCacheKey) (expense.SummaryReport, bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetFromCache", ctx, userID, since, till)
+	ret := m.ctrl.Call(m, "GetFromCache", ctx, userID, key)
 	ret0, _ := ret[0].(expense.SummaryReport)
 	ret1, _ := ret[1].(bool)
 	ret2, _ := ret[2].(error)
@@ -226,7 +940,7 @@ func (m *MockReportsCache) GetFromCache(ctx context.Context, userID models.UserI
 }
 
 // GetFromCache indicates an expected call of GetFromCache.
-func (mr *MockReportsCacheMockRecorder) GetFromCache(ctx, userID, since, till interface{}) *gomock.Call {
+func (mr *MockReportsCacheMockRecorder) GetFromCache(ctx, userID, key interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFromCache", reflect.TypeOf((*MockReportsCache)(nil).GetFromCache), ctx, userID, since, till)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFromCache", reflect.TypeOf((*MockReportsCache)(nil).GetFromCache), ctx, userID, key)
 }
@@ -7,12 +7,224 @@ package mock_user
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
-	decimal "github.com/shopspring/decimal"
 	models "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
 )
 
+// MockSettings is a mock of Settings interface.
+type MockSettings struct {
+	ctrl     *gomock.Controller
+	recorder *MockSettingsMockRecorder
+}
+
+// MockSettingsMockRecorder is the mock recorder for MockSettings.
+type MockSettingsMockRecorder struct {
+	mock *MockSettings
+}
+
+// NewMockSettings creates a new mock instance.
+func NewMockSettings(ctrl *gomock.Controller) *MockSettings {
+	mock := &MockSettings{ctrl: ctrl}
+	mock.recorder = &MockSettingsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSettings) EXPECT() *MockSettingsMockRecorder {
+	return m.recorder
+}
+
+// ChangeUserCurrency mocks base method.
+func (m *MockSettings) ChangeUserCurrency(ctx context.Context, id models.UserID, currency models.CurrencyCode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeUserCurrency", ctx, id, currency)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangeUserCurrency indicates an expected call of ChangeUserCurrency.
+func (mr *MockSettingsMockRecorder) ChangeUserCurrency(ctx, id, currency interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeUserCurrency", reflect.TypeOf((*MockSettings)(nil).ChangeUserCurrency), ctx, id, currency)
+}
+
+// CreateUser mocks base method.
+func (m *MockSettings) CreateUser(ctx context.Context, u models.User) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, u)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockSettingsMockRecorder) CreateUser(ctx, u interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockSettings)(nil).CreateUser), ctx, u)
+}
+
+// DeleteUserLimit mocks base method.
+func (m *MockSettings) DeleteUserLimit(ctx context.Context, id models.UserID, period models.LimitPeriod, category models.ExpenseCategory) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserLimit", ctx, id, period, category)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserLimit indicates an expected call of DeleteUserLimit.
+func (mr *MockSettingsMockRecorder) DeleteUserLimit(ctx, id, period, category interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserLimit", reflect.TypeOf((*MockSettings)(nil).DeleteUserLimit), ctx, id, period, category)
+}
+
+// GetUserCurrency mocks base method.
+func (m *MockSettings) GetUserCurrency(ctx context.Context, id models.UserID) (models.CurrencyCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserCurrency", ctx, id)
+	ret0, _ := ret[0].(models.CurrencyCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserCurrency indicates an expected call of GetUserCurrency.
+func (mr *MockSettingsMockRecorder) GetUserCurrency(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserCurrency", reflect.TypeOf((*MockSettings)(nil).GetUserCurrency), ctx, id)
+}
+
+// GetUserLimitMode mocks base method.
+func (m *MockSettings) GetUserLimitMode(ctx context.Context, id models.UserID) (models.LimitMode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserLimitMode", ctx, id)
+	ret0, _ := ret[0].(models.LimitMode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserLimitMode indicates an expected call of GetUserLimitMode.
+func (mr *MockSettingsMockRecorder) GetUserLimitMode(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserLimitMode", reflect.TypeOf((*MockSettings)(nil).GetUserLimitMode), ctx, id)
+}
+
+// GetUserLimits mocks base method.
+func (m *MockSettings) GetUserLimits(ctx context.Context, id models.UserID) ([]models.Limit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserLimits", ctx, id)
+	ret0, _ := ret[0].([]models.Limit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserLimits indicates an expected call of GetUserLimits.
+func (mr *MockSettingsMockRecorder) GetUserLimits(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserLimits", reflect.TypeOf((*MockSettings)(nil).GetUserLimits), ctx, id)
+}
+
+// GetUserPeriodStartDay mocks base method.
+func (m *MockSettings) GetUserPeriodStartDay(ctx context.Context, id models.UserID) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserPeriodStartDay", ctx, id)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserPeriodStartDay indicates an expected call of GetUserPeriodStartDay.
+func (mr *MockSettingsMockRecorder) GetUserPeriodStartDay(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserPeriodStartDay", reflect.TypeOf((*MockSettings)(nil).GetUserPeriodStartDay), ctx, id)
+}
+
+// GetUserTimeZone mocks base method.
+func (m *MockSettings) GetUserTimeZone(ctx context.Context, id models.UserID) (*time.Location, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserTimeZone", ctx, id)
+	ret0, _ := ret[0].(*time.Location)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserTimeZone indicates an expected call of GetUserTimeZone.
+func (mr *MockSettingsMockRecorder) GetUserTimeZone(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserTimeZone", reflect.TypeOf((*MockSettings)(nil).GetUserTimeZone), ctx, id)
+}
+
+// IsUserExists mocks base method.
+func (m *MockSettings) IsUserExists(ctx context.Context, id models.UserID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsUserExists", ctx, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsUserExists indicates an expected call of IsUserExists.
+func (mr *MockSettingsMockRecorder) IsUserExists(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsUserExists", reflect.TypeOf((*MockSettings)(nil).IsUserExists), ctx, id)
+}
+
+// SetUserLimitMode mocks base method.
+func (m *MockSettings) SetUserLimitMode(ctx context.Context, id models.UserID, mode models.LimitMode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserLimitMode", ctx, id, mode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserLimitMode indicates an expected call of SetUserLimitMode.
+func (mr *MockSettingsMockRecorder) SetUserLimitMode(ctx, id, mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserLimitMode", reflect.TypeOf((*MockSettings)(nil).SetUserLimitMode), ctx, id, mode)
+}
+
+// SetUserLimits mocks base method.
+func (m *MockSettings) SetUserLimits(ctx context.Context, id models.UserID, limits []models.Limit) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserLimits", ctx, id, limits)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserLimits indicates an expected call of SetUserLimits.
+func (mr *MockSettingsMockRecorder) SetUserLimits(ctx, id, limits interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserLimits", reflect.TypeOf((*MockSettings)(nil).SetUserLimits), ctx, id, limits)
+}
+
+// SetUserPeriodStartDay mocks base method.
+func (m *MockSettings) SetUserPeriodStartDay(ctx context.Context, id models.UserID, day int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserPeriodStartDay", ctx, id, day)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserPeriodStartDay indicates an expected call of SetUserPeriodStartDay.
+func (mr *MockSettingsMockRecorder) SetUserPeriodStartDay(ctx, id, day interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserPeriodStartDay", reflect.TypeOf((*MockSettings)(nil).SetUserPeriodStartDay), ctx, id, day)
+}
+
+// SetUserTimeZone mocks base method.
+func (m *MockSettings) SetUserTimeZone(ctx context.Context, id models.UserID, loc *time.Location) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserTimeZone", ctx, id, loc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserTimeZone indicates an expected call of SetUserTimeZone.
+func (mr *MockSettingsMockRecorder) SetUserTimeZone(ctx, id, loc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserTimeZone", reflect.TypeOf((*MockSettings)(nil).SetUserTimeZone), ctx, id, loc)
+}
+
 // MockRepository is a mock of Repository interface.
 type MockRepository struct {
 	ctrl     *gomock.Controller
@@ -65,6 +277,20 @@ func (mr *MockRepositoryMockRecorder) CreateUser(ctx, u interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockRepository)(nil).CreateUser), ctx, u)
 }
 
+// DeleteUserLimit mocks base method.
+func (m *MockRepository) DeleteUserLimit(ctx context.Context, id models.UserID, period models.LimitPeriod, category models.ExpenseCategory) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserLimit", ctx, id, period, category)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserLimit indicates an expected call of DeleteUserLimit.
+func (mr *MockRepositoryMockRecorder) DeleteUserLimit(ctx, id, period, category interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserLimit", reflect.TypeOf((*MockRepository)(nil).DeleteUserLimit), ctx, id, period, category)
+}
+
 // GetUserCurrency mocks base method.
 func (m *MockRepository) GetUserCurrency(ctx context.Context, id models.UserID) (models.CurrencyCode, error) {
 	m.ctrl.T.Helper()
@@ -80,19 +306,64 @@ func (mr *MockRepositoryMockRecorder) GetUserCurrency(ctx, id interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserCurrency", reflect.TypeOf((*MockRepository)(nil).GetUserCurrency), ctx, id)
 }
 
-// GetUserMonthlyLimit mocks base method.
-func (m *MockRepository) GetUserMonthlyLimit(ctx context.Context, id models.UserID) (*decimal.Decimal, error) {
+// GetUserLimitMode mocks base method.
+func (m *MockRepository) GetUserLimitMode(ctx context.Context, id models.UserID) (models.LimitMode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserLimitMode", ctx, id)
+	ret0, _ := ret[0].(models.LimitMode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserLimitMode indicates an expected call of GetUserLimitMode.
+func (mr *MockRepositoryMockRecorder) GetUserLimitMode(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserLimitMode", reflect.TypeOf((*MockRepository)(nil).GetUserLimitMode), ctx, id)
+}
+
+// GetUserLimits mocks base method.
+func (m *MockRepository) GetUserLimits(ctx context.Context, id models.UserID) ([]models.Limit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserLimits", ctx, id)
+	ret0, _ := ret[0].([]models.Limit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserLimits indicates an expected call of GetUserLimits.
+func (mr *MockRepositoryMockRecorder) GetUserLimits(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserLimits", reflect.TypeOf((*MockRepository)(nil).GetUserLimits), ctx, id)
+}
+
+// GetUserPeriodStartDay mocks base method.
+func (m *MockRepository) GetUserPeriodStartDay(ctx context.Context, id models.UserID) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserPeriodStartDay", ctx, id)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserPeriodStartDay indicates an expected call of GetUserPeriodStartDay.
+func (mr *MockRepositoryMockRecorder) GetUserPeriodStartDay(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserPeriodStartDay", reflect.TypeOf((*MockRepository)(nil).GetUserPeriodStartDay), ctx, id)
+}
+
+// GetUserTimeZone mocks base method.
+func (m *MockRepository) GetUserTimeZone(ctx context.Context, id models.UserID) (*time.Location, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserMonthlyLimit", ctx, id)
-	ret0, _ := ret[0].(*decimal.Decimal)
+	ret := m.ctrl.Call(m, "GetUserTimeZone", ctx, id)
+	ret0, _ := ret[0].(*time.Location)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserMonthlyLimit indicates an expected call of GetUserMonthlyLimit.
-func (mr *MockRepositoryMockRecorder) GetUserMonthlyLimit(ctx, id interface{}) *gomock.Call {
+// GetUserTimeZone indicates an expected call of GetUserTimeZone.
+func (mr *MockRepositoryMockRecorder) GetUserTimeZone(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserMonthlyLimit", reflect.TypeOf((*MockRepository)(nil).GetUserMonthlyLimit), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserTimeZone", reflect.TypeOf((*MockRepository)(nil).GetUserTimeZone), ctx, id)
 }
 
 // IsUserExists mocks base method.
@@ -110,18 +381,74 @@ func (mr *MockRepositoryMockRecorder) IsUserExists(ctx, id interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsUserExists", reflect.TypeOf((*MockRepository)(nil).IsUserExists), ctx, id)
 }
 
-// SetUserMonthlyLimit mocks base method.
-func (m *MockRepository) SetUserMonthlyLimit(ctx context.Context, id models.UserID, limit *decimal.Decimal) error {
+// Isolated mocks base method.
+func (m *MockRepository) Isolated(ctx context.Context, callback func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Isolated", ctx, callback)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Isolated indicates an expected call of Isolated.
+func (mr *MockRepositoryMockRecorder) Isolated(ctx, callback interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Isolated", reflect.TypeOf((*MockRepository)(nil).Isolated), ctx, callback)
+}
+
+// SetUserLimitMode mocks base method.
+func (m *MockRepository) SetUserLimitMode(ctx context.Context, id models.UserID, mode models.LimitMode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserLimitMode", ctx, id, mode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserLimitMode indicates an expected call of SetUserLimitMode.
+func (mr *MockRepositoryMockRecorder) SetUserLimitMode(ctx, id, mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserLimitMode", reflect.TypeOf((*MockRepository)(nil).SetUserLimitMode), ctx, id, mode)
+}
+
+// SetUserLimits mocks base method.
+func (m *MockRepository) SetUserLimits(ctx context.Context, id models.UserID, limits []models.Limit) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserLimits", ctx, id, limits)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserLimits indicates an expected call of SetUserLimits.
+func (mr *MockRepositoryMockRecorder) SetUserLimits(ctx, id, limits interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserLimits", reflect.TypeOf((*MockRepository)(nil).SetUserLimits), ctx, id, limits)
+}
+
+// SetUserPeriodStartDay mocks base method.
+func (m *MockRepository) SetUserPeriodStartDay(ctx context.Context, id models.UserID, day int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserPeriodStartDay", ctx, id, day)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserPeriodStartDay indicates an expected call of SetUserPeriodStartDay.
+func (mr *MockRepositoryMockRecorder) SetUserPeriodStartDay(ctx, id, day interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserPeriodStartDay", reflect.TypeOf((*MockRepository)(nil).SetUserPeriodStartDay), ctx, id, day)
+}
+
+// SetUserTimeZone mocks base method.
+func (m *MockRepository) SetUserTimeZone(ctx context.Context, id models.UserID, loc *time.Location) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetUserMonthlyLimit", ctx, id, limit)
+	ret := m.ctrl.Call(m, "SetUserTimeZone", ctx, id, loc)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SetUserMonthlyLimit indicates an expected call of SetUserMonthlyLimit.
-func (mr *MockRepositoryMockRecorder) SetUserMonthlyLimit(ctx, id, limit interface{}) *gomock.Call {
+// SetUserTimeZone indicates an expected call of SetUserTimeZone.
+func (mr *MockRepositoryMockRecorder) SetUserTimeZone(ctx, id, loc interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserMonthlyLimit", reflect.TypeOf((*MockRepository)(nil).SetUserMonthlyLimit), ctx, id, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserTimeZone", reflect.TypeOf((*MockRepository)(nil).SetUserTimeZone), ctx, id, loc)
 }
 
 // MockUseCase is a mock of UseCase interface.
@@ -176,6 +503,20 @@ func (mr *MockUseCaseMockRecorder) CreateUser(ctx, u interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUseCase)(nil).CreateUser), ctx, u)
 }
 
+// DeleteUserLimit mocks base method.
+func (m *MockUseCase) DeleteUserLimit(ctx context.Context, id models.UserID, period models.LimitPeriod, category models.ExpenseCategory) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserLimit", ctx, id, period, category)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserLimit indicates an expected call of DeleteUserLimit.
+func (mr *MockUseCaseMockRecorder) DeleteUserLimit(ctx, id, period, category interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserLimit", reflect.TypeOf((*MockUseCase)(nil).DeleteUserLimit), ctx, id, period, category)
+}
+
 // GetUserCurrency mocks base method.
 func (m *MockUseCase) GetUserCurrency(ctx context.Context, id models.UserID) (models.CurrencyCode, error) {
 	m.ctrl.T.Helper()
@@ -191,19 +532,64 @@ func (mr *MockUseCaseMockRecorder) GetUserCurrency(ctx, id interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserCurrency", reflect.TypeOf((*MockUseCase)(nil).GetUserCurrency), ctx, id)
 }
 
-// GetUserMonthlyLimit mocks base method.
-func (m *MockUseCase) GetUserMonthlyLimit(ctx context.Context, id models.UserID) (*decimal.Decimal, error) {
+// GetUserLimitMode mocks base method.
+func (m *MockUseCase) GetUserLimitMode(ctx context.Context, id models.UserID) (models.LimitMode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserLimitMode", ctx, id)
+	ret0, _ := ret[0].(models.LimitMode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserLimitMode indicates an expected call of GetUserLimitMode.
+func (mr *MockUseCaseMockRecorder) GetUserLimitMode(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserLimitMode", reflect.TypeOf((*MockUseCase)(nil).GetUserLimitMode), ctx, id)
+}
+
+// GetUserLimits mocks base method.
+func (m *MockUseCase) GetUserLimits(ctx context.Context, id models.UserID) ([]models.Limit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserLimits", ctx, id)
+	ret0, _ := ret[0].([]models.Limit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserLimits indicates an expected call of GetUserLimits.
+func (mr *MockUseCaseMockRecorder) GetUserLimits(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserLimits", reflect.TypeOf((*MockUseCase)(nil).GetUserLimits), ctx, id)
+}
+
+// GetUserPeriodStartDay mocks base method.
+func (m *MockUseCase) GetUserPeriodStartDay(ctx context.Context, id models.UserID) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserPeriodStartDay", ctx, id)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserPeriodStartDay indicates an expected call of GetUserPeriodStartDay.
+func (mr *MockUseCaseMockRecorder) GetUserPeriodStartDay(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserPeriodStartDay", reflect.TypeOf((*MockUseCase)(nil).GetUserPeriodStartDay), ctx, id)
+}
+
+// GetUserTimeZone mocks base method.
+func (m *MockUseCase) GetUserTimeZone(ctx context.Context, id models.UserID) (*time.Location, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserMonthlyLimit", ctx, id)
-	ret0, _ := ret[0].(*decimal.Decimal)
+	ret := m.ctrl.Call(m, "GetUserTimeZone", ctx, id)
+	ret0, _ := ret[0].(*time.Location)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserMonthlyLimit indicates an expected call of GetUserMonthlyLimit.
-func (mr *MockUseCaseMockRecorder) GetUserMonthlyLimit(ctx, id interface{}) *gomock.Call {
+// GetUserTimeZone indicates an expected call of GetUserTimeZone.
+func (mr *MockUseCaseMockRecorder) GetUserTimeZone(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserMonthlyLimit", reflect.TypeOf((*MockUseCase)(nil).GetUserMonthlyLimit), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserTimeZone", reflect.TypeOf((*MockUseCase)(nil).GetUserTimeZone), ctx, id)
 }
 
 // IsUserExists mocks base method.
@@ -221,16 +607,73 @@ func (mr *MockUseCaseMockRecorder) IsUserExists(ctx, id interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsUserExists", reflect.TypeOf((*MockUseCase)(nil).IsUserExists), ctx, id)
 }
 
-// SetUserMonthlyLimit mocks base method.
-func (m *MockUseCase) SetUserMonthlyLimit(ctx context.Context, id models.UserID, limit *decimal.Decimal) error {
+// SetUserLimitMode mocks base method.
+func (m *MockUseCase) SetUserLimitMode(ctx context.Context, id models.UserID, mode models.LimitMode) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetUserMonthlyLimit", ctx, id, limit)
+	ret := m.ctrl.Call(m, "SetUserLimitMode", ctx, id, mode)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SetUserMonthlyLimit indicates an expected call of SetUserMonthlyLimit.
-func (mr *MockUseCaseMockRecorder) SetUserMonthlyLimit(ctx, id, limit interface{}) *gomock.Call {
+// SetUserLimitMode indicates an expected call of SetUserLimitMode.
+func (mr *MockUseCaseMockRecorder) SetUserLimitMode(ctx, id, mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserLimitMode", reflect.TypeOf((*MockUseCase)(nil).SetUserLimitMode), ctx, id, mode)
+}
+
+// SetUserLimits mocks base method.
+func (m *MockUseCase) SetUserLimits(ctx context.Context, id models.UserID, limits []models.Limit) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserLimits", ctx, id, limits)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserLimits indicates an expected call of SetUserLimits.
+func (mr *MockUseCaseMockRecorder) SetUserLimits(ctx, id, limits interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserLimits", reflect.TypeOf((*MockUseCase)(nil).SetUserLimits), ctx, id, limits)
+}
+
+// SetUserPeriodStartDay mocks base method.
+func (m *MockUseCase) SetUserPeriodStartDay(ctx context.Context, id models.UserID, day int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserPeriodStartDay", ctx, id, day)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserPeriodStartDay indicates an expected call of SetUserPeriodStartDay.
+func (mr *MockUseCaseMockRecorder) SetUserPeriodStartDay(ctx, id, day interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserPeriodStartDay", reflect.TypeOf((*MockUseCase)(nil).SetUserPeriodStartDay), ctx, id, day)
+}
+
+// SetUserTimeZone mocks base method.
+func (m *MockUseCase) SetUserTimeZone(ctx context.Context, id models.UserID, loc *time.Location) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserTimeZone", ctx, id, loc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserTimeZone indicates an expected call of SetUserTimeZone.
+func (mr *MockUseCaseMockRecorder) SetUserTimeZone(ctx, id, loc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserTimeZone", reflect.TypeOf((*MockUseCase)(nil).SetUserTimeZone), ctx, id, loc)
+}
+
+// UpdateUserLimits mocks base method.
+func (m *MockUseCase) UpdateUserLimits(ctx context.Context, id models.UserID, limits, removed []models.Limit) ([]models.Limit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserLimits", ctx, id, limits, removed)
+	ret0, _ := ret[0].([]models.Limit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserLimits indicates an expected call of UpdateUserLimits.
+func (mr *MockUseCaseMockRecorder) UpdateUserLimits(ctx, id, limits, removed interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserMonthlyLimit", reflect.TypeOf((*MockUseCase)(nil).SetUserMonthlyLimit), ctx, id, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserLimits", reflect.TypeOf((*MockUseCase)(nil).UpdateUserLimits), ctx, id, limits, removed)
 }
@@ -0,0 +1,23 @@
+package income
+
+import (
+	"context"
+	"time"
+
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+type Repository interface {
+	AddIncome(ctx context.Context, userID models.UserID, inc models.Income) (models.Income, error)
+	// GetIncomesAscendSinceTill passes incomes between since and till instants inclusive ordered by date
+	// to iter until it returns false.
+	GetIncomesAscendSinceTill(ctx context.Context, userID models.UserID, since, till time.Time, iter func(inc *models.Income) bool) error
+}
+
+type UseCase interface {
+	// AddIncome adds the income with the amount in its original currency if it's set, otherwise in the user selected one.
+	AddIncome(ctx context.Context, userID models.UserID, inc models.Income) (models.Income, error)
+	// GetCashFlow returns income and expenses by budget months for days from since till till inclusive
+	// in the user selected currency, the first and the last months are cut by the dates.
+	GetCashFlow(ctx context.Context, userID models.UserID, since, till time.Time) ([]models.CashFlow, error)
+}
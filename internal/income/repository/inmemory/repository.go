@@ -0,0 +1,60 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+type Repository struct {
+	mu      *sync.RWMutex
+	lastID  models.IncomeID
+	incomes map[models.UserID][]models.Income // ordered by date
+}
+
+func New() (*Repository, error) {
+	return &Repository{
+		mu:      &sync.RWMutex{},
+		incomes: make(map[models.UserID][]models.Income),
+	}, nil
+}
+
+func (r *Repository) AddIncome(ctx context.Context, userID models.UserID, inc models.Income) (models.Income, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastID++
+	inc.ID = r.lastID
+	incomes := r.incomes[userID]
+	i := sort.Search(len(incomes), func(i int) bool {
+		return incomes[i].Date.After(inc.Date)
+	})
+	incomes = append(incomes, models.Income{})
+	copy(incomes[i+1:], incomes[i:])
+	incomes[i] = inc
+	r.incomes[userID] = incomes
+	return inc, nil
+}
+
+func (r *Repository) GetIncomesAscendSinceTill(
+	ctx context.Context,
+	userID models.UserID,
+	since, till time.Time,
+	iter func(inc *models.Income) bool,
+) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, inc := range r.incomes[userID] {
+		if inc.Date.Before(since) || inc.Date.After(till) {
+			continue
+		}
+		if !iter(&inc) {
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/common/database/postgres"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+type Repository struct {
+	db postgres.DBDoer
+}
+
+func New(db postgres.DBDoer) (*Repository, error) {
+	return &Repository{db: db}, nil
+}
+
+func (r *Repository) AddIncome(ctx context.Context, userID models.UserID, inc models.Income) (models.Income, error) {
+	err := r.db.Do(ctx).QueryRowContext(ctx,
+		`INSERT INTO incomes (user_id, source, amount, date, comment, author_id, original_amount, original_currency, rate)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), $7, $8, $9) RETURNING id`,
+		userID, inc.Source, inc.Amount, inc.Date.UTC(), inc.Comment, inc.AuthorID, inc.OriginalAmount, inc.OriginalCurrency, inc.Rate,
+	).Scan(&inc.ID)
+	if err != nil {
+		return models.Income{}, errors.Wrap(err, "failed to add income to db")
+	}
+	return inc, nil
+}
+
+func (r *Repository) GetIncomesAscendSinceTill(
+	ctx context.Context,
+	userID models.UserID,
+	since, till time.Time,
+	iter func(inc *models.Income) bool,
+) (err error) {
+	rows, err := r.db.Do(ctx).QueryContext(ctx, `
+			SELECT id, source, amount, date, comment, COALESCE(author_id, 0), original_amount, original_currency, rate
+			FROM incomes WHERE user_id = $1 AND date BETWEEN $2 AND $3 ORDER BY date`,
+		userID, since.UTC(), till.UTC(),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create db query and get incomes since/till")
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = errors.Wrap(closeErr, "failed to close rows")
+		}
+	}()
+	for rows.Next() {
+		var inc models.Income
+		err := rows.Scan(
+			&inc.ID, &inc.Source, &inc.Amount, &inc.Date, &inc.Comment, &inc.AuthorID,
+			&inc.OriginalAmount, &inc.OriginalCurrency, &inc.Rate,
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to scan incomes since/till")
+		}
+		if !iter(&inc) {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "error occurred after scanning incomes since/till")
+	}
+	return nil
+}
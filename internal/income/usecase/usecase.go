@@ -0,0 +1,161 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/exrate"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/income"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/user"
+)
+
+const (
+	userIDSpanTagKey          = "user_id"
+	sinceUnixMillisSpanTagKey = "since_unix_ms"
+	tillUnixMillisSpanTagKey  = "till_unix_ms"
+)
+
+type UseCase struct {
+	baseCurrency models.CurrencyCode
+	repo         income.Repository
+	expUC        expense.UseCase
+	userRepo     user.Repository
+	exrateRepo   exrate.Repository
+}
+
+func New(
+	baseCurrency models.CurrencyCode,
+	repo income.Repository,
+	expUC expense.UseCase, userRepo user.Repository, exrateRepo exrate.Repository,
+) (*UseCase, error) {
+	return &UseCase{
+		baseCurrency: baseCurrency,
+		repo:         repo,
+		expUC:        expUC,
+		userRepo:     userRepo,
+		exrateRepo:   exrateRepo,
+	}, nil
+}
+
+func (u *UseCase) AddIncome(ctx context.Context, userID models.UserID, inc models.Income) (_ models.Income, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "AddIncome")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+
+	if err := inc.Validate(); err != nil {
+		return models.Income{}, errors.Wrap(err, "income validation failed")
+	}
+	if !inc.HasOriginal() {
+		curr, err := u.userRepo.GetUserCurrency(ctx, userID)
+		if err != nil {
+			return models.Income{}, errors.Wrapf(err, "failed to get selected user currency by userID=%d", userID)
+		}
+		inc.OriginalAmount, inc.OriginalCurrency = inc.Amount, curr
+	}
+	inc.Amount, inc.Rate = inc.OriginalAmount, decimal.NewFromInt(1)
+	if curr := inc.OriginalCurrency; curr != u.baseCurrency {
+		rate, err := u.exrateRepo.GetRate(ctx, curr, inc.Date)
+		if err != nil {
+			return models.Income{}, errors.Wrapf(err, "failed to get exchange rate for currency=%q at time=%v", curr, inc.Date)
+		}
+		inc.Amount, inc.Rate = rate.ConvertToBase(inc.Amount), rate.Rate
+	}
+	out, err := u.repo.AddIncome(ctx, userID, inc)
+	if err != nil {
+		return models.Income{}, errors.Wrap(err, "failed to add income to incomes repository")
+	}
+	return out, nil
+}
+
+func (u *UseCase) GetCashFlow(ctx context.Context, userID models.UserID, since, till time.Time) (_ []models.CashFlow, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "GetCashFlow")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+	span.SetTag(sinceUnixMillisSpanTagKey, since.UnixMilli())
+	span.SetTag(tillUnixMillisSpanTagKey, till.UnixMilli())
+
+	loc, err := u.userRepo.GetUserTimeZone(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get user time zone by userID=%d", userID)
+	}
+	startDay, err := u.userRepo.GetUserPeriodStartDay(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get user period start day by userID=%d", userID)
+	}
+	curr, err := u.userRepo.GetUserCurrency(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get selected user currency by userID=%d", userID)
+	}
+	since, till = models.StartOfDay(since.In(loc)), models.EndOfDay(till.In(loc))
+	var out []models.CashFlow
+	for start := since; !start.After(till); {
+		_, end := models.LimitPeriodMonth.Bounds(start, startDay)
+		if end.After(till) {
+			end = till
+		}
+		flow, err := u.getCashFlow(ctx, userID, start, end, curr)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, flow)
+		start = end.Add(time.Nanosecond)
+	}
+	return out, nil
+}
+
+// getCashFlow returns income and expenses between since and till instants inclusive in curr.
+func (u *UseCase) getCashFlow(ctx context.Context, userID models.UserID, since, till time.Time, curr models.CurrencyCode) (models.CashFlow, error) {
+	flow := models.CashFlow{Start: since, End: till, Sources: make(map[models.IncomeSource]decimal.Decimal)}
+	var iterErr error
+	err := u.repo.GetIncomesAscendSinceTill(ctx, userID, since, till, func(inc *models.Income) bool {
+		amount, err := u.convertIncome(ctx, inc, curr)
+		if err != nil {
+			iterErr = err
+			return false
+		}
+		flow.Income = flow.Income.Add(amount)
+		flow.Sources[inc.Source] = flow.Sources[inc.Source].Add(amount)
+		return true
+	})
+	if err == nil {
+		err = iterErr
+	}
+	if err != nil {
+		return models.CashFlow{}, errors.Wrapf(err, "failed to get incomes of userID=%d since %v till %v", userID, since, till)
+	}
+	report, err := u.expUC.GetExpensesSummaryByCategorySince(ctx, userID, since, till, curr, "")
+	if err != nil {
+		return models.CashFlow{}, errors.Wrapf(err, "failed to get expenses of userID=%d since %v till %v", userID, since, till)
+	}
+	for _, amount := range report {
+		flow.Expenses = flow.Expenses.Add(amount)
+	}
+	return flow, nil
+}
+
+// convertIncome returns the income amount in curr, the typed amount is returned as is for incomes added in curr.
+func (u *UseCase) convertIncome(ctx context.Context, inc *models.Income, curr models.CurrencyCode) (decimal.Decimal, error) {
+	switch {
+	case inc.OriginalCurrency == curr:
+		return inc.OriginalAmount, nil
+	case curr == u.baseCurrency:
+		return inc.Amount, nil
+	}
+	rate, err := u.exrateRepo.GetRate(ctx, curr, inc.Date)
+	if err != nil {
+		return decimal.Decimal{}, errors.Wrapf(err, "failed to get exchange rate for currency=%q at time=%v", curr, inc.Date)
+	}
+	return rate.ConvertFromBase(inc.Amount), nil
+}
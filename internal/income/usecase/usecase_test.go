@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense"
+	expenseInMemRepo "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense/repository/inmemory"
+	expenseUseCase "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense/usecase"
+	exrateInMemRepo "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/exrate/repository/inmemory"
+	incomeInMemRepo "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/income/repository/inmemory"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+	userInMemRepo "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/user/repository/inmemory"
+)
+
+const (
+	userID   = models.UserID(10)
+	baseCurr = models.CurrencyCode("RUB")
+)
+
+func newUC(t *testing.T, limits ...models.Limit) (*UseCase, *expenseUseCase.UseCase) {
+	ctx := context.Background()
+
+	userRepo, err := userInMemRepo.New()
+	require.NoError(t, err)
+	_, err = userRepo.CreateUser(ctx, models.NewUser(userID, baseCurr))
+	require.NoError(t, err)
+	require.NoError(t, userRepo.SetUserLimits(ctx, userID, limits))
+	expRepo, err := expenseInMemRepo.New()
+	require.NoError(t, err)
+	ratesRepo, err := exrateInMemRepo.New()
+	require.NoError(t, err)
+	expUC, err := expenseUseCase.New(baseCurr, expRepo, userRepo, ratesRepo)
+	require.NoError(t, err)
+
+	repo, err := incomeInMemRepo.New()
+	require.NoError(t, err)
+	uc, err := New(baseCurr, repo, expUC, userRepo, ratesRepo)
+	require.NoError(t, err)
+	return uc, expUC
+}
+
+func TestUseCase_GetCashFlow(t *testing.T) {
+	ctx := context.Background()
+	uc, expUC := newUC(t)
+	jan := time.Date(2023, time.January, 10, 0, 0, 0, 0, time.UTC)
+	feb := jan.AddDate(0, 1, 0)
+
+	for _, inc := range []models.Income{
+		{Source: "salary", Amount: decimal.NewFromInt(1000), Date: jan},
+		{Source: "gifts", Amount: decimal.NewFromInt(200), Date: jan},
+		{Source: "salary", Amount: decimal.NewFromInt(1000), Date: feb},
+	} {
+		_, err := uc.AddIncome(ctx, userID, inc)
+		require.NoError(t, err)
+	}
+	_, err := expUC.AddExpense(ctx, userID, models.Expense{Category: "food", Amount: decimal.NewFromInt(300), Date: jan})
+	require.NoError(t, err)
+	_, err = expUC.AddExpense(ctx, userID, models.Expense{Category: "rent", Amount: decimal.NewFromInt(1200), Date: feb})
+	require.NoError(t, err)
+
+	flows, err := uc.GetCashFlow(ctx, userID, jan, feb)
+	require.NoError(t, err)
+	require.Len(t, flows, 2)
+
+	assert.Equal(t, jan, flows[0].Start)
+	assert.Equal(t, models.EndOfDay(time.Date(2023, time.January, 31, 0, 0, 0, 0, time.UTC)), flows[0].End)
+	assert.True(t, decimal.NewFromInt(1200).Equal(flows[0].Income))
+	assert.True(t, decimal.NewFromInt(200).Equal(flows[0].Sources["gifts"]))
+	assert.True(t, decimal.NewFromInt(300).Equal(flows[0].Expenses))
+	rate, ok := flows[0].SavingsRate()
+	assert.True(t, ok)
+	assert.True(t, decimal.NewFromInt(75).Equal(rate), rate)
+
+	assert.Equal(t, models.EndOfDay(feb), flows[1].End)
+	assert.True(t, decimal.NewFromInt(-200).Equal(flows[1].Savings()))
+}
+
+func TestUseCase_IncomeIsIgnoredByLimits(t *testing.T) {
+	ctx := context.Background()
+	uc, expUC := newUC(t, models.Limit{Period: models.LimitPeriodMonth, Amount: decimal.NewFromInt(100)})
+	now := time.Now()
+
+	_, err := uc.AddIncome(ctx, userID, models.Income{Source: "salary", Amount: decimal.NewFromInt(1000), Date: now})
+	require.NoError(t, err)
+	_, err = expUC.AddExpense(ctx, userID, models.Expense{Category: "food", Amount: decimal.NewFromInt(150), Date: now})
+	require.ErrorIs(t, err, expense.ErrExpensesLimitExcess)
+}
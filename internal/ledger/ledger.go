@@ -0,0 +1,42 @@
+package ledger
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+var (
+	ErrAlreadyExists = errors.New("ledger already exists")
+	ErrDoesNotExist  = errors.New("ledger does not exist")
+	ErrNotMember     = errors.New("user is not a ledger member")
+)
+
+type Repository interface {
+	Isolated(ctx context.Context, callback func(ctx context.Context) error) error
+	CreateLedger(ctx context.Context, l models.Ledger) error
+	// GetLedger returns the ledger with members sorted by the join time.
+	GetLedger(ctx context.Context, id models.UserID) (models.Ledger, error)
+	GetLedgerIDByInviteCode(ctx context.Context, code string) (models.UserID, error)
+	// AddLedgerMember adds the member to the ledger or updates the member name and username.
+	AddLedgerMember(ctx context.Context, id models.UserID, member models.LedgerMember) error
+	DeleteLedgerMember(ctx context.Context, id models.UserID, userID models.UserID) error
+	// SetActiveLedger makes the ledger used by the member in the private chat with the bot.
+	SetActiveLedger(ctx context.Context, userID models.UserID, id models.UserID) error
+	GetActiveLedgerID(ctx context.Context, userID models.UserID) (models.UserID, error)
+}
+
+type UseCase interface {
+	// JoinChatLedger returns the ledger of the group chat with the member in it, the ledger is created on the first call.
+	// The member name and username are updated, if they have changed.
+	JoinChatLedger(ctx context.Context, chatID models.UserID, title string, member models.LedgerMember) (models.Ledger, error)
+	// JoinLedgerByInviteCode adds the member to the ledger and makes it active in the private chat with the bot.
+	JoinLedgerByInviteCode(ctx context.Context, code string, member models.LedgerMember) (models.Ledger, error)
+	// LeaveActiveLedger removes the user from the active ledger, ErrDoesNotExist is returned if there is no such one.
+	LeaveActiveLedger(ctx context.Context, userID models.UserID) (models.Ledger, error)
+	// GetActiveLedger returns the ledger used by the user in the private chat, ErrDoesNotExist is returned if the user
+	// uses the personal account.
+	GetActiveLedger(ctx context.Context, userID models.UserID) (models.Ledger, error)
+	GetLedger(ctx context.Context, id models.UserID) (models.Ledger, error)
+}
@@ -0,0 +1,131 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/ledger"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+type Repository struct {
+	mu            *sync.RWMutex
+	isolatedMu    *sync.Mutex
+	ledgers       map[models.UserID]models.Ledger
+	activeLedgers map[models.UserID]models.UserID // user ID to ledger ID
+}
+
+func New() (*Repository, error) {
+	return &Repository{
+		mu:            &sync.RWMutex{},
+		isolatedMu:    &sync.Mutex{},
+		ledgers:       make(map[models.UserID]models.Ledger),
+		activeLedgers: make(map[models.UserID]models.UserID),
+	}, nil
+}
+
+func (r *Repository) Isolated(ctx context.Context, callback func(ctx context.Context) error) error {
+	r.isolatedMu.Lock()
+	defer r.isolatedMu.Unlock()
+	return callback(ctx)
+}
+
+func (r *Repository) CreateLedger(ctx context.Context, l models.Ledger) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.ledgers[l.ID]; ok {
+		return ledger.ErrAlreadyExists
+	}
+	l.Members = nil
+	r.ledgers[l.ID] = l
+	return nil
+}
+
+func (r *Repository) GetLedger(ctx context.Context, id models.UserID) (models.Ledger, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	l, ok := r.ledgers[id]
+	if !ok {
+		return models.Ledger{}, ledger.ErrDoesNotExist
+	}
+	l.Members = append([]models.LedgerMember(nil), l.Members...)
+	return l, nil
+}
+
+func (r *Repository) GetLedgerIDByInviteCode(ctx context.Context, code string) (models.UserID, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for id, l := range r.ledgers {
+		if l.InviteCode == code {
+			return id, nil
+		}
+	}
+	return 0, ledger.ErrDoesNotExist
+}
+
+func (r *Repository) AddLedgerMember(ctx context.Context, id models.UserID, member models.LedgerMember) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, ok := r.ledgers[id]
+	if !ok {
+		return ledger.ErrDoesNotExist
+	}
+	for i := range l.Members {
+		if l.Members[i].UserID == member.UserID {
+			l.Members[i].Name, l.Members[i].Username = member.Name, member.Username
+			return nil
+		}
+	}
+	l.Members = append(l.Members, member)
+	sort.SliceStable(l.Members, func(i, j int) bool {
+		return l.Members[i].JoinedAt.Before(l.Members[j].JoinedAt)
+	})
+	r.ledgers[id] = l
+	return nil
+}
+
+func (r *Repository) DeleteLedgerMember(ctx context.Context, id models.UserID, userID models.UserID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l := r.ledgers[id]
+	for i := range l.Members {
+		if l.Members[i].UserID == userID {
+			l.Members = append(l.Members[:i], l.Members[i+1:]...)
+			r.ledgers[id] = l
+			if r.activeLedgers[userID] == id {
+				delete(r.activeLedgers, userID)
+			}
+			return nil
+		}
+	}
+	return ledger.ErrNotMember
+}
+
+func (r *Repository) SetActiveLedger(ctx context.Context, userID models.UserID, id models.UserID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l := r.ledgers[id]
+	if _, ok := l.Member(userID); !ok {
+		return ledger.ErrNotMember
+	}
+	r.activeLedgers[userID] = id
+	return nil
+}
+
+func (r *Repository) GetActiveLedgerID(ctx context.Context, userID models.UserID) (models.UserID, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.activeLedgers[userID]
+	if !ok {
+		return 0, ledger.ErrDoesNotExist
+	}
+	return id, nil
+}
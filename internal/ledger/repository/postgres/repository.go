@@ -0,0 +1,155 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/common/database/postgres"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/ledger"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+type Repository struct {
+	db postgres.DBDoer
+}
+
+func New(db postgres.DBDoer) (*Repository, error) {
+	return &Repository{db: db}, nil
+}
+
+func (r *Repository) Isolated(ctx context.Context, callback func(ctx context.Context) error) error {
+	return r.db.DoIsolated(ctx, nil, callback)
+}
+
+func (r *Repository) CreateLedger(ctx context.Context, l models.Ledger) error {
+	res, err := r.db.Do(ctx).ExecContext(ctx,
+		"INSERT INTO ledgers(id, title, invite_code) VALUES ($1, $2, $3) ON CONFLICT (id) DO NOTHING",
+		l.ID, l.Title, l.InviteCode,
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create ledgerID=%d", l.ID)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "failed to create ledgerID=%d", l.ID)
+	}
+	if affected == 0 {
+		return ledger.ErrAlreadyExists
+	}
+	return nil
+}
+
+func (r *Repository) GetLedger(ctx context.Context, id models.UserID) (_ models.Ledger, err error) {
+	l := models.Ledger{ID: id}
+	err = r.db.Do(ctx).QueryRowContext(ctx, "SELECT title, invite_code FROM ledgers WHERE id = $1", id).
+		Scan(&l.Title, &l.InviteCode)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Ledger{}, ledger.ErrDoesNotExist
+		}
+		return models.Ledger{}, errors.Wrapf(err, "failed to get ledgerID=%d", id)
+	}
+	rows, err := r.db.Do(ctx).QueryContext(ctx,
+		"SELECT user_id, name, username, joined_at FROM ledger_members WHERE ledger_id = $1 ORDER BY joined_at, user_id", id,
+	)
+	if err != nil {
+		return models.Ledger{}, errors.Wrapf(err, "failed to get members of ledgerID=%d", id)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = errors.Wrap(closeErr, "failed to close rows")
+		}
+	}()
+	for rows.Next() {
+		var member models.LedgerMember
+		if err := rows.Scan(&member.UserID, &member.Name, &member.Username, &member.JoinedAt); err != nil {
+			return models.Ledger{}, errors.Wrapf(err, "failed to scan member of ledgerID=%d", id)
+		}
+		l.Members = append(l.Members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return models.Ledger{}, errors.Wrapf(err, "failed to get members of ledgerID=%d", id)
+	}
+	return l, nil
+}
+
+func (r *Repository) GetLedgerIDByInviteCode(ctx context.Context, code string) (models.UserID, error) {
+	var id models.UserID
+	err := r.db.Do(ctx).QueryRowContext(ctx, "SELECT id FROM ledgers WHERE invite_code = $1", code).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ledger.ErrDoesNotExist
+		}
+		return 0, errors.Wrap(err, "failed to get ledger by invite code")
+	}
+	return id, nil
+}
+
+func (r *Repository) AddLedgerMember(ctx context.Context, id models.UserID, member models.LedgerMember) error {
+	_, err := r.db.Do(ctx).ExecContext(ctx, `
+			INSERT INTO ledger_members(ledger_id, user_id, name, username, joined_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (ledger_id, user_id) DO UPDATE SET name = $3, username = $4`,
+		id, member.UserID, member.Name, member.Username, member.JoinedAt.UTC(),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to add userID=%d to ledgerID=%d", member.UserID, id)
+	}
+	return nil
+}
+
+func (r *Repository) DeleteLedgerMember(ctx context.Context, id models.UserID, userID models.UserID) error {
+	res, err := r.db.Do(ctx).ExecContext(ctx, "DELETE FROM ledger_members WHERE ledger_id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete userID=%d from ledgerID=%d", userID, id)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete userID=%d from ledgerID=%d", userID, id)
+	}
+	if affected == 0 {
+		return ledger.ErrNotMember
+	}
+	return nil
+}
+
+func (r *Repository) SetActiveLedger(ctx context.Context, userID models.UserID, id models.UserID) error {
+	return r.Isolated(ctx, func(ctx context.Context) error {
+		// the only active ledger of the user is guaranteed by the unique index, so the old one is deactivated first
+		_, err := r.db.Do(ctx).ExecContext(ctx,
+			"UPDATE ledger_members SET active = FALSE WHERE user_id = $1 AND active AND ledger_id <> $2", userID, id,
+		)
+		if err != nil {
+			return errors.Wrapf(err, "failed to deactivate ledgers of userID=%d", userID)
+		}
+		res, err := r.db.Do(ctx).ExecContext(ctx,
+			"UPDATE ledger_members SET active = TRUE WHERE user_id = $1 AND ledger_id = $2", userID, id,
+		)
+		if err != nil {
+			return errors.Wrapf(err, "failed to activate ledgerID=%d for userID=%d", id, userID)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return errors.Wrapf(err, "failed to activate ledgerID=%d for userID=%d", id, userID)
+		}
+		if affected == 0 {
+			return ledger.ErrNotMember
+		}
+		return nil
+	})
+}
+
+func (r *Repository) GetActiveLedgerID(ctx context.Context, userID models.UserID) (models.UserID, error) {
+	var id models.UserID
+	err := r.db.Do(ctx).QueryRowContext(ctx,
+		"SELECT ledger_id FROM ledger_members WHERE user_id = $1 AND active", userID,
+	).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ledger.ErrDoesNotExist
+		}
+		return 0, errors.Wrapf(err, "failed to get active ledger of userID=%d", userID)
+	}
+	return id, nil
+}
@@ -0,0 +1,185 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/pkg/errors"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/ledger"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/user"
+)
+
+const (
+	userIDSpanTagKey   = "user_id"
+	ledgerIDSpanTagKey = "ledger_id"
+)
+
+type UseCase struct {
+	baseCurrency models.CurrencyCode
+	repo         ledger.Repository
+	userRepo     user.Repository
+}
+
+func New(baseCurrency models.CurrencyCode, repo ledger.Repository, userRepo user.Repository) (*UseCase, error) {
+	return &UseCase{
+		baseCurrency: baseCurrency,
+		repo:         repo,
+		userRepo:     userRepo,
+	}, nil
+}
+
+func (u *UseCase) JoinChatLedger(
+	ctx context.Context,
+	chatID models.UserID,
+	title string,
+	member models.LedgerMember,
+) (_ models.Ledger, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "JoinChatLedger")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(ledgerIDSpanTagKey, chatID)
+	span.SetTag(userIDSpanTagKey, member.UserID)
+
+	var out models.Ledger
+	err = u.repo.Isolated(ctx, func(ctx context.Context) error {
+		l, err := u.repo.GetLedger(ctx, chatID)
+		if errors.Is(err, ledger.ErrDoesNotExist) {
+			l, err = u.createLedger(ctx, chatID, title)
+		}
+		if err != nil {
+			return err
+		}
+		known, ok := l.Member(member.UserID)
+		switch {
+		case !ok:
+			if err := u.repo.AddLedgerMember(ctx, l.ID, member); err != nil {
+				return err
+			}
+			l.Members = append(l.Members, member)
+		case known.Name != member.Name || known.Username != member.Username:
+			if err := u.repo.AddLedgerMember(ctx, l.ID, member); err != nil {
+				return err
+			}
+			if l, err = u.repo.GetLedger(ctx, l.ID); err != nil {
+				return err
+			}
+		}
+		out = l
+		return nil
+	})
+	if err != nil {
+		return models.Ledger{}, errors.Wrapf(err, "failed to join userID=%d to ledger of chatID=%d", member.UserID, chatID)
+	}
+	return out, nil
+}
+
+// createLedger creates the ledger with its account in the base currency.
+func (u *UseCase) createLedger(ctx context.Context, id models.UserID, title string) (models.Ledger, error) {
+	code, err := models.NewInviteCode()
+	if err != nil {
+		return models.Ledger{}, err
+	}
+	l := models.Ledger{ID: id, Title: title, InviteCode: code}
+	if _, err := u.userRepo.CreateUser(ctx, models.NewUser(id, u.baseCurrency)); err != nil && !errors.Is(err, user.ErrAlreadyExists) {
+		return models.Ledger{}, errors.Wrapf(err, "failed to create account of ledgerID=%d", id)
+	}
+	if err := u.repo.CreateLedger(ctx, l); err != nil {
+		return models.Ledger{}, err
+	}
+	return l, nil
+}
+
+func (u *UseCase) JoinLedgerByInviteCode(ctx context.Context, code string, member models.LedgerMember) (_ models.Ledger, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "JoinLedgerByInviteCode")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, member.UserID)
+
+	var out models.Ledger
+	err = u.repo.Isolated(ctx, func(ctx context.Context) error {
+		id, err := u.repo.GetLedgerIDByInviteCode(ctx, code)
+		if err != nil {
+			return err
+		}
+		span.SetTag(ledgerIDSpanTagKey, id)
+		l, err := u.repo.GetLedger(ctx, id)
+		if err != nil {
+			return err
+		}
+		if _, ok := l.Member(member.UserID); !ok {
+			if err := u.repo.AddLedgerMember(ctx, id, member); err != nil {
+				return err
+			}
+			l.Members = append(l.Members, member)
+		}
+		out = l
+		return u.repo.SetActiveLedger(ctx, member.UserID, id)
+	})
+	if err != nil {
+		return models.Ledger{}, errors.Wrapf(err, "failed to join userID=%d to ledger by invite code", member.UserID)
+	}
+	return out, nil
+}
+
+func (u *UseCase) LeaveActiveLedger(ctx context.Context, userID models.UserID) (_ models.Ledger, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "LeaveActiveLedger")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+
+	var out models.Ledger
+	err = u.repo.Isolated(ctx, func(ctx context.Context) error {
+		id, err := u.repo.GetActiveLedgerID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		span.SetTag(ledgerIDSpanTagKey, id)
+		if err := u.repo.DeleteLedgerMember(ctx, id, userID); err != nil {
+			return err
+		}
+		out, err = u.repo.GetLedger(ctx, id)
+		return err
+	})
+	if err != nil {
+		return models.Ledger{}, errors.Wrapf(err, "failed to leave active ledger by userID=%d", userID)
+	}
+	return out, nil
+}
+
+func (u *UseCase) GetActiveLedger(ctx context.Context, userID models.UserID) (_ models.Ledger, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "GetActiveLedger")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+
+	id, err := u.repo.GetActiveLedgerID(ctx, userID)
+	if err != nil {
+		return models.Ledger{}, errors.Wrapf(err, "failed to get active ledger of userID=%d", userID)
+	}
+	return u.GetLedger(ctx, id)
+}
+
+func (u *UseCase) GetLedger(ctx context.Context, id models.UserID) (_ models.Ledger, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "GetLedger")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(ledgerIDSpanTagKey, id)
+
+	l, err := u.repo.GetLedger(ctx, id)
+	if err != nil {
+		return models.Ledger{}, errors.Wrapf(err, "failed to get ledgerID=%d", id)
+	}
+	return l, nil
+}
@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/ledger"
+	ledgerInMemRepo "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/ledger/repository/inmemory"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+	userInMemRepo "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/user/repository/inmemory"
+)
+
+const (
+	chatID   = models.UserID(-100)
+	baseCurr = models.CurrencyCode("RUB")
+)
+
+func newUC(t *testing.T) (*UseCase, *userInMemRepo.Repository) {
+	userRepo, err := userInMemRepo.New()
+	require.NoError(t, err)
+	repo, err := ledgerInMemRepo.New()
+	require.NoError(t, err)
+	uc, err := New(baseCurr, repo, userRepo)
+	require.NoError(t, err)
+	return uc, userRepo
+}
+
+func TestUseCase_JoinChatLedger(t *testing.T) {
+	ctx := context.Background()
+	uc, userRepo := newUC(t)
+	alice := models.LedgerMember{UserID: 1, Name: "Alice", JoinedAt: time.Now()}
+	bob := models.LedgerMember{UserID: 2, Name: "Bob", JoinedAt: alice.JoinedAt.Add(time.Minute)}
+
+	created, err := uc.JoinChatLedger(ctx, chatID, "family", alice)
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.InviteCode)
+	curr, err := userRepo.GetUserCurrency(ctx, chatID)
+	require.NoError(t, err)
+	assert.Equal(t, baseCurr, curr)
+
+	// the ledger is created once and members are added once
+	for i := 0; i < 2; i++ {
+		joined, err := uc.JoinChatLedger(ctx, chatID, "family", bob)
+		require.NoError(t, err)
+		assert.Equal(t, created.InviteCode, joined.InviteCode)
+		assert.Equal(t, []models.LedgerMember{alice, bob}, joined.Members)
+	}
+	// group chat members don't switch the private chat account
+	_, err = uc.GetActiveLedger(ctx, alice.UserID)
+	require.ErrorIs(t, err, ledger.ErrDoesNotExist)
+}
+
+func TestUseCase_JoinLedgerByInviteCode(t *testing.T) {
+	ctx := context.Background()
+	uc, _ := newUC(t)
+	alice := models.LedgerMember{UserID: 1, Name: "Alice", JoinedAt: time.Now()}
+	bob := models.LedgerMember{UserID: 2, Name: "Bob", JoinedAt: alice.JoinedAt.Add(time.Minute)}
+
+	created, err := uc.JoinChatLedger(ctx, chatID, "family", alice)
+	require.NoError(t, err)
+	_, err = uc.JoinLedgerByInviteCode(ctx, "unknown", bob)
+	require.ErrorIs(t, err, ledger.ErrDoesNotExist)
+
+	joined, err := uc.JoinLedgerByInviteCode(ctx, created.InviteCode, bob)
+	require.NoError(t, err)
+	assert.Equal(t, []models.LedgerMember{alice, bob}, joined.Members)
+	active, err := uc.GetActiveLedger(ctx, bob.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, chatID, active.ID)
+
+	left, err := uc.LeaveActiveLedger(ctx, bob.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.LedgerMember{alice}, left.Members)
+	_, err = uc.GetActiveLedger(ctx, bob.UserID)
+	require.ErrorIs(t, err, ledger.ErrDoesNotExist)
+	_, err = uc.LeaveActiveLedger(ctx, bob.UserID)
+	require.ErrorIs(t, err, ledger.ErrDoesNotExist)
+}
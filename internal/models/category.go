@@ -0,0 +1,75 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// CategorySeparator splits the category into the path of parent categories, e.g. 'food/restaurants'.
+const CategorySeparator = "/"
+
+var (
+	ErrExpenseCategoryIsInvalid = errors.New("expense category has empty parts")
+	ErrCategoryAliasToItself    = errors.New("category alias points to itself")
+)
+
+// CategoryStats describes the category usage.
+type CategoryStats struct {
+	Category ExpenseCategory
+	Count    int
+	Amount   decimal.Decimal
+}
+
+// Normalize returns the category in lower case, so the input matching ignores case.
+func (c ExpenseCategory) Normalize() ExpenseCategory {
+	return ExpenseCategory(strings.ToLower(string(c)))
+}
+
+// Parent returns the parent category, false is returned for top level categories.
+func (c ExpenseCategory) Parent() (ExpenseCategory, bool) {
+	i := strings.LastIndex(string(c), CategorySeparator)
+	if i < 0 {
+		return "", false
+	}
+	return c[:i], true
+}
+
+// Name returns the last part of the category path.
+func (c ExpenseCategory) Name() string {
+	return string(c[strings.LastIndex(string(c), CategorySeparator)+1:])
+}
+
+// Depth returns the number of parents of the category.
+func (c ExpenseCategory) Depth() int {
+	return strings.Count(string(c), CategorySeparator)
+}
+
+// IsWithin reports whether the category is the parent one or its descendant.
+func (c ExpenseCategory) IsWithin(parent ExpenseCategory) bool {
+	return c == parent || strings.HasPrefix(string(c), string(parent)+CategorySeparator)
+}
+
+// Rebase moves the category within from to the same place within to, categories are matched ignoring case
+// and the rest of the path is normalized. False is returned if the category isn't within from.
+func (c ExpenseCategory) Rebase(from, to ExpenseCategory) (ExpenseCategory, bool) {
+	normalized, from := c.Normalize(), from.Normalize()
+	if !normalized.IsWithin(from) {
+		return c, false
+	}
+	return to + normalized[len(from):], true
+}
+
+// Validate checks that nested categories have no empty parts.
+func (c ExpenseCategory) Validate() error {
+	if !strings.Contains(string(c), CategorySeparator) {
+		return nil
+	}
+	for _, part := range strings.Split(string(c), CategorySeparator) {
+		if part == "" {
+			return ErrExpenseCategoryIsInvalid
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+const (
+	amountTokenPrefix = "amount:"
+	maxTokenLen       = 64
+)
+
+// CategoryModel is the naive Bayes model predicting the category of the expense by its comment and amount.
+type CategoryModel struct {
+	Expenses map[ExpenseCategory]int64            // the number of learned expenses by categories
+	Tokens   map[ExpenseCategory]map[string]int64 // the number of learned expenses with the token by categories
+}
+
+func NewCategoryModel() *CategoryModel {
+	return &CategoryModel{
+		Expenses: make(map[ExpenseCategory]int64),
+		Tokens:   make(map[ExpenseCategory]map[string]int64),
+	}
+}
+
+// ExpenseTokens returns features of the expense: distinct words of the comment and the order of the amount in the base currency.
+func ExpenseTokens(exp *Expense) []string {
+	digits := len(exp.Amount.Abs().Truncate(0).String())
+	tokens := []string{amountTokenPrefix + strconv.Itoa(digits)}
+	seen := make(map[string]struct{})
+	for _, word := range ruleWords(exp.Comment) {
+		if _, ok := seen[word]; ok || len(word) > maxTokenLen {
+			continue
+		}
+		seen[word] = struct{}{}
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
+
+func (m *CategoryModel) IsEmpty() bool {
+	return len(m.Expenses) == 0
+}
+
+// Learn adds the expense to the model.
+func (m *CategoryModel) Learn(exp *Expense) {
+	m.add(exp.Category, 1, ExpenseTokens(exp)...)
+}
+
+// Merge adds counts of the other model to the model.
+func (m *CategoryModel) Merge(other *CategoryModel) {
+	for category, count := range other.Expenses {
+		m.add(category, count)
+	}
+	for category, tokens := range other.Tokens {
+		for token, count := range tokens {
+			m.AddToken(category, token, count)
+		}
+	}
+}
+
+// AddExpenses adds the number of expenses of the category without their tokens.
+func (m *CategoryModel) AddExpenses(category ExpenseCategory, count int64) {
+	m.add(category, count)
+}
+
+// AddToken adds the number of expenses of the category with the token.
+func (m *CategoryModel) AddToken(category ExpenseCategory, token string, count int64) {
+	tokens, ok := m.Tokens[category]
+	if !ok {
+		tokens = make(map[string]int64)
+		m.Tokens[category] = tokens
+	}
+	tokens[token] += count
+}
+
+func (m *CategoryModel) add(category ExpenseCategory, count int64, tokens ...string) {
+	m.Expenses[category] += count
+	for _, token := range tokens {
+		m.AddToken(category, token, count)
+	}
+}
+
+// Suggest returns categories of the model ordered by decreasing probability to be the category of the expense.
+// Tokens unknown to the model are ignored, Laplace smoothing is used for the known ones.
+func (m *CategoryModel) Suggest(exp *Expense) []ExpenseCategory {
+	var total int64
+	for _, count := range m.Expenses {
+		total += count
+	}
+	if total == 0 {
+		return nil
+	}
+	vocabulary := make(map[string]struct{})
+	for _, tokens := range m.Tokens {
+		for token := range tokens {
+			vocabulary[token] = struct{}{}
+		}
+	}
+	var known []string
+	for _, token := range ExpenseTokens(exp) {
+		if _, ok := vocabulary[token]; ok {
+			known = append(known, token)
+		}
+	}
+	scores := make(map[ExpenseCategory]float64, len(m.Expenses))
+	categories := make([]ExpenseCategory, 0, len(m.Expenses))
+	for category, count := range m.Expenses {
+		if count <= 0 {
+			continue
+		}
+		var tokensCount int64
+		for _, tokenCount := range m.Tokens[category] {
+			tokensCount += tokenCount
+		}
+		score := math.Log(float64(count) / float64(total))
+		for _, token := range known {
+			score += math.Log(float64(m.Tokens[category][token]+1) / float64(tokensCount+int64(len(vocabulary))))
+		}
+		scores[category] = score
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		a, b := scores[categories[i]], scores[categories[j]]
+		if a == b {
+			return categories[i] < categories[j]
+		}
+		return a > b
+	})
+	return categories
+}
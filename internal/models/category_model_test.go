@@ -0,0 +1,47 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpenseTokens(t *testing.T) {
+	exp := Expense{Amount: decimal.NewFromFloat(250.5), Comment: "Latte, latte #work"}
+	assert.Equal(t, []string{"amount:3", "latte", "work"}, ExpenseTokens(&exp))
+
+	exp = Expense{Amount: decimal.NewFromFloat(0.5)}
+	assert.Equal(t, []string{"amount:1"}, ExpenseTokens(&exp))
+}
+
+func TestCategoryModel_Suggest(t *testing.T) {
+	model := NewCategoryModel()
+	assert.Empty(t, model.Suggest(&Expense{Comment: "latte"}))
+
+	for _, exp := range []Expense{
+		{Category: "coffee", Amount: decimal.NewFromInt(250), Comment: "latte"},
+		{Category: "coffee", Amount: decimal.NewFromInt(300), Comment: "cappuccino"},
+		{Category: "food", Amount: decimal.NewFromInt(2500), Comment: "groceries"},
+		{Category: "food", Amount: decimal.NewFromInt(3000), Comment: "groceries milk"},
+		{Category: "food", Amount: decimal.NewFromInt(1800), Comment: "bakery"},
+		{Category: "taxi", Amount: decimal.NewFromInt(430), Comment: "airport"},
+	} {
+		exp := exp
+		model.Learn(&exp)
+	}
+
+	suggested := model.Suggest(&Expense{Amount: decimal.NewFromInt(270), Comment: "Latte to go"})
+	assert.Equal(t, []ExpenseCategory{"coffee", "taxi", "food"}, suggested)
+
+	suggested = model.Suggest(&Expense{Amount: decimal.NewFromInt(2000), Comment: "milk"})
+	assert.Equal(t, ExpenseCategory("food"), suggested[0])
+
+	// unknown tokens leave the most frequent category first
+	suggested = model.Suggest(&Expense{Amount: decimal.NewFromInt(100000), Comment: "piano"})
+	assert.Equal(t, ExpenseCategory("food"), suggested[0])
+
+	merged := NewCategoryModel()
+	merged.Merge(model)
+	assert.Equal(t, model, merged)
+}
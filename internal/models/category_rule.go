@@ -0,0 +1,80 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+type CategoryRuleID int64
+
+var (
+	ErrCategoryRulePatternIsEmpty = errors.New("category rule pattern is empty")
+	ErrCategoryRuleRegexIsInvalid = errors.New("category rule regex is invalid")
+)
+
+// CategoryRule picks the category of the expense by its comment.
+// The keyword matches the whole words of the comment, the regex matches any part of it, both ignore case.
+type CategoryRule struct {
+	ID       CategoryRuleID
+	Pattern  string
+	IsRegex  bool
+	Category ExpenseCategory
+}
+
+func (r CategoryRule) Validate() error {
+	if strings.TrimSpace(r.Pattern) == "" {
+		return ErrCategoryRulePatternIsEmpty
+	}
+	if r.IsRegex {
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return errors.Wrap(ErrCategoryRuleRegexIsInvalid, err.Error())
+		}
+	}
+	return r.Category.Validate()
+}
+
+// Matches reports whether the rule matches the text, the invalid regex matches nothing.
+func (r CategoryRule) Matches(text string) bool {
+	if r.IsRegex {
+		matched, err := regexp.MatchString("(?i)"+r.Pattern, text)
+		return err == nil && matched
+	}
+	keyword := ruleWords(r.Pattern)
+	if len(keyword) == 0 {
+		return false
+	}
+	words := ruleWords(text)
+	for i := 0; i+len(keyword) <= len(words); i++ {
+		if equalWords(words[i:i+len(keyword)], keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r CategoryRule) String() string {
+	if r.IsRegex {
+		return fmt.Sprintf("/%s/ -> %s", r.Pattern, r.Category)
+	}
+	return fmt.Sprintf("%q -> %s", r.Pattern, r.Category)
+}
+
+// ruleWords splits the text into lower case words ignoring punctuation.
+func ruleWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+func equalWords(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategoryRule_Matches(t *testing.T) {
+	tests := []struct {
+		rule     CategoryRule
+		text     string
+		expected bool
+	}{
+		{rule: CategoryRule{Pattern: "starbucks"}, text: "Starbucks latte", expected: true},
+		{rule: CategoryRule{Pattern: "starbucks"}, text: "latte at starbucks, downtown", expected: true},
+		{rule: CategoryRule{Pattern: "star"}, text: "starbucks latte", expected: false},
+		{rule: CategoryRule{Pattern: "yandex go"}, text: "Yandex Go to the airport", expected: true},
+		{rule: CategoryRule{Pattern: "yandex go"}, text: "yandex market", expected: false},
+		{rule: CategoryRule{Pattern: "^uber|taxi", IsRegex: true}, text: "Uber airport", expected: true},
+		{rule: CategoryRule{Pattern: "^uber|taxi", IsRegex: true}, text: "airport uber", expected: false},
+		{rule: CategoryRule{Pattern: "(", IsRegex: true}, text: "(", expected: false},
+	}
+	for _, test := range tests {
+		assert.Equalf(t, test.expected, test.rule.Matches(test.text), "rule %s, text %q", test.rule, test.text)
+	}
+}
+
+func TestCategoryRule_Validate(t *testing.T) {
+	assert.NoError(t, CategoryRule{Pattern: "starbucks", Category: "coffee"}.Validate())
+	assert.ErrorIs(t, CategoryRule{Pattern: " ", Category: "coffee"}.Validate(), ErrCategoryRulePatternIsEmpty)
+	assert.ErrorIs(t, CategoryRule{Pattern: "(", IsRegex: true, Category: "coffee"}.Validate(), ErrCategoryRuleRegexIsInvalid)
+	assert.ErrorIs(t, CategoryRule{Pattern: "starbucks", Category: "coffee/"}.Validate(), ErrExpenseCategoryIsInvalid)
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpenseCategory_Parent(t *testing.T) {
+	parent, ok := ExpenseCategory("food/restaurants/pizza").Parent()
+	assert.True(t, ok)
+	assert.Equal(t, ExpenseCategory("food/restaurants"), parent)
+
+	_, ok = ExpenseCategory("food").Parent()
+	assert.False(t, ok)
+}
+
+func TestExpenseCategory_IsWithin(t *testing.T) {
+	assert.True(t, ExpenseCategory("food").IsWithin("food"))
+	assert.True(t, ExpenseCategory("food/restaurants").IsWithin("food"))
+	assert.False(t, ExpenseCategory("food").IsWithin("food/restaurants"))
+	assert.False(t, ExpenseCategory("foodstuff").IsWithin("food"))
+}
+
+func TestExpenseCategory_Validate(t *testing.T) {
+	assert.NoError(t, ExpenseCategory("food").Validate())
+	assert.NoError(t, ExpenseCategory("food/restaurants").Validate())
+	assert.ErrorIs(t, ExpenseCategory("food/").Validate(), ErrExpenseCategoryIsInvalid)
+	assert.ErrorIs(t, ExpenseCategory("/food").Validate(), ErrExpenseCategoryIsInvalid)
+	assert.ErrorIs(t, ExpenseCategory("food//pizza").Validate(), ErrExpenseCategoryIsInvalid)
+}
+
+func TestExpenseCategory_Rebase(t *testing.T) {
+	rebased, ok := ExpenseCategory("Food/Pizza").Rebase("food", "meal")
+	assert.True(t, ok)
+	assert.Equal(t, ExpenseCategory("meal/pizza"), rebased)
+
+	rebased, ok = ExpenseCategory("EDA").Rebase("eda", "food")
+	assert.True(t, ok)
+	assert.Equal(t, ExpenseCategory("food"), rebased)
+
+	_, ok = ExpenseCategory("foodstuff").Rebase("food", "meal")
+	assert.False(t, ok)
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// StartOfDay returns the midnight of the t day in t location.
+func StartOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// BudgetPeriodBounds returns the first and the last instants of the budget period containing t in t location.
+// Periods start at startDay of every month, the last day of the month is used if the month is shorter.
+func BudgetPeriodBounds(t time.Time, startDay int) (start, end time.Time) {
+	y, m, _ := t.Date()
+	start = dayOfMonth(y, m, startDay, t.Location())
+	if t.Before(start) {
+		start = dayOfMonth(y, m-1, startDay, t.Location())
+	}
+	y, m, _ = start.Date()
+	end = dayOfMonth(y, m+1, startDay, t.Location()).Add(-1 * time.Nanosecond)
+	return start, end
+}
+
+// dayOfMonth returns the midnight of the month day, the last day of the month is used if the month is shorter.
+func dayOfMonth(year int, month time.Month, day int, loc *time.Location) time.Time {
+	firstDay := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	if lastDay := firstDay.AddDate(0, 1, -1).Day(); day > lastDay {
+		day = lastDay
+	}
+	return firstDay.AddDate(0, 0, day-1)
+}
+
+// EndOfDay returns the last instant of the t day in t location.
+func EndOfDay(t time.Time) time.Time {
+	return StartOfDay(t).AddDate(0, 0, 1).Add(-1 * time.Nanosecond)
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrDebtAmountIsNotPositive = errors.New("debt amount is not positive")
+	ErrDebtAmountTooBig        = errors.New("too big debt amount")
+	ErrDebtToItself            = errors.New("debtor and creditor are the same user")
+)
+
+type (
+	DebtID   int64
+	DebtKind string
+)
+
+const (
+	DebtKindSplit      DebtKind = "split"      // the share of the bill paid by the creditor
+	DebtKindSettlement DebtKind = "settlement" // the repayment, the creditor is the one who paid
+)
+
+// Debt is the amount in the base currency the debtor owes the creditor.
+type Debt struct {
+	ID         DebtID
+	DebtorID   UserID
+	CreditorID UserID
+	Amount     decimal.Decimal
+	Kind       DebtKind
+	Date       time.Time
+	Comment    string
+}
+
+func (d *Debt) Validate() error {
+	switch {
+	case !d.Amount.IsPositive():
+		return ErrDebtAmountIsNotPositive
+	case d.Amount.GreaterThanOrEqual(decimalValueLimit):
+		return ErrDebtAmountTooBig
+	case d.DebtorID == d.CreditorID:
+		return ErrDebtToItself
+	default:
+		return nil
+	}
+}
+
+// SplitAmount splits the amount to n equal shares rounded to cents, the first share gets the rounding remainder.
+func SplitAmount(amount decimal.Decimal, n int) []decimal.Decimal {
+	if n <= 0 {
+		return nil
+	}
+	share := amount.DivRound(decimal.NewFromInt(int64(n)), 2)
+	shares := make([]decimal.Decimal, n)
+	for i := range shares {
+		shares[i] = share
+	}
+	shares[0] = amount.Sub(share.Mul(decimal.NewFromInt(int64(n - 1))))
+	return shares
+}
+
+// NetBalances returns amounts owed to users, negative amounts are owed by users. Settled users are omitted.
+func NetBalances(debts []Debt) map[UserID]decimal.Decimal {
+	balances := make(map[UserID]decimal.Decimal)
+	for _, debt := range debts {
+		balances[debt.CreditorID] = balances[debt.CreditorID].Add(debt.Amount)
+		balances[debt.DebtorID] = balances[debt.DebtorID].Sub(debt.Amount)
+	}
+	for userID, balance := range balances {
+		if balance.IsZero() {
+			delete(balances, userID)
+		}
+	}
+	return balances
+}
+
+// Transfer is the payment suggested to settle debts.
+type Transfer struct {
+	From, To UserID
+	Amount   decimal.Decimal
+}
+
+// SettlementTransfers returns transfers settling the balances. The largest debtor pays the largest creditor
+// on each step, so there are at most n-1 transfers for n users and often fewer, when amounts match.
+func SettlementTransfers(balances map[UserID]decimal.Decimal) []Transfer {
+	type balance struct {
+		userID UserID
+		amount decimal.Decimal // absolute value
+	}
+	var creditors, debtors []balance
+	for userID, amount := range balances {
+		switch {
+		case amount.IsPositive():
+			creditors = append(creditors, balance{userID: userID, amount: amount})
+		case amount.IsNegative():
+			debtors = append(debtors, balance{userID: userID, amount: amount.Neg()})
+		}
+	}
+	byAmountDesc := func(b []balance) func(i, j int) bool {
+		return func(i, j int) bool {
+			if !b[i].amount.Equal(b[j].amount) {
+				return b[i].amount.GreaterThan(b[j].amount)
+			}
+			return b[i].userID < b[j].userID
+		}
+	}
+	var out []Transfer
+	for len(creditors) != 0 && len(debtors) != 0 {
+		sort.Slice(creditors, byAmountDesc(creditors))
+		sort.Slice(debtors, byAmountDesc(debtors))
+		creditor, debtor := &creditors[0], &debtors[0]
+		amount := decimal.Min(creditor.amount, debtor.amount)
+		out = append(out, Transfer{From: debtor.userID, To: creditor.userID, Amount: amount})
+		creditor.amount, debtor.amount = creditor.amount.Sub(amount), debtor.amount.Sub(amount)
+		if creditor.amount.IsZero() {
+			creditors = creditors[1:]
+		}
+		if debtor.amount.IsZero() {
+			debtors = debtors[1:]
+		}
+	}
+	return out
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitAmount(t *testing.T) {
+	shares := SplitAmount(decimal.NewFromInt(100), 3)
+	require.Len(t, shares, 3)
+	assert.Equal(t, "33.34", shares[0].String())
+	assert.Equal(t, "33.33", shares[1].String())
+	assert.Equal(t, "33.33", shares[2].String())
+}
+
+func TestSettlementTransfers(t *testing.T) {
+	const alice, bob, carol, dave = UserID(1), UserID(2), UserID(3), UserID(4)
+	amount := decimal.NewFromInt
+	debts := []Debt{
+		// alice paid 3000 for dinner of three
+		{DebtorID: bob, CreditorID: alice, Amount: amount(1000)},
+		{DebtorID: carol, CreditorID: alice, Amount: amount(1000)},
+		// bob paid 600 for taxi of carol and dave
+		{DebtorID: carol, CreditorID: bob, Amount: amount(300)},
+		{DebtorID: dave, CreditorID: bob, Amount: amount(300)},
+		// carol repaid alice
+		{DebtorID: alice, CreditorID: carol, Amount: amount(500)},
+	}
+	balances := NetBalances(debts)
+	assert.Len(t, balances, 4)
+	assert.True(t, amount(1500).Equal(balances[alice]))
+	assert.True(t, amount(-400).Equal(balances[bob]))
+	assert.True(t, amount(-800).Equal(balances[carol]))
+	assert.True(t, amount(-300).Equal(balances[dave]))
+
+	transfers := SettlementTransfers(balances)
+	require.Len(t, transfers, 3)
+	for _, transfer := range transfers {
+		assert.Equal(t, alice, transfer.To)
+		balances[transfer.From] = balances[transfer.From].Add(transfer.Amount)
+		balances[transfer.To] = balances[transfer.To].Sub(transfer.Amount)
+	}
+	for userID, balance := range balances {
+		assert.Truef(t, balance.IsZero(), "userID=%d is not settled: %v", userID, balance)
+	}
+	assert.Empty(t, SettlementTransfers(NetBalances(nil)))
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -12,19 +14,93 @@ var decimalValueLimit = decimal.NewFromInt(10).Shift(21)
 var (
 	ErrExpenseAmountTooBig        = errors.New("too big expense amount")
 	ErrExpenseAmountIsNotPositive = errors.New("expense amount is not positive")
+	ErrRefundAmountIsNotPositive  = errors.New("refund amount is not positive")
 )
 
 type (
 	ExpenseID       int64
 	ExpenseCategory string
+	MessageID       int64
+	RefundID        int64
+	ExpenseTag      string // lowercase hashtag without '#'
 )
 
+// MessageRef identifies the message the expense was created by, message IDs are unique only within the chat.
+type MessageRef struct {
+	ChatID    int64
+	MessageID MessageID
+}
+
 type Expense struct {
 	ID       ExpenseID
 	Category ExpenseCategory
 	Amount   decimal.Decimal
 	Date     time.Time
 	Comment  string
+	AuthorID UserID // the user who added the expense, zero value means unknown
+	// OriginalAmount in OriginalCurrency is the amount the user typed, Rate is the exchange rate of OriginalCurrency
+	// applied to convert it to the base currency. Zero values mean unknown for expenses added before they were kept.
+	OriginalAmount   decimal.Decimal
+	OriginalCurrency CurrencyCode
+	Rate             decimal.Decimal
+	Refunded         decimal.Decimal // the sum of refunds of the expense in the same currency as Amount
+	Tags             []ExpenseTag    // hashtags of the comment
+}
+
+// Refund returns the part of the expense back, Amount is in the base currency.
+type Refund struct {
+	ID        RefundID
+	ExpenseID ExpenseID
+	Amount    decimal.Decimal
+	Date      time.Time
+}
+
+var tagPattern = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_-]+)`)
+
+// ParseTags returns unique hashtags of the comment in order of their appearance.
+func ParseTags(comment string) []ExpenseTag {
+	var out []ExpenseTag
+	seen := make(map[ExpenseTag]struct{})
+	for _, match := range tagPattern.FindAllStringSubmatch(comment, -1) {
+		tag := ExpenseTag(strings.ToLower(match[1]))
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		out = append(out, tag)
+	}
+	return out
+}
+
+// HasTag reports whether the expense is tagged with the tag, any expense has the empty one.
+func (e *Expense) HasTag(tag ExpenseTag) bool {
+	if tag == "" {
+		return true
+	}
+	for _, t := range e.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// HasOriginal reports whether the amount the user typed is known.
+func (e *Expense) HasOriginal() bool {
+	return e.OriginalCurrency != ""
+}
+
+// NetAmount returns the amount left after refunds.
+func (e *Expense) NetAmount() decimal.Decimal {
+	return e.Amount.Sub(e.Refunded)
+}
+
+// OriginalRefunded returns the refunded amount in the original currency, if it's known.
+func (e *Expense) OriginalRefunded() decimal.Decimal {
+	if !e.HasOriginal() || e.Amount.IsZero() {
+		return e.Refunded
+	}
+	return e.Refunded.Mul(e.OriginalAmount).Div(e.Amount)
 }
 
 func (e *Expense) Validate() error {
@@ -34,6 +110,6 @@ func (e *Expense) Validate() error {
 	case e.Amount.GreaterThanOrEqual(decimalValueLimit):
 		return ErrExpenseAmountTooBig
 	default:
-		return nil
+		return e.Category.Validate()
 	}
 }
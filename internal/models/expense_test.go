@@ -0,0 +1,24 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		comment  string
+		expected []ExpenseTag
+	}{
+		{comment: "", expected: nil},
+		{comment: "dinner", expected: nil},
+		{comment: "#trip-italy dinner #Work", expected: []ExpenseTag{"trip-italy", "work"}},
+		{comment: "pizza #work #WORK #work_lunch", expected: []ExpenseTag{"work", "work_lunch"}},
+		{comment: "room#42 # #", expected: nil},
+		{comment: "отель #поездка", expected: []ExpenseTag{"поездка"}},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, ParseTags(test.comment), test.comment)
+	}
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrIncomeAmountTooBig        = errors.New("too big income amount")
+	ErrIncomeAmountIsNotPositive = errors.New("income amount is not positive")
+)
+
+type (
+	IncomeID     int64
+	IncomeSource string // the income category, e.g. salary
+)
+
+// Income is the inflow of money, its fields have the same meaning as the ones of Expense.
+type Income struct {
+	ID               IncomeID
+	Source           IncomeSource
+	Amount           decimal.Decimal
+	Date             time.Time
+	Comment          string
+	AuthorID         UserID
+	OriginalAmount   decimal.Decimal
+	OriginalCurrency CurrencyCode
+	Rate             decimal.Decimal
+}
+
+func (i *Income) Validate() error {
+	switch {
+	case !i.Amount.IsPositive():
+		return ErrIncomeAmountIsNotPositive
+	case i.Amount.GreaterThanOrEqual(decimalValueLimit):
+		return ErrIncomeAmountTooBig
+	default:
+		return nil
+	}
+}
+
+// HasOriginal reports whether the amount the user typed is known.
+func (i *Income) HasOriginal() bool {
+	return i.OriginalCurrency != ""
+}
+
+// CashFlow describes income and expenses between Start and End instants inclusive in the same currency.
+type CashFlow struct {
+	Start, End time.Time
+	Income     decimal.Decimal
+	Sources    map[IncomeSource]decimal.Decimal
+	Expenses   decimal.Decimal
+}
+
+// Savings returns the income left after expenses, it's negative if expenses exceed the income.
+func (f *CashFlow) Savings() decimal.Decimal {
+	return f.Income.Sub(f.Expenses)
+}
+
+// SavingsRate returns savings in percents of the income, false is returned if there is no income.
+func (f *CashFlow) SavingsRate() (decimal.Decimal, bool) {
+	if !f.Income.IsPositive() {
+		return decimal.Decimal{}, false
+	}
+	return f.Savings().Mul(decimal.NewFromInt(100)).Div(f.Income), true
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const inviteCodeBytes = 8
+
+// Ledger is the account shared by members of the telegram group chat. The ledger ID is the chat ID,
+// it is negative for group chats, so it never matches IDs of users, and is used as the user ID of the ledger
+// expenses, limits and settings.
+type Ledger struct {
+	ID         UserID
+	Title      string
+	InviteCode string // the code of the '/start join_<code>' deep link to join the ledger
+	Members    []LedgerMember
+}
+
+type LedgerMember struct {
+	UserID   UserID
+	Name     string
+	Username string // telegram username without '@', it's empty if the user has no one
+	JoinedAt time.Time
+}
+
+// Member returns the ledger member with the user ID.
+func (l *Ledger) Member(userID UserID) (LedgerMember, bool) {
+	for _, member := range l.Members {
+		if member.UserID == userID {
+			return member, true
+		}
+	}
+	return LedgerMember{}, false
+}
+
+// MemberByUsername returns the ledger member with the telegram username, usernames are case-insensitive.
+func (l *Ledger) MemberByUsername(username string) (LedgerMember, bool) {
+	for _, member := range l.Members {
+		if member.Username != "" && strings.EqualFold(member.Username, username) {
+			return member, true
+		}
+	}
+	return LedgerMember{}, false
+}
+
+// NewInviteCode returns the random code to join the ledger.
+func NewInviteCode() (string, error) {
+	b := make([]byte, inviteCodeBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to generate invite code")
+	}
+	return hex.EncodeToString(b), nil
+}
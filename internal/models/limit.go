@@ -0,0 +1,177 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrLimitTooBig        = errors.New("limit is too big")
+	ErrLimitIsNegative    = errors.New("limit is negative")
+	ErrLimitPeriodInvalid = errors.New("limit period is invalid")
+
+	ErrRolloverCapIsNegative = errors.New("rollover cap is negative")
+)
+
+// LimitPeriod is the kind of period the limit applies to.
+type LimitPeriod string
+
+const (
+	LimitPeriodWeek    LimitPeriod = "week"    // weeks start on monday
+	LimitPeriodMonth   LimitPeriod = "month"   // the user budget period
+	LimitPeriodQuarter LimitPeriod = "quarter" // calendar quarter
+	LimitPeriodYear    LimitPeriod = "year"    // calendar year
+)
+
+// LimitPeriods lists all the limit periods from the shortest to the longest one.
+var LimitPeriods = []LimitPeriod{LimitPeriodWeek, LimitPeriodMonth, LimitPeriodQuarter, LimitPeriodYear}
+
+// Order returns the position of the period in LimitPeriods, -1 is returned for the unknown period.
+func (p LimitPeriod) Order() int {
+	for i, period := range LimitPeriods {
+		if period == p {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p LimitPeriod) Validate() error {
+	if p.Order() < 0 {
+		return errors.Wrapf(ErrLimitPeriodInvalid, "period %q", p)
+	}
+	return nil
+}
+
+// Bounds returns the first and the last instants of the period containing t in t location.
+// The budget period starting at startDay of month is used for the month limits. The period must be valid.
+func (p LimitPeriod) Bounds(t time.Time, startDay int) (start, end time.Time) {
+	y, m, _ := t.Date()
+	switch p {
+	case LimitPeriodWeek:
+		day := StartOfDay(t)
+		start = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+		end = start.AddDate(0, 0, 7)
+	case LimitPeriodMonth:
+		return BudgetPeriodBounds(t, startDay)
+	case LimitPeriodQuarter:
+		start = time.Date(y, m-(m-1)%3, 1, 0, 0, 0, 0, t.Location())
+		end = start.AddDate(0, 3, 0)
+	default:
+		start = time.Date(y, time.January, 1, 0, 0, 0, 0, t.Location())
+		end = start.AddDate(1, 0, 0)
+	}
+	return start, end.Add(-1 * time.Nanosecond)
+}
+
+// Rollover carries the unspent or overspent amount of the limit to the next period.
+type Rollover struct {
+	Since time.Time        // amounts are carried starting from the period containing this instant
+	Cap   *decimal.Decimal // max absolute value of the carried amount, nil value means no cap
+}
+
+func (r *Rollover) Validate() error {
+	switch {
+	case r.Cap == nil:
+		return nil
+	case r.Cap.IsNegative():
+		return ErrRolloverCapIsNegative
+	case r.Cap.GreaterThanOrEqual(decimalValueLimit):
+		return ErrLimitTooBig
+	default:
+		return nil
+	}
+}
+
+// Carry returns the amount carried to the next period given the remaining amount of the period.
+func (r *Rollover) Carry(remaining decimal.Decimal) decimal.Decimal {
+	if r.Cap == nil {
+		return remaining
+	}
+	if remaining.GreaterThan(*r.Cap) {
+		return *r.Cap
+	}
+	if remaining.LessThan(r.Cap.Neg()) {
+		return r.Cap.Neg()
+	}
+	return remaining
+}
+
+// Limit is the limit of expenses per period in its own currency, it's converted to the base currency
+// at the moment of the check. The limit is applied to all the expenses if the category is empty.
+type Limit struct {
+	Period   LimitPeriod
+	Category ExpenseCategory
+	Amount   decimal.Decimal
+	Currency CurrencyCode // empty value means the base currency
+	Rollover *Rollover    // nil value means the unspent amount is lost at the end of the period
+}
+
+func (l *Limit) Validate() error {
+	switch {
+	case l.Amount.IsNegative():
+		return ErrLimitIsNegative
+	case l.Amount.GreaterThanOrEqual(decimalValueLimit):
+		return ErrLimitTooBig
+	case l.Rollover != nil:
+		if err := l.Rollover.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := l.Category.Validate(); err != nil {
+		return err
+	}
+	return l.Period.Validate()
+}
+
+// AppliesTo reports whether the expense is counted against the limit, the limit of the category covers its children.
+func (l *Limit) AppliesTo(exp *Expense) bool {
+	return l.Category == "" || exp.Category.IsWithin(l.Category)
+}
+
+// Name returns the human-readable limit name like 'weekly limit of category "food"'.
+func (l *Limit) Name() string {
+	name := string(l.Period) + "ly limit"
+	if l.Category != "" {
+		name += fmt.Sprintf(" of category %q", l.Category)
+	}
+	return name
+}
+
+// AmountString returns the limit amount with the currency, if it's not the base one.
+func (l *Limit) AmountString() string {
+	if l.Currency == "" {
+		return l.Amount.String()
+	}
+	return l.Amount.String() + " " + string(l.Currency)
+}
+
+// LimitStatus is the limit with amounts spent in the period and carried from the previous ones in the limit currency.
+type LimitStatus struct {
+	Limit
+	Start, End time.Time // the first and the last instants of the period
+	Carried    decimal.Decimal
+	Spent      decimal.Decimal
+}
+
+// Allowance returns the amount allowed to spend in the period including the carried one.
+func (s *LimitStatus) Allowance() decimal.Decimal {
+	return s.Amount.Add(s.Carried)
+}
+
+// Remaining returns the amount left till the allowance is exhausted, it's negative if the allowance is overspent.
+func (s *LimitStatus) Remaining() decimal.Decimal {
+	return s.Allowance().Sub(s.Spent)
+}
+
+// SpentPercent returns the spent share of the allowance in percents, not positive allowance is treated as 100% spent.
+func (s *LimitStatus) SpentPercent() decimal.Decimal {
+	allowance := s.Allowance()
+	if !allowance.IsPositive() {
+		return decimal.NewFromInt(100)
+	}
+	return s.Spent.Mul(decimal.NewFromInt(100)).Div(allowance).Round(0)
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitPeriod_Bounds(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	moment := time.Date(2022, time.November, 16, 13, 45, 0, 0, time.UTC) // wednesday
+	tests := []struct {
+		period        LimitPeriod
+		startDay      int
+		expectedStart time.Time
+		expectedEnd   time.Time
+	}{
+		{
+			period:        LimitPeriodWeek,
+			expectedStart: day(2022, time.November, 14),
+			expectedEnd:   day(2022, time.November, 21),
+		},
+		{
+			period:        LimitPeriodMonth,
+			startDay:      20,
+			expectedStart: day(2022, time.October, 20),
+			expectedEnd:   day(2022, time.November, 20),
+		},
+		{
+			period:        LimitPeriodQuarter,
+			expectedStart: day(2022, time.October, 1),
+			expectedEnd:   day(2023, time.January, 1),
+		},
+		{
+			period:        LimitPeriodYear,
+			expectedStart: day(2022, time.January, 1),
+			expectedEnd:   day(2023, time.January, 1),
+		},
+	}
+	for _, test := range tests {
+		testCase := test
+		t.Run(string(testCase.period), func(t *testing.T) {
+			require.NoError(t, testCase.period.Validate())
+			start, end := testCase.period.Bounds(moment, testCase.startDay)
+			assert.Equal(t, testCase.expectedStart, start)
+			assert.Equal(t, testCase.expectedEnd.Add(-1*time.Nanosecond), end)
+		})
+	}
+}
+
+func TestLimit_Validate(t *testing.T) {
+	require.ErrorIs(t, (&Limit{Period: "day", Amount: decimal.NewFromInt(1)}).Validate(), ErrLimitPeriodInvalid)
+	require.ErrorIs(t, (&Limit{Period: LimitPeriodWeek, Amount: decimalValueLimit}).Validate(), ErrLimitTooBig)
+	require.ErrorIs(t, (&Limit{Period: LimitPeriodWeek, Amount: decimalValueLimit.Neg()}).Validate(), ErrLimitIsNegative)
+}
+
+func TestRollover_Carry(t *testing.T) {
+	limitCap := decimal.NewFromInt(100)
+	tests := []struct {
+		rollover  Rollover
+		remaining int64
+		expected  int64
+	}{
+		{rollover: Rollover{}, remaining: 500, expected: 500},
+		{rollover: Rollover{}, remaining: -500, expected: -500},
+		{rollover: Rollover{Cap: &limitCap}, remaining: 50, expected: 50},
+		{rollover: Rollover{Cap: &limitCap}, remaining: 500, expected: 100},
+		{rollover: Rollover{Cap: &limitCap}, remaining: -500, expected: -100},
+	}
+	for _, test := range tests {
+		actual := test.rollover.Carry(decimal.NewFromInt(test.remaining))
+		assert.Truef(t, decimal.NewFromInt(test.expected).Equal(actual), "remaining %d: want %d, got %v", test.remaining, test.expected, actual)
+	}
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrRecurrenceRuleInvalid = errors.New("recurrence rule is invalid")
+)
+
+type (
+	RecurringExpenseID int64
+	RecurrenceKind     string
+)
+
+const (
+	RecurrenceMonthly   RecurrenceKind = "monthly" // Value is the day of month
+	RecurrenceWeekly    RecurrenceKind = "weekly"  // Value is the time.Weekday
+	RecurrenceEveryDays RecurrenceKind = "every"   // Value is the count of days between occurrences
+)
+
+const (
+	maxRecurrenceMonthDay = 31
+	maxRecurrenceDays     = 366
+)
+
+type RecurrenceRule struct {
+	Kind  RecurrenceKind
+	Value int
+}
+
+func (r RecurrenceRule) Validate() error {
+	switch r.Kind {
+	case RecurrenceMonthly:
+		if r.Value < 1 || r.Value > maxRecurrenceMonthDay {
+			return errors.Wrapf(ErrRecurrenceRuleInvalid, "day of month %d", r.Value)
+		}
+	case RecurrenceWeekly:
+		if r.Value < int(time.Sunday) || r.Value > int(time.Saturday) {
+			return errors.Wrapf(ErrRecurrenceRuleInvalid, "weekday %d", r.Value)
+		}
+	case RecurrenceEveryDays:
+		if r.Value < 1 || r.Value > maxRecurrenceDays {
+			return errors.Wrapf(ErrRecurrenceRuleInvalid, "days count %d", r.Value)
+		}
+	default:
+		return errors.Wrapf(ErrRecurrenceRuleInvalid, "kind %q", r.Kind)
+	}
+	return nil
+}
+
+// FirstOccurrence returns the first occurrence day not before the since day. The rule must be valid.
+func (r RecurrenceRule) FirstOccurrence(since time.Time) time.Time {
+	since = StartOfDay(since)
+	switch r.Kind {
+	case RecurrenceMonthly:
+		y, m, _ := since.Date()
+		if day := dayOfMonth(y, m, r.Value, since.Location()); !day.Before(since) {
+			return day
+		}
+		return dayOfMonth(y, m+1, r.Value, since.Location())
+	case RecurrenceWeekly:
+		daysAhead := (r.Value - int(since.Weekday()) + 7) % 7
+		return since.AddDate(0, 0, daysAhead)
+	default:
+		return since
+	}
+}
+
+// NextOccurrence returns the occurrence day following the prev occurrence. The rule must be valid.
+func (r RecurrenceRule) NextOccurrence(prev time.Time) time.Time {
+	if r.Kind == RecurrenceEveryDays {
+		return StartOfDay(prev).AddDate(0, 0, r.Value)
+	}
+	return r.FirstOccurrence(StartOfDay(prev).AddDate(0, 0, 1))
+}
+
+func (r RecurrenceRule) String() string {
+	switch r.Kind {
+	case RecurrenceMonthly:
+		return fmt.Sprintf("monthly on day %d", r.Value)
+	case RecurrenceWeekly:
+		return fmt.Sprintf("weekly on %s", time.Weekday(r.Value))
+	case RecurrenceEveryDays:
+		return fmt.Sprintf("every %d days", r.Value)
+	default:
+		return fmt.Sprintf("unknown rule %q", r.Kind)
+	}
+}
+
+type RecurringExpense struct {
+	ID       RecurringExpenseID
+	UserID   UserID
+	Category ExpenseCategory
+	Amount   decimal.Decimal // in the user selected currency at the moment of the occurrence
+	Comment  string
+	Rule     RecurrenceRule
+	NextDate time.Time // the day of the next occurrence which is not created yet
+	Paused   bool
+}
+
+func (r *RecurringExpense) Validate() error {
+	exp := r.Expense(r.NextDate)
+	if err := exp.Validate(); err != nil {
+		return err
+	}
+	return r.Rule.Validate()
+}
+
+// Expense returns the expense of the occurrence at the date.
+func (r *RecurringExpense) Expense(date time.Time) Expense {
+	return Expense{
+		Category: r.Category,
+		Amount:   r.Amount,
+		Date:     date,
+		Comment:  r.Comment,
+	}
+}
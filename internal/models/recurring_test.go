@@ -0,0 +1,64 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecurrenceRule_Occurrences(t *testing.T) {
+	day := func(m time.Month, d int) time.Time {
+		return time.Date(2022, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name     string
+		rule     RecurrenceRule
+		since    time.Time
+		expected []time.Time
+	}{
+		{
+			name:     "monthly",
+			rule:     RecurrenceRule{Kind: RecurrenceMonthly, Value: 10},
+			since:    day(time.October, 12),
+			expected: []time.Time{day(time.November, 10), day(time.December, 10)},
+		},
+		{
+			name:     "monthly at the month end",
+			rule:     RecurrenceRule{Kind: RecurrenceMonthly, Value: 31},
+			since:    day(time.January, 31),
+			expected: []time.Time{day(time.January, 31), day(time.February, 28), day(time.March, 31)},
+		},
+		{
+			name:     "weekly",
+			rule:     RecurrenceRule{Kind: RecurrenceWeekly, Value: int(time.Monday)},
+			since:    day(time.October, 12), // wednesday
+			expected: []time.Time{day(time.October, 17), day(time.October, 24)},
+		},
+		{
+			name:     "every days",
+			rule:     RecurrenceRule{Kind: RecurrenceEveryDays, Value: 3},
+			since:    day(time.October, 30),
+			expected: []time.Time{day(time.October, 30), day(time.November, 2)},
+		},
+	}
+	for _, test := range tests {
+		testCase := test
+		t.Run(testCase.name, func(t *testing.T) {
+			require.NoError(t, testCase.rule.Validate())
+			actual := []time.Time{testCase.rule.FirstOccurrence(testCase.since)}
+			for len(actual) < len(testCase.expected) {
+				actual = append(actual, testCase.rule.NextOccurrence(actual[len(actual)-1]))
+			}
+			assert.Equal(t, testCase.expected, actual)
+		})
+	}
+}
+
+func TestRecurrenceRule_Validate(t *testing.T) {
+	require.ErrorIs(t, RecurrenceRule{Kind: RecurrenceMonthly, Value: 32}.Validate(), ErrRecurrenceRuleInvalid)
+	require.ErrorIs(t, RecurrenceRule{Kind: RecurrenceWeekly, Value: 7}.Validate(), ErrRecurrenceRuleInvalid)
+	require.ErrorIs(t, RecurrenceRule{Kind: RecurrenceEveryDays, Value: 0}.Validate(), ErrRecurrenceRuleInvalid)
+	require.ErrorIs(t, RecurrenceRule{Kind: "yearly", Value: 1}.Validate(), ErrRecurrenceRuleInvalid)
+}
@@ -1,41 +1,135 @@
 package models
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/pkg/errors"
-	"github.com/shopspring/decimal"
 )
 
 var (
-	ErrUserMonthlyLimitTooBig     = errors.New("user monthly limit is too big")
-	ErrUserMonthlyLimitIsNegative = errors.New("user monthly limit is negative")
+	ErrUnknownTimeZone           = errors.New("unknown time zone")
+	ErrUserPeriodStartDayInvalid = errors.New("user budget period start day is out of range")
+	ErrUserLimitModeInvalid      = errors.New("user limit mode is invalid")
+)
+
+const (
+	DefaultUserPeriodStartDay = 1
+	maxUserPeriodStartDay     = 31
 )
 
 type UserID int64
 
+// LimitMode defines what happens with the expense exceeding the limit.
+type LimitMode string
+
+const (
+	LimitModeHard LimitMode = "hard" // the expense is rejected
+	LimitModeSoft LimitMode = "soft" // the expense is accepted, but the user is warned
+)
+
 type User struct {
 	ID               UserID
 	SelectedCurrency CurrencyCode
-	MonthlyLimit     *decimal.Decimal // nil value means no limit
+	TimeZone         *time.Location // nil value means UTC
+	PeriodStartDay   int            // day of month when the budget period starts
+	LimitMode        LimitMode
 }
 
 func NewUser(id UserID, curr CurrencyCode) User {
-	return User{ID: id, SelectedCurrency: curr}
+	return User{ID: id, SelectedCurrency: curr, PeriodStartDay: DefaultUserPeriodStartDay, LimitMode: LimitModeHard}
+}
+
+// Location returns the user time zone, UTC is used by default.
+func (u *User) Location() *time.Location {
+	if u.TimeZone == nil {
+		return time.UTC
+	}
+	return u.TimeZone
 }
 
 func (u *User) Validate() error {
-	return ValidateUserMonthlyLimit(u.MonthlyLimit)
+	if err := ValidateUserPeriodStartDay(u.PeriodStartDay); err != nil {
+		return err
+	}
+	return ValidateUserLimitMode(u.LimitMode)
 }
 
-func ValidateUserMonthlyLimit(monthlyLimit *decimal.Decimal) error {
-	if monthlyLimit == nil {
-		return nil
+func ValidateUserPeriodStartDay(day int) error {
+	if day < 1 || day > maxUserPeriodStartDay {
+		return ErrUserPeriodStartDayInvalid
 	}
-	switch {
-	case monthlyLimit.IsNegative():
-		return ErrUserMonthlyLimitIsNegative
-	case monthlyLimit.GreaterThanOrEqual(decimalValueLimit):
-		return ErrUserMonthlyLimitTooBig
-	default:
+	return nil
+}
+
+func ValidateUserLimitMode(mode LimitMode) error {
+	switch mode {
+	case LimitModeHard, LimitModeSoft:
 		return nil
+	default:
+		return errors.Wrapf(ErrUserLimitModeInvalid, "mode %q", mode)
+	}
+}
+
+const maxTimeZoneOffset = 14 * time.Hour
+
+// ParseTimeZone parses IANA time zone name like 'Europe/Moscow' or UTC offset like 'UTC+3', '+05:30'.
+// The name of the returned location can be parsed back by ParseTimeZone.
+func ParseTimeZone(name string) (*time.Location, error) {
+	if upper := strings.ToUpper(name); strings.HasPrefix(upper, "UTC") && upper != "UTC" {
+		return parseTimeZoneOffset(strings.TrimPrefix(upper, "UTC"))
+	}
+	if strings.HasPrefix(name, "+") || strings.HasPrefix(name, "-") {
+		return parseTimeZoneOffset(name)
+	}
+	if name == "" || name == "Local" { // time.LoadLocation treats them specially
+		return nil, errors.Wrapf(ErrUnknownTimeZone, "time zone %q", name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, errors.Wrapf(ErrUnknownTimeZone, "time zone %q: %v", name, err)
+	}
+	return loc, nil
+}
+
+func parseTimeZoneOffset(offset string) (*time.Location, error) {
+	invalidErr := errors.Wrapf(ErrUnknownTimeZone, "time zone offset %q", offset)
+	if len(offset) < 2 || (offset[0] != '+' && offset[0] != '-') {
+		return nil, invalidErr
+	}
+	hoursStr, minutesStr, hasMinutes := strings.Cut(offset[1:], ":")
+	if !isDigits(hoursStr) || len(hoursStr) > 2 || (hasMinutes && (!isDigits(minutesStr) || len(minutesStr) != 2)) {
+		return nil, invalidErr
+	}
+	hours, _ := strconv.Atoi(hoursStr)
+	var minutes int
+	if hasMinutes {
+		minutes, _ = strconv.Atoi(minutesStr)
+	}
+	abs := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute
+	if minutes >= 60 || abs > maxTimeZoneOffset {
+		return nil, invalidErr
+	}
+	if abs == 0 {
+		return time.UTC, nil
+	}
+	name := fmt.Sprintf("UTC%c%02d:%02d", offset[0], hours, minutes)
+	if offset[0] == '-' {
+		abs = -abs
+	}
+	return time.FixedZone(name, int(abs/time.Second)), nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
 	}
+	return true
 }
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeZone(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectedName string
+		fails        bool
+	}{
+		{name: "UTC", expectedName: "UTC"},
+		{name: "utc+0", expectedName: "UTC"},
+		{name: "UTC+3", expectedName: "UTC+03:00"},
+		{name: "+05:30", expectedName: "UTC+05:30"},
+		{name: "UTC-10", expectedName: "UTC-10:00"},
+		{name: "UTC+03:00", expectedName: "UTC+03:00"},
+		{name: "UTC+15", fails: true},
+		{name: "UTC+3:5", fails: true},
+		{name: "+3:60", fails: true},
+		{name: "UTC3", fails: true},
+		{name: "Local", fails: true},
+		{name: "", fails: true},
+		{name: "Mars/Olympus", fails: true},
+	}
+	for _, test := range tests {
+		testCase := test
+		t.Run(testCase.name, func(t *testing.T) {
+			loc, err := ParseTimeZone(testCase.name)
+			if testCase.fails {
+				require.ErrorIs(t, err, ErrUnknownTimeZone)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expectedName, loc.String())
+			// the name is stored in the repository and must be parsed back
+			parsed, err := ParseTimeZone(loc.String())
+			require.NoError(t, err)
+			assert.Equal(t, loc.String(), parsed.String())
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package recurring
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+)
+
+var (
+	ErrDoesNotExist    = errors.New("recurring expense does not exist")
+	ErrAlreadyAdvanced = errors.New("recurring expense occurrence is already processed")
+	ErrAlreadyPaused   = errors.New("recurring expense is already paused")
+	ErrAlreadyActive   = errors.New("recurring expense is already active")
+)
+
+type Repository interface {
+	Isolated(ctx context.Context, callback func(ctx context.Context) error) error
+	AddRecurringExpense(ctx context.Context, rec models.RecurringExpense) (models.RecurringExpense, error)
+	GetRecurringExpenseByID(ctx context.Context, userID models.UserID, id models.RecurringExpenseID) (models.RecurringExpense, error)
+	GetRecurringExpenses(ctx context.Context, userID models.UserID) ([]models.RecurringExpense, error)
+	SetRecurringExpensePaused(ctx context.Context, userID models.UserID, id models.RecurringExpenseID, paused bool, nextDate time.Time) error
+	DeleteRecurringExpense(ctx context.Context, userID models.UserID, id models.RecurringExpenseID) error
+	// GetDueRecurringExpenses returns not paused recurring expenses of all users with the next occurrence not after till.
+	GetDueRecurringExpenses(ctx context.Context, till time.Time) ([]models.RecurringExpense, error)
+	// AdvanceRecurringExpense moves the next occurrence from the prev date to the next one.
+	// ErrAlreadyAdvanced is returned if the next occurrence is not the prev date anymore.
+	AdvanceRecurringExpense(ctx context.Context, id models.RecurringExpenseID, prev, next time.Time) error
+}
+
+type UseCase interface {
+	AddRecurringExpense(ctx context.Context, userID models.UserID, rec models.RecurringExpense) (models.RecurringExpense, error)
+	GetRecurringExpenses(ctx context.Context, userID models.UserID) ([]models.RecurringExpense, error)
+	PauseRecurringExpense(ctx context.Context, userID models.UserID, id models.RecurringExpenseID) error
+	ResumeRecurringExpense(ctx context.Context, userID models.UserID, id models.RecurringExpenseID) (models.RecurringExpense, error)
+	DeleteRecurringExpense(ctx context.Context, userID models.UserID, id models.RecurringExpenseID) error
+}
+
+type MessageSender interface {
+	SendMessage(chatID int64, message string) error
+}
@@ -0,0 +1,125 @@
+package inmemory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/recurring"
+)
+
+type Repository struct {
+	mu         *sync.RWMutex
+	isolatedMu *sync.Mutex
+	lastID     models.RecurringExpenseID
+	storage    map[models.RecurringExpenseID]models.RecurringExpense
+}
+
+func New() (*Repository, error) {
+	return &Repository{
+		mu:         &sync.RWMutex{},
+		isolatedMu: &sync.Mutex{},
+		storage:    make(map[models.RecurringExpenseID]models.RecurringExpense),
+	}, nil
+}
+
+func (r *Repository) Isolated(ctx context.Context, callback func(ctx context.Context) error) error {
+	r.isolatedMu.Lock()
+	defer r.isolatedMu.Unlock()
+	return callback(ctx)
+}
+
+func (r *Repository) AddRecurringExpense(ctx context.Context, rec models.RecurringExpense) (models.RecurringExpense, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastID++
+	rec.ID = r.lastID
+	r.storage[rec.ID] = rec
+	return rec, nil
+}
+
+func (r *Repository) GetRecurringExpenseByID(ctx context.Context, userID models.UserID, id models.RecurringExpenseID) (models.RecurringExpense, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rec, ok := r.storage[id]
+	if !ok || rec.UserID != userID {
+		return models.RecurringExpense{}, recurring.ErrDoesNotExist
+	}
+	return rec, nil
+}
+
+func (r *Repository) GetRecurringExpenses(ctx context.Context, userID models.UserID) ([]models.RecurringExpense, error) {
+	return r.filter(func(rec *models.RecurringExpense) bool {
+		return rec.UserID == userID
+	}), nil
+}
+
+func (r *Repository) SetRecurringExpensePaused(
+	ctx context.Context,
+	userID models.UserID,
+	id models.RecurringExpenseID,
+	paused bool,
+	nextDate time.Time,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, ok := r.storage[id]
+	if !ok || rec.UserID != userID {
+		return recurring.ErrDoesNotExist
+	}
+	rec.Paused, rec.NextDate = paused, nextDate
+	r.storage[id] = rec
+	return nil
+}
+
+func (r *Repository) DeleteRecurringExpense(ctx context.Context, userID models.UserID, id models.RecurringExpenseID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, ok := r.storage[id]
+	if !ok || rec.UserID != userID {
+		return recurring.ErrDoesNotExist
+	}
+	delete(r.storage, id)
+	return nil
+}
+
+func (r *Repository) GetDueRecurringExpenses(ctx context.Context, till time.Time) ([]models.RecurringExpense, error) {
+	return r.filter(func(rec *models.RecurringExpense) bool {
+		return !rec.Paused && !rec.NextDate.After(till)
+	}), nil
+}
+
+func (r *Repository) AdvanceRecurringExpense(ctx context.Context, id models.RecurringExpenseID, prev, next time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, ok := r.storage[id]
+	if !ok || rec.Paused || !rec.NextDate.Equal(prev) {
+		return recurring.ErrAlreadyAdvanced
+	}
+	rec.NextDate = next
+	r.storage[id] = rec
+	return nil
+}
+
+func (r *Repository) filter(predicate func(rec *models.RecurringExpense) bool) []models.RecurringExpense {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var out []models.RecurringExpense
+	for _, rec := range r.storage {
+		if predicate(&rec) {
+			out = append(out, rec)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/common/database/postgres"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/recurring"
+)
+
+const selectRecurringExpenses = "SELECT id, user_id, category, amount, comment, rule_kind, rule_value, next_date, paused FROM recurring_expenses"
+
+type Repository struct {
+	db postgres.DBDoer
+}
+
+func New(db postgres.DBDoer) (*Repository, error) {
+	return &Repository{db: db}, nil
+}
+
+func (r *Repository) Isolated(ctx context.Context, callback func(ctx context.Context) error) error {
+	return r.db.DoIsolated(ctx, nil, callback)
+}
+
+func (r *Repository) AddRecurringExpense(ctx context.Context, rec models.RecurringExpense) (models.RecurringExpense, error) {
+	err := r.db.Do(ctx).QueryRowContext(ctx,
+		`INSERT INTO recurring_expenses (user_id, category, amount, comment, rule_kind, rule_value, next_date, paused)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+		rec.UserID, rec.Category, rec.Amount, rec.Comment, rec.Rule.Kind, rec.Rule.Value, rec.NextDate, rec.Paused,
+	).Scan(&rec.ID)
+	if err != nil {
+		return models.RecurringExpense{}, errors.Wrapf(err, "failed to add recurring expense for userID=%d to db", rec.UserID)
+	}
+	return rec, nil
+}
+
+func (r *Repository) GetRecurringExpenseByID(ctx context.Context, userID models.UserID, id models.RecurringExpenseID) (models.RecurringExpense, error) {
+	rows, err := r.db.Do(ctx).QueryContext(ctx, selectRecurringExpenses+" WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return models.RecurringExpense{}, errors.Wrapf(err, "failed to get recurring expenseID=%d from db", id)
+	}
+	recs, err := scanRecurringExpenses(rows)
+	if err != nil {
+		return models.RecurringExpense{}, errors.Wrapf(err, "failed to get recurring expenseID=%d from db", id)
+	}
+	if len(recs) == 0 {
+		return models.RecurringExpense{}, recurring.ErrDoesNotExist
+	}
+	return recs[0], nil
+}
+
+func (r *Repository) GetRecurringExpenses(ctx context.Context, userID models.UserID) ([]models.RecurringExpense, error) {
+	rows, err := r.db.Do(ctx).QueryContext(ctx, selectRecurringExpenses+" WHERE user_id = $1 ORDER BY id", userID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get recurring expenses for userID=%d from db", userID)
+	}
+	recs, err := scanRecurringExpenses(rows)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get recurring expenses for userID=%d from db", userID)
+	}
+	return recs, nil
+}
+
+func (r *Repository) SetRecurringExpensePaused(
+	ctx context.Context,
+	userID models.UserID,
+	id models.RecurringExpenseID,
+	paused bool,
+	nextDate time.Time,
+) error {
+	res, err := r.db.Do(ctx).ExecContext(ctx,
+		"UPDATE recurring_expenses SET paused = $1, next_date = $2 WHERE id = $3 AND user_id = $4",
+		paused, nextDate, id, userID,
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set paused=%t for recurring expenseID=%d in db", paused, id)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "failed to set paused=%t for recurring expenseID=%d in db", paused, id)
+	}
+	if affected == 0 {
+		return recurring.ErrDoesNotExist
+	}
+	return nil
+}
+
+func (r *Repository) DeleteRecurringExpense(ctx context.Context, userID models.UserID, id models.RecurringExpenseID) error {
+	res, err := r.db.Do(ctx).ExecContext(ctx, "DELETE FROM recurring_expenses WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete recurring expenseID=%d from db", id)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete recurring expenseID=%d from db", id)
+	}
+	if affected == 0 {
+		return recurring.ErrDoesNotExist
+	}
+	return nil
+}
+
+func (r *Repository) GetDueRecurringExpenses(ctx context.Context, till time.Time) ([]models.RecurringExpense, error) {
+	rows, err := r.db.Do(ctx).QueryContext(ctx,
+		selectRecurringExpenses+" WHERE NOT paused AND next_date <= $1 ORDER BY next_date",
+		till,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get due recurring expenses from db")
+	}
+	recs, err := scanRecurringExpenses(rows)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get due recurring expenses from db")
+	}
+	return recs, nil
+}
+
+func (r *Repository) AdvanceRecurringExpense(ctx context.Context, id models.RecurringExpenseID, prev, next time.Time) error {
+	// the condition on the next_date guarantees that the occurrence is processed only once by concurrent workers
+	res, err := r.db.Do(ctx).ExecContext(ctx,
+		"UPDATE recurring_expenses SET next_date = $1 WHERE id = $2 AND next_date = $3 AND NOT paused",
+		next, id, prev,
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to advance recurring expenseID=%d in db", id)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "failed to advance recurring expenseID=%d in db", id)
+	}
+	if affected == 0 {
+		return recurring.ErrAlreadyAdvanced
+	}
+	return nil
+}
+
+func scanRecurringExpenses(rows *sql.Rows) (_ []models.RecurringExpense, err error) {
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+	var out []models.RecurringExpense
+	for rows.Next() {
+		var rec models.RecurringExpense
+		err := rows.Scan(
+			&rec.ID, &rec.UserID, &rec.Category, &rec.Amount, &rec.Comment,
+			&rec.Rule.Kind, &rec.Rule.Value, &rec.NextDate, &rec.Paused,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan recurring expense")
+		}
+		out = append(out, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "error occurred after scanning recurring expenses")
+	}
+	return out, nil
+}
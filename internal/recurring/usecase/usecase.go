@@ -0,0 +1,307 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/pkg/errors"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/recurring"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/user"
+	"go.uber.org/zap"
+)
+
+const (
+	userIDSpanTagKey             = "user_id"
+	recurringExpenseIDSpanTagKey = "recurring_expense_id"
+	dueCountSpanTagKey           = "due_count"
+)
+
+const dateLayout = "2006.01.02"
+
+type UseCase struct {
+	repo     recurring.Repository
+	expUC    expense.UseCase
+	userRepo user.Repository
+}
+
+func New(repo recurring.Repository, expUC expense.UseCase, userRepo user.Repository) (*UseCase, error) {
+	return &UseCase{
+		repo:     repo,
+		expUC:    expUC,
+		userRepo: userRepo,
+	}, nil
+}
+
+// AddRecurringExpense creates the recurring expense with the first occurrence not before today in the user time zone.
+func (u *UseCase) AddRecurringExpense(ctx context.Context, userID models.UserID, rec models.RecurringExpense) (_ models.RecurringExpense, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "AddRecurringExpense")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+
+	rec.UserID = userID
+	if err := rec.Rule.Validate(); err != nil {
+		return models.RecurringExpense{}, errors.Wrap(err, "recurrence rule validation failed")
+	}
+	today, err := u.getUserToday(ctx, userID)
+	if err != nil {
+		return models.RecurringExpense{}, err
+	}
+	rec.NextDate = rec.Rule.FirstOccurrence(today)
+	if err := rec.Validate(); err != nil {
+		return models.RecurringExpense{}, errors.Wrap(err, "recurring expense validation failed")
+	}
+	return u.repo.AddRecurringExpense(ctx, rec)
+}
+
+func (u *UseCase) GetRecurringExpenses(ctx context.Context, userID models.UserID) (_ []models.RecurringExpense, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "GetRecurringExpenses")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+
+	today, err := u.getUserToday(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	recs, err := u.repo.GetRecurringExpenses(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range recs {
+		recs[i].NextDate = recs[i].NextDate.In(today.Location())
+	}
+	return recs, nil
+}
+
+func (u *UseCase) PauseRecurringExpense(ctx context.Context, userID models.UserID, id models.RecurringExpenseID) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "PauseRecurringExpense")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+	span.SetTag(recurringExpenseIDSpanTagKey, id)
+
+	return u.repo.Isolated(ctx, func(ctx context.Context) error {
+		rec, err := u.repo.GetRecurringExpenseByID(ctx, userID, id)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get recurring expenseID=%d", id)
+		}
+		if rec.Paused {
+			return recurring.ErrAlreadyPaused
+		}
+		return u.repo.SetRecurringExpensePaused(ctx, userID, id, true, rec.NextDate)
+	})
+}
+
+// ResumeRecurringExpense resumes the recurring expense, occurrences missed while it was paused are skipped.
+func (u *UseCase) ResumeRecurringExpense(ctx context.Context, userID models.UserID, id models.RecurringExpenseID) (_ models.RecurringExpense, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ResumeRecurringExpense")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+	span.SetTag(recurringExpenseIDSpanTagKey, id)
+
+	today, err := u.getUserToday(ctx, userID)
+	if err != nil {
+		return models.RecurringExpense{}, err
+	}
+	var out models.RecurringExpense
+	err = u.repo.Isolated(ctx, func(ctx context.Context) error {
+		rec, err := u.repo.GetRecurringExpenseByID(ctx, userID, id)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get recurring expenseID=%d", id)
+		}
+		if !rec.Paused {
+			return recurring.ErrAlreadyActive
+		}
+		rec.NextDate = rec.NextDate.In(today.Location())
+		if rec.NextDate.Before(today) {
+			rec.NextDate = rec.Rule.FirstOccurrence(today)
+		}
+		rec.Paused = false
+		out = rec
+		return u.repo.SetRecurringExpensePaused(ctx, userID, id, false, rec.NextDate)
+	})
+	if err != nil {
+		return models.RecurringExpense{}, err
+	}
+	return out, nil
+}
+
+func (u *UseCase) DeleteRecurringExpense(ctx context.Context, userID models.UserID, id models.RecurringExpenseID) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "DeleteRecurringExpense")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, userID)
+	span.SetTag(recurringExpenseIDSpanTagKey, id)
+
+	return u.repo.DeleteRecurringExpense(ctx, userID, id)
+}
+
+func (u *UseCase) getUserToday(ctx context.Context, userID models.UserID) (time.Time, error) {
+	loc, err := u.userRepo.GetUserTimeZone(ctx, userID)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to get user time zone by userID=%d", userID)
+	}
+	return models.StartOfDay(time.Now().In(loc)), nil
+}
+
+// occurrenceResult describes the processed occurrence of the recurring expense.
+type occurrenceResult struct {
+	rec     models.RecurringExpense
+	date    time.Time
+	created models.Expense
+	err     error // user facing error, e.g. exceeded limit
+}
+
+func (r *occurrenceResult) text() string {
+	if r.err != nil {
+		return fmt.Sprintf("Recurring expense #%d %s %v for %s was skipped: %v",
+			r.rec.ID, r.rec.Category, r.rec.Amount, r.date.Format(dateLayout), r.err)
+	}
+	return fmt.Sprintf("Recurring expense #%d created expense #%d %s %v %s %s",
+		r.rec.ID, r.created.ID, r.created.Category, r.created.Amount, r.date.Format(dateLayout), r.created.Comment)
+}
+
+// processDueRecurringExpenses creates expenses for all occurrences not after now, including ones missed during downtime.
+// Each occurrence is processed in its own transaction together with moving the next occurrence date,
+// so the same occurrence is never created twice.
+func (u *UseCase) processDueRecurringExpenses(ctx context.Context, now time.Time, handle func(res occurrenceResult)) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "processDueRecurringExpenses")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+
+	due, err := u.repo.GetDueRecurringExpenses(ctx, now)
+	if err != nil {
+		return errors.Wrap(err, "failed to get due recurring expenses")
+	}
+	span.SetTag(dueCountSpanTagKey, len(due))
+	var firstErr error
+	for _, rec := range due {
+		// one broken recurring expense must not block others
+		if err := u.processRecurringExpense(ctx, rec, now, handle); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (u *UseCase) processRecurringExpense(
+	ctx context.Context,
+	rec models.RecurringExpense,
+	now time.Time,
+	handle func(res occurrenceResult),
+) error {
+	loc, err := u.userRepo.GetUserTimeZone(ctx, rec.UserID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get user time zone by userID=%d", rec.UserID)
+	}
+	// occurrences are midnights in the user time zone
+	for date := rec.NextDate.In(loc); !date.After(now); date = rec.Rule.NextOccurrence(date) {
+		res, err := u.processOccurrence(ctx, rec, date)
+		if err != nil {
+			if errors.Is(err, recurring.ErrAlreadyAdvanced) {
+				return nil // processed concurrently, paused or deleted
+			}
+			return errors.Wrapf(err, "failed to process recurring expenseID=%d occurrence at %v", rec.ID, date)
+		}
+		handle(res)
+	}
+	return nil
+}
+
+func (u *UseCase) processOccurrence(ctx context.Context, rec models.RecurringExpense, date time.Time) (_ occurrenceResult, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "processOccurrence")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, rec.UserID)
+	span.SetTag(recurringExpenseIDSpanTagKey, rec.ID)
+
+	res := occurrenceResult{rec: rec, date: date}
+	err = u.repo.Isolated(ctx, func(ctx context.Context) error {
+		if err := u.repo.AdvanceRecurringExpense(ctx, rec.ID, date, rec.Rule.NextOccurrence(date)); err != nil {
+			return err
+		}
+		created, err := u.expUC.AddExpense(ctx, rec.UserID, rec.Expense(date))
+		switch {
+		case errors.Is(err, expense.ErrExpensesLimitExcess):
+			res.err = errors.Cause(err) // the occurrence is skipped, but the user is notified about exceeded limits
+			return nil
+		case err != nil:
+			return errors.Wrap(err, "failed to add expense")
+		}
+		res.created = created
+		return nil
+	})
+	if err != nil {
+		return occurrenceResult{}, err
+	}
+	return res, nil
+}
+
+// RunScheduler creates due recurring expenses right after the start and then every interval, users are notified by sender.
+func (u *UseCase) RunScheduler(
+	ctx context.Context,
+	logger *zap.Logger,
+	interval time.Duration,
+	sender recurring.MessageSender,
+) (<-chan struct{}, error) {
+	if interval <= 0 {
+		return nil, errors.New("negative or zero recurring expenses scheduler interval duration")
+	}
+	process := func(now time.Time) {
+		err := u.processDueRecurringExpenses(ctx, now, func(res occurrenceResult) {
+			chatID := int64(res.rec.UserID) // private chat ID equals to the user ID
+			if err := sender.SendMessage(chatID, res.text()); err != nil {
+				logger.Error("Failed to notify user about recurring expense",
+					zap.Int64("user_id", int64(res.rec.UserID)),
+					zap.Int64("recurring_expense_id", int64(res.rec.ID)),
+					zap.Error(err),
+				)
+			}
+		})
+		if err != nil {
+			logger.Error("Error occurred in recurring expenses scheduler", zap.Error(err))
+		}
+	}
+	worker := func(done chan<- struct{}) {
+		ticker := time.NewTicker(interval)
+		defer func() {
+			ticker.Stop()
+			close(done)
+			logger.Info("Recurring expenses scheduler successfully stopped")
+		}()
+		logger.Info("Staring recurring expenses scheduler with specific interval", zap.Duration("interval", interval))
+		process(time.Now()) // catch up occurrences missed while the bot was down
+		for {
+			select {
+			case tick := <-ticker.C:
+				process(tick)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+	done := make(chan struct{})
+	go worker(done)
+	return done, nil
+}
@@ -0,0 +1,158 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense"
+	expenseInMemRepo "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense/repository/inmemory"
+	expenseUseCase "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/expense/usecase"
+	exrateInMemRepo "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/exrate/repository/inmemory"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
+	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/recurring"
+	recurringInMemRepo "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/recurring/repository/inmemory"
+	userInMemRepo "gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/user/repository/inmemory"
+)
+
+const (
+	userID   = models.UserID(10)
+	baseCurr = models.CurrencyCode("RUB")
+)
+
+func newUC(t *testing.T, u models.User, limits ...models.Limit) (*UseCase, *expenseUseCase.UseCase) {
+	ctx := context.Background()
+
+	userRepo, err := userInMemRepo.New()
+	require.NoError(t, err)
+	_, err = userRepo.CreateUser(ctx, u)
+	require.NoError(t, err)
+	err = userRepo.SetUserLimits(ctx, u.ID, limits)
+	require.NoError(t, err)
+	expRepo, err := expenseInMemRepo.New()
+	require.NoError(t, err)
+	ratesRepo, err := exrateInMemRepo.New()
+	require.NoError(t, err)
+	expUC, err := expenseUseCase.New(baseCurr, expRepo, userRepo, ratesRepo)
+	require.NoError(t, err)
+
+	repo, err := recurringInMemRepo.New()
+	require.NoError(t, err)
+	uc, err := New(repo, expUC, userRepo)
+	require.NoError(t, err)
+	return uc, expUC
+}
+
+func collectResults(t *testing.T, uc *UseCase, now time.Time) []occurrenceResult {
+	var out []occurrenceResult
+	err := uc.processDueRecurringExpenses(context.Background(), now, func(res occurrenceResult) {
+		out = append(out, res)
+	})
+	require.NoError(t, err)
+	return out
+}
+
+func TestUseCase_ProcessDueRecurringExpenses(t *testing.T) {
+	ctx := context.Background()
+	uc, expUC := newUC(t, models.NewUser(userID, baseCurr))
+	now := time.Now().UTC()
+	today := models.StartOfDay(now)
+
+	rec, err := uc.repo.AddRecurringExpense(ctx, models.RecurringExpense{
+		UserID:   userID,
+		Category: "rent",
+		Amount:   decimal.NewFromInt(100),
+		Rule:     models.RecurrenceRule{Kind: models.RecurrenceEveryDays, Value: 3},
+		NextDate: today.AddDate(0, 0, -7), // the bot was down for a week
+	})
+	require.NoError(t, err)
+
+	results := collectResults(t, uc, now)
+	require.Len(t, results, 3)
+	for i, res := range results {
+		assert.NoError(t, res.err)
+		assert.Equal(t, today.AddDate(0, 0, -7+3*i), res.date)
+	}
+	// occurrences are never created twice
+	require.Empty(t, collectResults(t, uc, now))
+
+	expenses, err := expUC.GetExpensesAscendSinceTill(ctx, userID, today.AddDate(0, 0, -7), today, "", "", 10)
+	require.NoError(t, err)
+	require.Len(t, expenses, 3)
+
+	recs, err := uc.GetRecurringExpenses(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	assert.Equal(t, today.AddDate(0, 0, 2), recs[0].NextDate)
+
+	err = uc.PauseRecurringExpense(ctx, userID, rec.ID)
+	require.NoError(t, err)
+	require.Empty(t, collectResults(t, uc, now.AddDate(0, 0, 7)))
+	err = uc.PauseRecurringExpense(ctx, userID, rec.ID)
+	require.ErrorIs(t, err, recurring.ErrAlreadyPaused)
+}
+
+func TestUseCase_ProcessDueRecurringExpensesOverLimit(t *testing.T) {
+	ctx := context.Background()
+	limit := decimal.NewFromInt(150)
+	uc, _ := newUC(t, models.NewUser(userID, baseCurr), models.Limit{Period: models.LimitPeriodMonth, Amount: limit})
+	now := time.Now().UTC()
+
+	_, err := uc.repo.AddRecurringExpense(ctx, models.RecurringExpense{
+		UserID:   userID,
+		Category: "rent",
+		Amount:   decimal.NewFromInt(100),
+		Rule:     models.RecurrenceRule{Kind: models.RecurrenceEveryDays, Value: 1},
+		NextDate: models.StartOfDay(now),
+	})
+	require.NoError(t, err)
+
+	results := collectResults(t, uc, now)
+	require.Len(t, results, 1)
+	require.NoError(t, results[0].err)
+
+	// the second occurrence exceeds the limit, it is skipped but reported
+	results = collectResults(t, uc, now.AddDate(0, 0, 1))
+	require.Len(t, results, 1)
+	require.ErrorIs(t, results[0].err, expense.ErrExpensesLimitExcess)
+	require.Empty(t, collectResults(t, uc, now.AddDate(0, 0, 1)))
+}
+
+func TestUseCase_AddAndResumeRecurringExpense(t *testing.T) {
+	ctx := context.Background()
+	uc, _ := newUC(t, models.NewUser(userID, baseCurr))
+	today := models.StartOfDay(time.Now().UTC())
+
+	rec, err := uc.AddRecurringExpense(ctx, userID, models.RecurringExpense{
+		Category: "gym",
+		Amount:   decimal.NewFromInt(100),
+		Rule:     models.RecurrenceRule{Kind: models.RecurrenceEveryDays, Value: 1},
+	})
+	require.NoError(t, err)
+	require.Equal(t, today, rec.NextDate)
+
+	_, err = uc.ResumeRecurringExpense(ctx, userID, rec.ID)
+	require.ErrorIs(t, err, recurring.ErrAlreadyActive)
+
+	err = uc.PauseRecurringExpense(ctx, userID, rec.ID)
+	require.NoError(t, err)
+	resumed, err := uc.ResumeRecurringExpense(ctx, userID, rec.ID)
+	require.NoError(t, err)
+	require.False(t, resumed.Paused)
+	require.False(t, resumed.NextDate.Before(today))
+
+	_, err = uc.AddRecurringExpense(ctx, userID, models.RecurringExpense{
+		Category: "gym",
+		Amount:   decimal.NewFromInt(100),
+		Rule:     models.RecurrenceRule{Kind: models.RecurrenceMonthly, Value: 0},
+	})
+	require.ErrorIs(t, err, models.ErrRecurrenceRuleInvalid)
+
+	err = uc.DeleteRecurringExpense(ctx, userID, rec.ID)
+	require.NoError(t, err)
+	err = uc.DeleteRecurringExpense(ctx, userID, rec.ID)
+	require.ErrorIs(t, err, recurring.ErrDoesNotExist)
+}
@@ -2,25 +2,41 @@ package inmemory
 
 import (
 	"context"
+	"sort"
 	"sync"
+	"time"
 
-	"github.com/shopspring/decimal"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/user"
 )
 
+type limitKey struct {
+	period   models.LimitPeriod
+	category models.ExpenseCategory
+}
+
 type Repository struct {
-	mu      *sync.RWMutex
-	storage map[models.UserID]models.User
+	mu         *sync.RWMutex
+	isolatedMu *sync.Mutex
+	storage    map[models.UserID]models.User
+	limits     map[models.UserID]map[limitKey]models.Limit
 }
 
 func New() (*Repository, error) {
 	return &Repository{
-		mu:      &sync.RWMutex{},
-		storage: make(map[models.UserID]models.User),
+		mu:         &sync.RWMutex{},
+		isolatedMu: &sync.Mutex{},
+		storage:    make(map[models.UserID]models.User),
+		limits:     make(map[models.UserID]map[limitKey]models.Limit),
 	}, nil
 }
 
+func (r *Repository) Isolated(ctx context.Context, callback func(ctx context.Context) error) error {
+	r.isolatedMu.Lock()
+	defer r.isolatedMu.Unlock()
+	return callback(ctx)
+}
+
 func (r *Repository) CreateUser(ctx context.Context, u models.User) (models.User, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -65,7 +81,7 @@ func (r *Repository) GetUserCurrency(ctx context.Context, id models.UserID) (mod
 	return u.SelectedCurrency, nil
 }
 
-func (r *Repository) SetUserMonthlyLimit(ctx context.Context, id models.UserID, limit *decimal.Decimal) error {
+func (r *Repository) SetUserTimeZone(ctx context.Context, id models.UserID, loc *time.Location) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -73,12 +89,12 @@ func (r *Repository) SetUserMonthlyLimit(ctx context.Context, id models.UserID,
 	if !ok {
 		return user.ErrDoesNotExist
 	}
-	u.MonthlyLimit = limit
+	u.TimeZone = loc
 	r.storage[id] = u
 	return nil
 }
 
-func (r *Repository) GetUserMonthlyLimit(ctx context.Context, id models.UserID) (*decimal.Decimal, error) {
+func (r *Repository) GetUserTimeZone(ctx context.Context, id models.UserID) (*time.Location, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -86,5 +102,110 @@ func (r *Repository) GetUserMonthlyLimit(ctx context.Context, id models.UserID)
 	if !ok {
 		return nil, user.ErrDoesNotExist
 	}
-	return u.MonthlyLimit, nil
+	return u.Location(), nil
+}
+
+func (r *Repository) SetUserPeriodStartDay(ctx context.Context, id models.UserID, day int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.storage[id]
+	if !ok {
+		return user.ErrDoesNotExist
+	}
+	u.PeriodStartDay = day
+	r.storage[id] = u
+	return nil
+}
+
+func (r *Repository) GetUserPeriodStartDay(ctx context.Context, id models.UserID) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.storage[id]
+	if !ok {
+		return 0, user.ErrDoesNotExist
+	}
+	return u.PeriodStartDay, nil
+}
+
+func (r *Repository) SetUserLimitMode(ctx context.Context, id models.UserID, mode models.LimitMode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.storage[id]
+	if !ok {
+		return user.ErrDoesNotExist
+	}
+	u.LimitMode = mode
+	r.storage[id] = u
+	return nil
+}
+
+func (r *Repository) GetUserLimitMode(ctx context.Context, id models.UserID) (models.LimitMode, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.storage[id]
+	if !ok {
+		return "", user.ErrDoesNotExist
+	}
+	return u.LimitMode, nil
+}
+
+func (r *Repository) SetUserLimits(ctx context.Context, id models.UserID, limits []models.Limit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.storage[id]; !ok {
+		return user.ErrDoesNotExist
+	}
+	userLimits, ok := r.limits[id]
+	if !ok {
+		userLimits = make(map[limitKey]models.Limit)
+		r.limits[id] = userLimits
+	}
+	for _, limit := range limits {
+		key := limitKey{period: limit.Period, category: limit.Category}
+		// the rollover keeps its start if it was already enabled
+		if old, ok := userLimits[key]; ok && old.Rollover != nil && limit.Rollover != nil {
+			limit.Rollover = &models.Rollover{Since: old.Rollover.Since, Cap: limit.Rollover.Cap}
+		}
+		userLimits[key] = limit
+	}
+	return nil
+}
+
+func (r *Repository) DeleteUserLimit(
+	ctx context.Context,
+	id models.UserID,
+	period models.LimitPeriod,
+	category models.ExpenseCategory,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := limitKey{period: period, category: category}
+	if _, ok := r.limits[id][key]; !ok {
+		return user.ErrLimitDoesNotExist
+	}
+	delete(r.limits[id], key)
+	return nil
+}
+
+func (r *Repository) GetUserLimits(ctx context.Context, id models.UserID) ([]models.Limit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]models.Limit, 0, len(r.limits[id]))
+	for _, limit := range r.limits[id] {
+		out = append(out, limit)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if a, b := out[i].Period.Order(), out[j].Period.Order(); a != b {
+			return a < b
+		}
+		return out[i].Category < out[j].Category
+	})
+	return out, nil
 }
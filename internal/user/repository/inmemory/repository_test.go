@@ -3,6 +3,7 @@ package inmemory
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
@@ -139,84 +140,114 @@ func TestRepository_GetUserCurrency(t *testing.T) {
 	}
 }
 
-func TestRepository_GetUserMonthlyLimit(t *testing.T) {
+func TestRepository_SetUserLimits(t *testing.T) {
 	ctx := context.Background()
 	u := defaultUser
-	expectedLimit := decimal.NewFromInt(42)
+	weekly := models.Limit{Period: models.LimitPeriodWeek, Amount: decimal.NewFromInt(42), Currency: "USD"}
+	monthlyFood := models.Limit{Period: models.LimitPeriodMonth, Category: "food", Amount: decimal.NewFromInt(100)}
+	monthly := models.Limit{Period: models.LimitPeriodMonth, Amount: decimal.NewFromInt(300)}
+	rolloverSince := time.Date(2022, time.December, 1, 0, 0, 0, 0, time.UTC)
+	withRollover := func(limit models.Limit, since time.Time) models.Limit {
+		limit.Rollover = &models.Rollover{Since: since}
+		return limit
+	}
 
 	tests := []struct {
 		repoFn      repoFn
-		limit       *decimal.Decimal
+		limits      []models.Limit
+		expected    []models.Limit
 		expectedErr error
 	}{
 		{
-			repoFn:      func(t *testing.T) *Repository { return newRepoWithUser(t, u) },
-			limit:       nil,
-			expectedErr: nil,
+			repoFn:   func(t *testing.T) *Repository { return newRepoWithUser(t, u) },
+			limits:   nil,
+			expected: []models.Limit{},
+		},
+		{
+			repoFn:   func(t *testing.T) *Repository { return newRepoWithUser(t, u) },
+			limits:   []models.Limit{monthlyFood, monthly, weekly},
+			expected: []models.Limit{weekly, monthly, monthlyFood},
 		},
 		{
 			repoFn: func(t *testing.T) *Repository {
-				u := u
-				u.MonthlyLimit = &expectedLimit
-				return newRepoWithUser(t, u)
+				r := newRepoWithUser(t, u)
+				require.NoError(t, r.SetUserLimits(ctx, u.ID, []models.Limit{weekly, monthly}))
+				return r
 			},
-			limit:       &expectedLimit,
-			expectedErr: nil,
+			limits:   []models.Limit{{Period: models.LimitPeriodWeek, Amount: decimal.NewFromInt(10)}},
+			expected: []models.Limit{{Period: models.LimitPeriodWeek, Amount: decimal.NewFromInt(10)}, monthly},
+		},
+		{
+			// the rollover keeps its start when the limit is changed
+			repoFn: func(t *testing.T) *Repository {
+				r := newRepoWithUser(t, u)
+				require.NoError(t, r.SetUserLimits(ctx, u.ID, []models.Limit{withRollover(monthly, rolloverSince)}))
+				return r
+			},
+			limits:   []models.Limit{withRollover(monthly, rolloverSince.AddDate(0, 1, 0))},
+			expected: []models.Limit{withRollover(monthly, rolloverSince)},
 		},
 		{
 			repoFn:      newRepo,
-			limit:       nil,
+			limits:      []models.Limit{weekly},
 			expectedErr: user.ErrDoesNotExist,
 		},
 	}
 	for _, test := range tests {
 		r := test.repoFn(t)
-		limit, err := r.GetUserMonthlyLimit(ctx, u.ID)
+		err := r.SetUserLimits(ctx, u.ID, test.limits)
 		if test.expectedErr != nil {
 			require.Equal(t, err, test.expectedErr)
 		} else {
 			require.NoError(t, err)
-			require.Equal(t, test.limit, limit)
+			limits, err := r.GetUserLimits(ctx, u.ID)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, limits)
 		}
 	}
 }
 
-func TestRepository_SetUserMonthlyLimit(t *testing.T) {
+func TestRepository_DeleteUserLimit(t *testing.T) {
 	ctx := context.Background()
 	u := defaultUser
-	expectedLimit := decimal.NewFromInt(42)
+	monthlyFood := models.Limit{Period: models.LimitPeriodMonth, Category: "food", Amount: decimal.NewFromInt(100)}
+	monthly := models.Limit{Period: models.LimitPeriodMonth, Amount: decimal.NewFromInt(300)}
+	repoFn := func(t *testing.T) *Repository {
+		r := newRepoWithUser(t, u)
+		require.NoError(t, r.SetUserLimits(ctx, u.ID, []models.Limit{monthly, monthlyFood}))
+		return r
+	}
 
 	tests := []struct {
-		repoFn      repoFn
-		limit       *decimal.Decimal
+		period      models.LimitPeriod
+		category    models.ExpenseCategory
+		expected    []models.Limit
 		expectedErr error
 	}{
 		{
-			repoFn:      func(t *testing.T) *Repository { return newRepoWithUser(t, u) },
-			limit:       nil,
-			expectedErr: nil,
+			period:   models.LimitPeriodMonth,
+			category: "food",
+			expected: []models.Limit{monthly},
 		},
 		{
-			repoFn:      func(t *testing.T) *Repository { return newRepoWithUser(t, u) },
-			limit:       &expectedLimit,
-			expectedErr: nil,
+			period:   models.LimitPeriodMonth,
+			expected: []models.Limit{monthlyFood},
 		},
 		{
-			repoFn:      newRepo,
-			limit:       nil,
-			expectedErr: user.ErrDoesNotExist,
+			period:      models.LimitPeriodWeek,
+			expectedErr: user.ErrLimitDoesNotExist,
 		},
 	}
 	for _, test := range tests {
-		r := test.repoFn(t)
-		err := r.SetUserMonthlyLimit(ctx, u.ID, test.limit)
+		r := repoFn(t)
+		err := r.DeleteUserLimit(ctx, u.ID, test.period, test.category)
 		if test.expectedErr != nil {
 			require.Equal(t, err, test.expectedErr)
 		} else {
 			require.NoError(t, err)
-			limit, err := r.GetUserMonthlyLimit(ctx, u.ID)
+			limits, err := r.GetUserLimits(ctx, u.ID)
 			require.NoError(t, err)
-			require.Equal(t, test.limit, limit)
+			require.Equal(t, test.expected, limits)
 		}
 	}
 }
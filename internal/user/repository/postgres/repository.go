@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
@@ -19,10 +20,15 @@ func New(db postgres.DBDoer) (*Repository, error) {
 	return &Repository{db: db}, nil
 }
 
+func (r *Repository) Isolated(ctx context.Context, callback func(ctx context.Context) error) error {
+	return r.db.DoIsolated(ctx, nil, callback)
+}
+
 func (r *Repository) CreateUser(ctx context.Context, u models.User) (models.User, error) {
 	res, err := r.db.Do(ctx).ExecContext(ctx,
-		"INSERT INTO users(id, currency, monthly_limit) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING",
-		u.ID, u.SelectedCurrency, u.MonthlyLimit,
+		`INSERT INTO users(id, currency, timezone, period_start_day, limit_mode)
+		VALUES ($1, $2, $3, $4, $5) ON CONFLICT DO NOTHING`,
+		u.ID, u.SelectedCurrency, u.Location().String(), u.PeriodStartDay, u.LimitMode,
 	)
 	if err != nil {
 		return models.User{}, errors.Wrapf(err, "failed to create userID=%d", u.ID)
@@ -73,14 +79,14 @@ func (r *Repository) GetUserCurrency(ctx context.Context, id models.UserID) (mod
 	return curr, nil
 }
 
-func (r *Repository) SetUserMonthlyLimit(ctx context.Context, id models.UserID, limit *decimal.Decimal) error {
-	res, err := r.db.Do(ctx).ExecContext(ctx, "UPDATE users SET monthly_limit = $1 WHERE id = $2", limit, id)
+func (r *Repository) SetUserTimeZone(ctx context.Context, id models.UserID, loc *time.Location) error {
+	res, err := r.db.Do(ctx).ExecContext(ctx, "UPDATE users SET timezone = $1 WHERE id = $2", loc.String(), id)
 	if err != nil {
-		return errors.Wrapf(err, "failed to set montyly limit %v for userID=%d", limit, id)
+		return errors.Wrapf(err, "failed to set time zone %q for userID=%d", loc, id)
 	}
 	affected, err := res.RowsAffected()
 	if err != nil {
-		return errors.Wrapf(err, "failed to set montyly limit %v for userID=%d", limit, id)
+		return errors.Wrapf(err, "failed to set time zone %q for userID=%d", loc, id)
 	}
 	if affected == 0 {
 		return user.ErrDoesNotExist
@@ -88,14 +94,174 @@ func (r *Repository) SetUserMonthlyLimit(ctx context.Context, id models.UserID,
 	return nil
 }
 
-func (r *Repository) GetUserMonthlyLimit(ctx context.Context, id models.UserID) (*decimal.Decimal, error) {
-	var limit *decimal.Decimal
-	err := r.db.Do(ctx).QueryRowContext(ctx, "SELECT monthly_limit FROM users WHERE id = $1", id).Scan(&limit)
+func (r *Repository) GetUserTimeZone(ctx context.Context, id models.UserID) (*time.Location, error) {
+	var name string
+	err := r.db.Do(ctx).QueryRowContext(ctx, "SELECT timezone FROM users WHERE id = $1", id).Scan(&name)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, user.ErrDoesNotExist
 		}
-		return nil, errors.Wrapf(err, "failed to get monthly limit for userID=%d", id)
+		return nil, errors.Wrapf(err, "failed to get time zone for userID=%d", id)
+	}
+	loc, err := models.ParseTimeZone(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse time zone for userID=%d", id)
+	}
+	return loc, nil
+}
+
+func (r *Repository) SetUserPeriodStartDay(ctx context.Context, id models.UserID, day int) error {
+	res, err := r.db.Do(ctx).ExecContext(ctx, "UPDATE users SET period_start_day = $1 WHERE id = $2", day, id)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set period start day %d for userID=%d", day, id)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "failed to set period start day %d for userID=%d", day, id)
+	}
+	if affected == 0 {
+		return user.ErrDoesNotExist
+	}
+	return nil
+}
+
+func (r *Repository) GetUserPeriodStartDay(ctx context.Context, id models.UserID) (int, error) {
+	var day int
+	err := r.db.Do(ctx).QueryRowContext(ctx, "SELECT period_start_day FROM users WHERE id = $1", id).Scan(&day)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, user.ErrDoesNotExist
+		}
+		return 0, errors.Wrapf(err, "failed to get period start day for userID=%d", id)
+	}
+	return day, nil
+}
+
+func (r *Repository) SetUserLimitMode(ctx context.Context, id models.UserID, mode models.LimitMode) error {
+	res, err := r.db.Do(ctx).ExecContext(ctx, "UPDATE users SET limit_mode = $1 WHERE id = $2", mode, id)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set limit mode %q for userID=%d", mode, id)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "failed to set limit mode %q for userID=%d", mode, id)
+	}
+	if affected == 0 {
+		return user.ErrDoesNotExist
+	}
+	return nil
+}
+
+func (r *Repository) GetUserLimitMode(ctx context.Context, id models.UserID) (models.LimitMode, error) {
+	var mode models.LimitMode
+	err := r.db.Do(ctx).QueryRowContext(ctx, "SELECT limit_mode FROM users WHERE id = $1", id).Scan(&mode)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", user.ErrDoesNotExist
+		}
+		return "", errors.Wrapf(err, "failed to get limit mode for userID=%d", id)
+	}
+	return mode, nil
+}
+
+func (r *Repository) SetUserLimits(ctx context.Context, id models.UserID, limits []models.Limit) error {
+	// the rollover keeps its start if it was already enabled
+	const query = `
+		INSERT INTO limits(user_id, period, category, amount, currency, rollover_since, rollover_cap)
+		SELECT id, $2, $3, $4, $5, $6, $7 FROM users WHERE id = $1
+		ON CONFLICT (user_id, period, category) DO UPDATE SET
+			amount = excluded.amount,
+			currency = excluded.currency,
+			rollover_since = CASE WHEN excluded.rollover_since IS NULL THEN NULL
+				ELSE COALESCE(limits.rollover_since, excluded.rollover_since) END,
+			rollover_cap = excluded.rollover_cap`
+	return r.db.DoIsolated(ctx, nil, func(ctx context.Context) error {
+		for _, limit := range limits {
+			var (
+				currency      *models.CurrencyCode
+				rolloverSince *time.Time
+				rolloverCap   *decimal.Decimal
+			)
+			if limit.Currency != "" {
+				currency = &limit.Currency
+			}
+			if limit.Rollover != nil {
+				since := limit.Rollover.Since.UTC()
+				rolloverSince, rolloverCap = &since, limit.Rollover.Cap
+			}
+			res, err := r.db.Do(ctx).ExecContext(ctx, query,
+				id, limit.Period, limit.Category, limit.Amount, currency, rolloverSince, rolloverCap,
+			)
+			if err != nil {
+				return errors.Wrapf(err, "failed to set %s %s for userID=%d", limit.Name(), limit.AmountString(), id)
+			}
+			affected, err := res.RowsAffected()
+			if err != nil {
+				return errors.Wrapf(err, "failed to set %s %s for userID=%d", limit.Name(), limit.AmountString(), id)
+			}
+			if affected == 0 {
+				return user.ErrDoesNotExist
+			}
+		}
+		return nil
+	})
+}
+
+func (r *Repository) DeleteUserLimit(
+	ctx context.Context,
+	id models.UserID,
+	period models.LimitPeriod,
+	category models.ExpenseCategory,
+) error {
+	res, err := r.db.Do(ctx).ExecContext(ctx,
+		"DELETE FROM limits WHERE user_id = $1 AND period = $2 AND category = $3", id, period, category,
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete %s limit of category %q for userID=%d", period, category, id)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete %s limit of category %q for userID=%d", period, category, id)
+	}
+	if affected == 0 {
+		return user.ErrLimitDoesNotExist
+	}
+	return nil
+}
+
+func (r *Repository) GetUserLimits(ctx context.Context, id models.UserID) (_ []models.Limit, err error) {
+	rows, err := r.db.Do(ctx).QueryContext(ctx,
+		`SELECT period, category, amount, currency, rollover_since, rollover_cap FROM limits WHERE user_id = $1
+		ORDER BY array_position(ARRAY['week', 'month', 'quarter', 'year'], period::TEXT), category`, id,
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get limits for userID=%d", id)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = errors.Wrap(closeErr, "failed to close rows")
+		}
+	}()
+	var out []models.Limit
+	for rows.Next() {
+		var (
+			limit         models.Limit
+			currency      sql.NullString
+			rolloverSince sql.NullTime
+			rolloverCap   *decimal.Decimal
+		)
+		err := rows.Scan(&limit.Period, &limit.Category, &limit.Amount, &currency, &rolloverSince, &rolloverCap)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to scan limit for userID=%d", id)
+		}
+		limit.Currency = models.CurrencyCode(currency.String)
+		if rolloverSince.Valid {
+			limit.Rollover = &models.Rollover{Since: rolloverSince.Time, Cap: rolloverCap}
+		}
+		out = append(out, limit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to get limits for userID=%d", id)
 	}
-	return limit, nil
+	return out, nil
 }
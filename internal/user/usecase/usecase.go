@@ -2,19 +2,24 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/pkg/errors"
-	"github.com/shopspring/decimal"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/user"
 )
 
 const (
-	userIDSpanTagKey       = "user_id"
-	currencyCodeSpanTagKey = "currency_code"
-	monthlyLimitSpanTagKey = "monthly_limit"
+	userIDSpanTagKey         = "user_id"
+	currencyCodeSpanTagKey   = "currency_code"
+	timeZoneSpanTagKey       = "time_zone"
+	periodStartDaySpanTagKey = "period_start_day"
+	categorySpanTagKey       = "category"
+	limitModeSpanTagKey      = "limit_mode"
+	limitPeriodSpanTagKey    = "limit_period"
+	limitsCountSpanTagKey    = "limits_count"
 )
 
 type UseCase struct {
@@ -74,30 +79,165 @@ func (u *UseCase) GetUserCurrency(ctx context.Context, id models.UserID) (_ mode
 	return u.repo.GetUserCurrency(ctx, id)
 }
 
-func (u *UseCase) SetUserMonthlyLimit(ctx context.Context, id models.UserID, limit *decimal.Decimal) (err error) {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "SetUserMonthlyLimit")
+func (u *UseCase) SetUserTimeZone(ctx context.Context, id models.UserID, loc *time.Location) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SetUserTimeZone")
 	defer func() {
 		ext.Error.Set(span, err != nil)
 		span.Finish()
 	}()
 	span.SetTag(userIDSpanTagKey, id)
-	if limit != nil {
-		span.SetTag(monthlyLimitSpanTagKey, limit.String())
+	span.SetTag(timeZoneSpanTagKey, loc.String())
+
+	return u.repo.SetUserTimeZone(ctx, id, loc)
+}
+
+func (u *UseCase) GetUserTimeZone(ctx context.Context, id models.UserID) (_ *time.Location, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "GetUserTimeZone")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, id)
+
+	return u.repo.GetUserTimeZone(ctx, id)
+}
+
+func (u *UseCase) SetUserPeriodStartDay(ctx context.Context, id models.UserID, day int) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SetUserPeriodStartDay")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, id)
+	span.SetTag(periodStartDaySpanTagKey, day)
+
+	if err := models.ValidateUserPeriodStartDay(day); err != nil {
+		return errors.Wrap(err, "user period start day validation failed")
 	}
+	return u.repo.SetUserPeriodStartDay(ctx, id, day)
+}
+
+func (u *UseCase) GetUserPeriodStartDay(ctx context.Context, id models.UserID) (_ int, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "GetUserPeriodStartDay")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, id)
+
+	return u.repo.GetUserPeriodStartDay(ctx, id)
+}
 
-	if err := models.ValidateUserMonthlyLimit(limit); err != nil {
-		return errors.Wrap(err, "user monthly limit validation failed")
+func (u *UseCase) SetUserLimitMode(ctx context.Context, id models.UserID, mode models.LimitMode) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SetUserLimitMode")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, id)
+	span.SetTag(limitModeSpanTagKey, mode)
+
+	if err := models.ValidateUserLimitMode(mode); err != nil {
+		return errors.Wrap(err, "user limit mode validation failed")
 	}
-	return u.repo.SetUserMonthlyLimit(ctx, id, limit)
+	return u.repo.SetUserLimitMode(ctx, id, mode)
+}
+
+func (u *UseCase) GetUserLimitMode(ctx context.Context, id models.UserID) (_ models.LimitMode, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "GetUserLimitMode")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, id)
+
+	return u.repo.GetUserLimitMode(ctx, id)
+}
+
+func (u *UseCase) SetUserLimits(ctx context.Context, id models.UserID, limits []models.Limit) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "SetUserLimits")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, id)
+	span.SetTag(limitsCountSpanTagKey, len(limits))
+
+	for i := range limits {
+		if err := limits[i].Validate(); err != nil {
+			return errors.Wrapf(err, "%s validation failed", limits[i].Name())
+		}
+	}
+	return u.repo.SetUserLimits(ctx, id, limits)
+}
+
+func (u *UseCase) UpdateUserLimits(
+	ctx context.Context,
+	id models.UserID,
+	limits, removed []models.Limit,
+) (notFound []models.Limit, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "UpdateUserLimits")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, id)
+	span.SetTag(limitsCountSpanTagKey, len(limits)+len(removed))
+
+	for i := range limits {
+		if err := limits[i].Validate(); err != nil {
+			return nil, errors.Wrapf(err, "%s validation failed", limits[i].Name())
+		}
+	}
+	err = u.repo.Isolated(ctx, func(ctx context.Context) error {
+		notFound = nil
+		if len(limits) != 0 {
+			if err := u.repo.SetUserLimits(ctx, id, limits); err != nil {
+				return err
+			}
+		}
+		for i := range removed {
+			err := u.repo.DeleteUserLimit(ctx, id, removed[i].Period, removed[i].Category)
+			switch {
+			case errors.Is(err, user.ErrLimitDoesNotExist):
+				notFound = append(notFound, removed[i])
+			case err != nil:
+				return errors.Wrapf(err, "failed to delete %s", removed[i].Name())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return notFound, nil
+}
+
+func (u *UseCase) DeleteUserLimit(
+	ctx context.Context,
+	id models.UserID,
+	period models.LimitPeriod,
+	category models.ExpenseCategory,
+) (err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "DeleteUserLimit")
+	defer func() {
+		ext.Error.Set(span, err != nil)
+		span.Finish()
+	}()
+	span.SetTag(userIDSpanTagKey, id)
+	span.SetTag(limitPeriodSpanTagKey, period)
+	span.SetTag(categorySpanTagKey, category)
+
+	return u.repo.DeleteUserLimit(ctx, id, period, category)
 }
 
-func (u *UseCase) GetUserMonthlyLimit(ctx context.Context, id models.UserID) (_ *decimal.Decimal, err error) {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "GetUserMonthlyLimit")
+func (u *UseCase) GetUserLimits(ctx context.Context, id models.UserID) (_ []models.Limit, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "GetUserLimits")
 	defer func() {
 		ext.Error.Set(span, err != nil)
 		span.Finish()
 	}()
 	span.SetTag(userIDSpanTagKey, id)
 
-	return u.repo.GetUserMonthlyLimit(ctx, id)
+	return u.repo.GetUserLimits(ctx, id)
 }
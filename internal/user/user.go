@@ -2,26 +2,46 @@ package user
 
 import (
 	"context"
+	"time"
 
 	"github.com/pkg/errors"
-	"github.com/shopspring/decimal"
 	"gitlab.ozon.dev/mr.eskov1/telegram-bot/internal/models"
 )
 
 var (
 	ErrAlreadyExists = errors.New("user already exists")
 	ErrDoesNotExist  = errors.New("user does not exist")
+
+	ErrLimitDoesNotExist = errors.New("limit does not exist")
 )
 
-type Repository interface {
+// Settings are the user settings and limits shared by the repository and the use case.
+type Settings interface {
 	CreateUser(ctx context.Context, u models.User) (models.User, error)
 	IsUserExists(ctx context.Context, id models.UserID) (bool, error)
 	ChangeUserCurrency(ctx context.Context, id models.UserID, currency models.CurrencyCode) error
 	GetUserCurrency(ctx context.Context, id models.UserID) (models.CurrencyCode, error)
-	SetUserMonthlyLimit(ctx context.Context, id models.UserID, limit *decimal.Decimal) error
-	GetUserMonthlyLimit(ctx context.Context, id models.UserID) (*decimal.Decimal, error)
+	SetUserTimeZone(ctx context.Context, id models.UserID, loc *time.Location) error
+	GetUserTimeZone(ctx context.Context, id models.UserID) (*time.Location, error)
+	SetUserPeriodStartDay(ctx context.Context, id models.UserID, day int) error
+	GetUserPeriodStartDay(ctx context.Context, id models.UserID) (int, error)
+	SetUserLimitMode(ctx context.Context, id models.UserID, mode models.LimitMode) error
+	GetUserLimitMode(ctx context.Context, id models.UserID) (models.LimitMode, error)
+	// SetUserLimits creates or replaces the limits with the same period and category atomically.
+	SetUserLimits(ctx context.Context, id models.UserID, limits []models.Limit) error
+	DeleteUserLimit(ctx context.Context, id models.UserID, period models.LimitPeriod, category models.ExpenseCategory) error
+	// GetUserLimits returns limits sorted by period from the shortest one and by category.
+	GetUserLimits(ctx context.Context, id models.UserID) ([]models.Limit, error)
+}
+
+type Repository interface {
+	Isolated(ctx context.Context, callback func(ctx context.Context) error) error
+	Settings
 }
 
 type UseCase interface {
-	Repository
+	Settings
+	// UpdateUserLimits sets the limits and removes the removed ones in one transaction.
+	// The removed limits which don't exist are returned, only their period and category are taken into account.
+	UpdateUserLimits(ctx context.Context, id models.UserID, limits, removed []models.Limit) (notFound []models.Limit, err error)
 }
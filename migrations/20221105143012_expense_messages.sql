@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE expenses
+    ADD PRIMARY KEY (id);
+
+CREATE TABLE expense_messages
+(
+    user_id    BIGINT NOT NULL,
+    message_id BIGINT NOT NULL,
+    expense_id BIGINT NOT NULL REFERENCES expenses (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY (user_id, message_id)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE expense_messages CASCADE;
+
+ALTER TABLE expenses
+    DROP CONSTRAINT expenses_pkey;
+
+-- +goose StatementEnd
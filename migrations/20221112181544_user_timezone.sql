@@ -0,0 +1,63 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE users
+    ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'UTC' CHECK ( timezone <> '' );
+
+ALTER TABLE expenses
+    ADD COLUMN date_tz TIMESTAMPTZ;
+
+-- existing dates are treated as midnights in the user time zone
+UPDATE expenses e
+SET date_tz = e.date::TIMESTAMP AT TIME ZONE u.timezone
+FROM users u
+WHERE u.id = e.user_id;
+
+DROP INDEX expenses_user_id_date_idx;
+
+ALTER TABLE expenses
+    DROP COLUMN date;
+
+ALTER TABLE expenses
+    RENAME COLUMN date_tz TO date;
+
+ALTER TABLE expenses
+    ALTER COLUMN date SET NOT NULL;
+
+CREATE INDEX expenses_user_id_date_idx ON expenses (user_id, date);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE expenses
+    ADD COLUMN date_only DATE;
+
+-- fixed offset zones are saved by Go as 'UTC+03:00', PostgreSQL reads such names as POSIX zones with the opposite sign,
+-- so the offset is applied as the interval following ISO 8601 sign convention
+UPDATE expenses e
+SET date_only = CASE
+    WHEN u.timezone ~ '^UTC[+-]' THEN (e.date AT TIME ZONE substr(u.timezone, 4)::INTERVAL)::DATE
+    ELSE (e.date AT TIME ZONE u.timezone)::DATE
+    END
+FROM users u
+WHERE u.id = e.user_id;
+
+DROP INDEX expenses_user_id_date_idx;
+
+ALTER TABLE expenses
+    DROP COLUMN date;
+
+ALTER TABLE expenses
+    RENAME COLUMN date_only TO date;
+
+ALTER TABLE expenses
+    ALTER COLUMN date SET NOT NULL;
+
+CREATE INDEX expenses_user_id_date_idx ON expenses (user_id, date);
+
+ALTER TABLE users
+    DROP COLUMN timezone;
+
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE users
+    ADD COLUMN period_start_day SMALLINT NOT NULL DEFAULT 1 CHECK ( period_start_day BETWEEN 1 AND 31 );
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE users
+    DROP COLUMN period_start_day;
+
+-- +goose StatementEnd
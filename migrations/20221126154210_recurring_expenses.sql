@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE recurring_expenses
+(
+    id         BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id    BIGINT         NOT NULL REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    category   VARCHAR(256)   NOT NULL CHECK ( category <> '' ),
+    amount     NUMERIC(25, 5) NOT NULL CHECK ( amount > 0 ),
+    comment    VARCHAR(4096)  NOT NULL,
+    rule_kind  VARCHAR(16)    NOT NULL CHECK ( rule_kind IN ('monthly', 'weekly', 'every') ),
+    rule_value INTEGER        NOT NULL,
+    next_date  TIMESTAMPTZ    NOT NULL,
+    paused     BOOLEAN        NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX recurring_expenses_user_id_idx ON recurring_expenses (user_id);
+
+CREATE INDEX recurring_expenses_next_date_idx ON recurring_expenses (next_date) WHERE NOT paused;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX recurring_expenses_next_date_idx;
+
+DROP INDEX recurring_expenses_user_id_idx;
+
+DROP TABLE recurring_expenses CASCADE;
+
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE category_budgets
+(
+    user_id  BIGINT         NOT NULL REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    category VARCHAR(256)   NOT NULL CHECK ( category <> '' ),
+    amount   NUMERIC(25, 5) NOT NULL CHECK ( amount >= 0 ),
+    PRIMARY KEY (user_id, category)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE category_budgets CASCADE;
+
+-- +goose StatementEnd
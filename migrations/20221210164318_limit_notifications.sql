@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE users
+    ADD COLUMN limit_mode VARCHAR(8) NOT NULL DEFAULT 'hard' CHECK ( limit_mode IN ('hard', 'soft') );
+
+CREATE TABLE limit_notifications
+(
+    user_id      BIGINT      NOT NULL REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    period_start TIMESTAMPTZ NOT NULL,
+    threshold    SMALLINT    NOT NULL CHECK ( threshold > 0 ),
+    PRIMARY KEY (user_id, period_start, threshold)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE limit_notifications CASCADE;
+
+ALTER TABLE users
+    DROP COLUMN limit_mode;
+
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- existing limits without currency are in the base currency
+ALTER TABLE users
+    ADD COLUMN monthly_limit_currency VARCHAR(8) CHECK ( monthly_limit_currency <> '' );
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE users
+    DROP COLUMN monthly_limit_currency;
+
+-- +goose StatementEnd
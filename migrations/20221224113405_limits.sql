@@ -0,0 +1,82 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- empty category means the limit of all the expenses
+CREATE TABLE limits
+(
+    user_id  BIGINT         NOT NULL REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    period   VARCHAR(8)     NOT NULL CHECK ( period IN ('week', 'month', 'quarter', 'year') ),
+    category VARCHAR(256)   NOT NULL DEFAULT '',
+    amount   NUMERIC(25, 5) NOT NULL CHECK ( amount >= 0 ),
+    currency VARCHAR(8) CHECK ( currency <> '' ),
+    PRIMARY KEY (user_id, period, category)
+);
+
+INSERT INTO limits(user_id, period, amount, currency)
+SELECT id, 'month', monthly_limit, monthly_limit_currency
+FROM users
+WHERE monthly_limit IS NOT NULL;
+
+INSERT INTO limits(user_id, period, category, amount)
+SELECT user_id, 'month', category, amount
+FROM category_budgets;
+
+ALTER TABLE users
+    DROP COLUMN monthly_limit,
+    DROP COLUMN monthly_limit_currency;
+
+DROP TABLE category_budgets CASCADE;
+
+ALTER TABLE limit_notifications
+    ADD COLUMN period   VARCHAR(8)   NOT NULL DEFAULT 'month',
+    ADD COLUMN category VARCHAR(256) NOT NULL DEFAULT '',
+    DROP CONSTRAINT limit_notifications_pkey,
+    ADD PRIMARY KEY (user_id, period, category, period_start, threshold);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE
+FROM limit_notifications
+WHERE period <> 'month'
+   OR category <> '';
+
+ALTER TABLE limit_notifications
+    DROP CONSTRAINT limit_notifications_pkey,
+    DROP COLUMN period,
+    DROP COLUMN category,
+    ADD PRIMARY KEY (user_id, period_start, threshold);
+
+CREATE TABLE category_budgets
+(
+    user_id  BIGINT         NOT NULL REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    category VARCHAR(256)   NOT NULL CHECK ( category <> '' ),
+    amount   NUMERIC(25, 5) NOT NULL CHECK ( amount >= 0 ),
+    PRIMARY KEY (user_id, category)
+);
+
+-- category limits in other currencies can't be restored
+INSERT INTO category_budgets(user_id, category, amount)
+SELECT user_id, category, amount
+FROM limits
+WHERE period = 'month'
+  AND category <> ''
+  AND currency IS NULL;
+
+ALTER TABLE users
+    ADD COLUMN monthly_limit          NUMERIC(25, 5) CHECK ( monthly_limit >= 0 ),
+    ADD COLUMN monthly_limit_currency VARCHAR(8) CHECK ( monthly_limit_currency <> '' );
+
+UPDATE users
+SET monthly_limit          = limits.amount,
+    monthly_limit_currency = limits.currency
+FROM limits
+WHERE limits.user_id = users.id
+  AND limits.period = 'month'
+  AND limits.category = '';
+
+DROP TABLE limits CASCADE;
+
+-- +goose StatementEnd
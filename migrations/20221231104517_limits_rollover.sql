@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- rollover is enabled if rollover_since is set, empty rollover_cap means no cap
+ALTER TABLE limits
+    ADD COLUMN rollover_since TIMESTAMPTZ,
+    ADD COLUMN rollover_cap   NUMERIC(25, 5) CHECK ( rollover_cap >= 0 );
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE limits
+    DROP COLUMN rollover_since,
+    DROP COLUMN rollover_cap;
+
+-- +goose StatementEnd
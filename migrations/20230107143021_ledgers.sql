@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ledger ID is the group chat ID, the ledger account settings, limits and expenses are stored as the user ones
+CREATE TABLE ledgers
+(
+    id          BIGINT       NOT NULL PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    title       VARCHAR(256) NOT NULL,
+    invite_code VARCHAR(32)  NOT NULL UNIQUE
+);
+
+-- members of group chats may never start the private chat with the bot, so they are not required to be users
+CREATE TABLE ledger_members
+(
+    ledger_id BIGINT       NOT NULL REFERENCES ledgers (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    user_id   BIGINT       NOT NULL,
+    name      VARCHAR(256) NOT NULL,
+    joined_at TIMESTAMPTZ  NOT NULL,
+    active    BOOLEAN      NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (ledger_id, user_id)
+);
+
+-- active ledger is used by the member in the private chat with the bot instead of the personal account
+CREATE UNIQUE INDEX ledger_members_active_user_id_idx ON ledger_members (user_id) WHERE active;
+
+-- NULL value means the author is unknown
+ALTER TABLE expenses
+    ADD COLUMN author_id BIGINT;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE expenses
+    DROP COLUMN author_id;
+
+DROP INDEX ledger_members_active_user_id_idx;
+
+DROP TABLE ledger_members CASCADE;
+
+DROP TABLE ledgers CASCADE;
+
+-- +goose StatementEnd
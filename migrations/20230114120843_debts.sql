@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE ledger_members
+    ADD COLUMN username VARCHAR(64) NOT NULL DEFAULT '';
+
+-- amounts are in the base currency, the repayment is stored as the debt of the creditor to the one who paid
+CREATE TABLE debts
+(
+    id          BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id     BIGINT         NOT NULL REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    debtor_id   BIGINT         NOT NULL,
+    creditor_id BIGINT         NOT NULL CHECK ( creditor_id <> debtor_id ),
+    amount      NUMERIC(25, 5) NOT NULL CHECK ( amount > 0 ),
+    kind        VARCHAR(16)    NOT NULL CHECK ( kind IN ('split', 'settlement') ),
+    date        TIMESTAMPTZ    NOT NULL,
+    comment     VARCHAR(4096)  NOT NULL
+);
+
+CREATE INDEX debts_user_id_idx ON debts (user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX debts_user_id_idx;
+
+DROP TABLE debts CASCADE;
+
+ALTER TABLE ledger_members
+    DROP COLUMN username;
+
+-- +goose StatementEnd
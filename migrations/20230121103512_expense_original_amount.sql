@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- the typed amount of existing expenses is unknown, so the columns are left empty for them
+ALTER TABLE expenses
+    ADD COLUMN original_amount   NUMERIC(25, 5) CHECK ( original_amount > 0 ),
+    ADD COLUMN original_currency VARCHAR(8) CHECK ( original_currency <> '' ),
+    ADD COLUMN rate              NUMERIC(16, 8) CHECK ( rate > 0 );
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE expenses
+    DROP COLUMN original_amount,
+    DROP COLUMN original_currency,
+    DROP COLUMN rate;
+
+-- +goose StatementEnd
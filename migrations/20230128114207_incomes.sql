@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE incomes
+(
+    id                BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id           BIGINT         NOT NULL REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    source            VARCHAR(256)   NOT NULL CHECK ( source <> '' ),
+    amount            NUMERIC(25, 5) NOT NULL CHECK ( amount > 0 ),
+    date              TIMESTAMPTZ    NOT NULL,
+    comment           VARCHAR(4096)  NOT NULL,
+    author_id         BIGINT,
+    original_amount   NUMERIC(25, 5) NOT NULL CHECK ( original_amount > 0 ),
+    original_currency currency_code,
+    rate              NUMERIC(16, 8) NOT NULL CHECK ( rate > 0 )
+);
+
+CREATE INDEX incomes_user_id_date_idx ON incomes (user_id, date);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX incomes_user_id_date_idx;
+
+DROP TABLE incomes CASCADE;
+
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE refunds
+(
+    id         BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    expense_id BIGINT         NOT NULL REFERENCES expenses (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    amount     NUMERIC(25, 5) NOT NULL CHECK ( amount > 0 ),
+    date       TIMESTAMPTZ    NOT NULL
+);
+
+CREATE INDEX refunds_expense_id_idx ON refunds (expense_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX refunds_expense_id_idx;
+
+DROP TABLE refunds CASCADE;
+
+-- +goose StatementEnd
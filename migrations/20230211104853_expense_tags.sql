@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE tags
+(
+    id      BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id BIGINT       NOT NULL REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    name    VARCHAR(256) NOT NULL CHECK ( name <> '' ),
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE expense_tags
+(
+    expense_id BIGINT NOT NULL REFERENCES expenses (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    tag_id     BIGINT NOT NULL REFERENCES tags (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY (expense_id, tag_id)
+);
+
+CREATE INDEX expense_tags_tag_id_idx ON expense_tags (tag_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX expense_tags_tag_id_idx;
+
+DROP TABLE expense_tags CASCADE;
+
+DROP TABLE tags CASCADE;
+
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE category_aliases
+(
+    user_id  BIGINT       NOT NULL REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    alias    VARCHAR(256) NOT NULL CHECK ( alias <> '' ),
+    category VARCHAR(256) NOT NULL CHECK ( category <> '' ),
+    PRIMARY KEY (user_id, alias)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE category_aliases CASCADE;
+
+-- +goose StatementEnd
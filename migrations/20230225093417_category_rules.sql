@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE category_rules
+(
+    id       BIGSERIAL PRIMARY KEY,
+    user_id  BIGINT       NOT NULL REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE,
+    pattern  VARCHAR(256) NOT NULL CHECK ( pattern <> '' ),
+    is_regex BOOLEAN      NOT NULL DEFAULT FALSE,
+    category VARCHAR(256) NOT NULL CHECK ( category <> '' )
+);
+
+CREATE INDEX category_rules_user_id_idx ON category_rules (user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE category_rules CASCADE;
+
+-- +goose StatementEnd
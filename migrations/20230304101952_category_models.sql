@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE category_models
+(
+    user_id BIGINT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- the empty token counts expenses of the category
+CREATE TABLE category_model_counts
+(
+    user_id  BIGINT       NOT NULL REFERENCES category_models (user_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    category VARCHAR(256) NOT NULL,
+    token    VARCHAR(256) NOT NULL,
+    count    BIGINT       NOT NULL,
+    PRIMARY KEY (user_id, category, token)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE category_model_counts CASCADE;
+DROP TABLE category_models CASCADE;
+
+-- +goose StatementEnd
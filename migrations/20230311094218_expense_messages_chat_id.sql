@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- message IDs are unique only within the chat, while members of the ledger add expenses from their private chats too
+ALTER TABLE expense_messages
+    ADD COLUMN chat_id BIGINT;
+
+-- the chat ID is equal to the account ID for personal accounts and ledgers of group chats
+UPDATE expense_messages
+SET chat_id = user_id;
+
+ALTER TABLE expense_messages
+    ALTER COLUMN chat_id SET NOT NULL,
+    DROP CONSTRAINT expense_messages_pkey,
+    ADD PRIMARY KEY (user_id, chat_id, message_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE
+FROM expense_messages m
+    USING expense_messages d
+WHERE m.user_id = d.user_id
+  AND m.message_id = d.message_id
+  AND m.chat_id > d.chat_id;
+
+ALTER TABLE expense_messages
+    DROP CONSTRAINT expense_messages_pkey,
+    DROP COLUMN chat_id,
+    ADD PRIMARY KEY (user_id, message_id);
+
+-- +goose StatementEnd